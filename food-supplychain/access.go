@@ -0,0 +1,186 @@
+package main
+
+import (
+	"encoding/json"
+
+	"github.com/deevotech/sc-chaincode.deevo.io/lib/ccconfig"
+	"github.com/deevotech/sc-chaincode.deevo.io/lib/ccerror"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+// checkPolicy enforces the policy stored for a function. Functions without
+// a policy can be called by every member of the channel.
+func (t *FoodChaincode) checkPolicy(stub shim.ChaincodeStubInterface, function string) pb.Response {
+	policyKey, err := stub.CreateCompositeKey(CK_POLICY, []string{function})
+	if err != nil {
+		return ccerror.Internal("Failed to create composite key: " + err.Error())
+	}
+	policyAsBytes, err := stub.GetState(policyKey)
+	if err != nil {
+		return ccerror.Internal("Failed to get policy of function " + function + ", error: " + err.Error())
+	} else if policyAsBytes == nil {
+		return shim.Success(nil)
+	}
+
+	policy := FunctionPolicy{}
+	err = json.Unmarshal(policyAsBytes, &policy)
+	if err != nil {
+		return ccerror.Internal("Failed to decode policy of function " + function + ": " + err.Error())
+	}
+
+	identity, err := getClientIdentity(stub)
+	if err != nil {
+		return ccerror.Internal("Failed to get client identity: " + err.Error())
+	}
+
+	if len(policy.MSPIDs) > 0 {
+		mspID, err := identity.GetMSPID()
+		if err != nil {
+			return ccerror.Internal("Failed to get MSP ID of client: " + err.Error())
+		}
+		allowed := false
+		for _, policyMSPID := range policy.MSPIDs {
+			if policyMSPID == mspID {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return ccerror.Forbidden("Access denied: " + mspID + " can not call " + function)
+		}
+	}
+
+	for name, value := range policy.Attributes {
+		err = identity.AssertAttributeValue(name, value)
+		if err != nil {
+			return ccerror.Forbidden("Access denied: " + function + " requires attribute " + name + "=" + value)
+		}
+	}
+
+	return shim.Success(nil)
+}
+
+// checkAdmin only lets clients with the admin attribute through, from one
+// of the adminMSPs of the config when these are set
+func (t *FoodChaincode) checkAdmin(stub shim.ChaincodeStubInterface) pb.Response {
+	identity, err := getClientIdentity(stub)
+	if err != nil {
+		return ccerror.Internal("Failed to get client identity: " + err.Error())
+	}
+	err = identity.AssertAttributeValue(ADMIN_ATTRIBUTE, "true")
+	if err != nil {
+		return ccerror.Forbidden("Access denied: attribute " + ADMIN_ATTRIBUTE + " is required")
+	}
+
+	result, config := t.readConfig(stub)
+	if result.Status != shim.OK {
+		return result
+	}
+	mspID, err := identity.GetMSPID()
+	if err != nil {
+		return ccerror.Internal("Failed to get MSP ID of client: " + err.Error())
+	}
+	if !ccconfig.IsAdmin(config.AdminMSPs, mspID) {
+		return ccerror.Forbidden("Access denied: " + mspID + " is not an admin MSP")
+	}
+	return shim.Success(nil)
+}
+
+// Methods on FunctionPolicy
+// ========================================
+func (t *FoodChaincode) setFunctionPolicy(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	logger.Debug(stub, "start setFunctionPolicy")
+
+	newPolicy := FunctionPolicy{}
+	err := json.Unmarshal([]byte(args[0]), &newPolicy)
+	if err != nil {
+		return ccerror.ValidationFailed("Failed to decode json of FunctionPolicy: " + err.Error())
+	}
+	if newPolicy.ObjectType != TYPE_POLICY {
+		return ccerror.TypeMismatch("Expexted objectType " + TYPE_POLICY + " for FunctionPolicy")
+	}
+	if len(newPolicy.Function) < 1 {
+		return ccerror.ValidationFailed("Function can not by empty")
+	}
+	if !t.isRoute(newPolicy.Function) {
+		return ccerror.WithDetails(ccerror.VALIDATION_FAILED, "Function "+newPolicy.Function+" is not a function of the chaincode",
+			map[string]interface{}{"function": newPolicy.Function})
+	}
+	if len(newPolicy.MSPIDs) == 0 && len(newPolicy.Attributes) == 0 {
+		return ccerror.ValidationFailed("FunctionPolicy needs at least one MSP ID or attribute")
+	}
+
+	policyKey, err := stub.CreateCompositeKey(CK_POLICY, []string{newPolicy.Function})
+	if err != nil {
+		return ccerror.Internal("Failed to create composite key: " + err.Error())
+	}
+	policyAsBytes, err := json.Marshal(newPolicy)
+	if err != nil {
+		return ccerror.Internal("Failed to encode json of FunctionPolicy: " + err.Error())
+	}
+	result := putVersioned(stub, policyKey, newPolicy.Function, policyAsBytes)
+	if result.Status != shim.OK {
+		logger.Debug(stub, "end setFunctionPolicy (failed)")
+		return result
+	}
+
+	logger.Debug(stub, "end setFunctionPolicy (success)")
+	return shim.Success(nil)
+}
+
+func (t *FoodChaincode) deleteFunctionPolicy(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	logger.Debug(stub, "start deleteFunctionPolicy")
+
+	policyKey, err := stub.CreateCompositeKey(CK_POLICY, []string{args[0]})
+	if err != nil {
+		return ccerror.Internal("Failed to create composite key: " + err.Error())
+	}
+	policyAsBytes, err := stub.GetState(policyKey)
+	if err != nil {
+		return ccerror.Internal("Failed to get policy of function " + args[0] + ", error: " + err.Error())
+	} else if policyAsBytes == nil {
+		return ccerror.NotFound("Function " + args[0] + " has no FunctionPolicy")
+	}
+
+	result := t.deleteCompositeKey(stub, CK_POLICY, []string{args[0]})
+
+	if result.Status == shim.OK {
+		logger.Debug(stub, "end deleteFunctionPolicy (success)")
+	}
+	return result
+}
+
+func (t *FoodChaincode) getFunctionPolicies(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	logger.Debug(stub, "start getFunctionPolicies")
+
+	resultsIterator, err := stub.GetStateByPartialCompositeKey(CK_POLICY, []string{})
+	if err != nil {
+		return ccerror.Internal(err.Error())
+	}
+	defer resultsIterator.Close()
+
+	response := []FunctionPolicy{}
+	for resultsIterator.HasNext() {
+		responseRange, err := resultsIterator.Next()
+		if err != nil {
+			return ccerror.Internal(err.Error())
+		}
+
+		policy := FunctionPolicy{}
+		err = json.Unmarshal(responseRange.Value, &policy)
+		if err != nil {
+			return ccerror.Internal("Failed to get decode policy: " + err.Error())
+		}
+		response = append(response, policy)
+	}
+
+	responseAsBytes, err := json.Marshal(response)
+	if err != nil {
+		return ccerror.Internal("Failed to get encode response: " + err.Error())
+	}
+
+	logger.Debug(stub, "end getFunctionPolicies (success)")
+	return shim.Success(responseAsBytes)
+}
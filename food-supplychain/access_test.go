@@ -0,0 +1,118 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/deevotech/sc-chaincode.deevo.io/lib/ccerror"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+func TestFood_FunctionPolicy(t *testing.T) {
+	scc := new(FoodChaincode)
+	stub := shim.NewMockStub("food", scc)
+
+	checkInit(t, stub, [][]byte{})
+
+	policy := FunctionPolicy{
+		ObjectType: TYPE_POLICY,
+		Function:   "createAuditor",
+		MSPIDs:     []string{"Org2MSP"},
+		Attributes: map[string]string{"food_supplychain.auditor": "true"},
+	}
+	policyAsBytes, err := json.Marshal(policy)
+	if err != nil {
+		fmt.Println("Failed to encode json")
+		t.FailNow()
+	}
+
+	// only admins can set policies
+	res := stub.MockInvoke("1", [][]byte{[]byte("setFunctionPolicy"), policyAsBytes})
+	if res.Status == shim.OK {
+		fmt.Println("Policy should not be set by a client without " + ADMIN_ATTRIBUTE)
+		t.FailNow()
+	}
+
+	setMockIdentity(TEST_MSP, map[string]string{ADMIN_ATTRIBUTE: "true"})
+	res = stub.MockInvoke("1", [][]byte{[]byte("setFunctionPolicy"), policyAsBytes})
+	if res.Status != shim.OK {
+		fmt.Println("failed", string(res.Message))
+		t.FailNow()
+	}
+
+	newAuditor := Auditor{ObjectType: TYPE_AUDITOR, ID: "Auditor_1", Name: "Auditor 1"}
+	newAuditorAsBytes, err := json.Marshal(newAuditor)
+	if err != nil {
+		fmt.Println("Failed to encode json")
+		t.FailNow()
+	}
+
+	// wrong MSP
+	setMockIdentity(TEST_MSP, map[string]string{"food_supplychain.auditor": "true"})
+	res = stub.MockInvoke("1", [][]byte{[]byte("createAuditor"), newAuditorAsBytes})
+	if res.Status == shim.OK {
+		fmt.Println("createAuditor should be denied for " + TEST_MSP)
+		t.FailNow()
+	}
+
+	// missing attribute
+	setMockIdentity("Org2MSP", nil)
+	res = stub.MockInvoke("1", [][]byte{[]byte("createAuditor"), newAuditorAsBytes})
+	if res.Status == shim.OK {
+		fmt.Println("createAuditor should be denied without attribute")
+		t.FailNow()
+	}
+
+	setMockIdentity("Org2MSP", map[string]string{"food_supplychain.auditor": "true"})
+	checkCreateAuditor(t, stub, newAuditorAsBytes, newAuditor)
+
+	// policy can be removed again
+	setMockIdentity(TEST_MSP, map[string]string{ADMIN_ATTRIBUTE: "true"})
+	res = stub.MockInvoke("1", [][]byte{[]byte("deleteFunctionPolicy"), []byte("createAuditor")})
+	if res.Status != shim.OK {
+		fmt.Println("failed", string(res.Message))
+		t.FailNow()
+	}
+	res = stub.MockInvoke("1", [][]byte{[]byte("getFunctionPolicies")})
+	if res.Status != shim.OK {
+		fmt.Println("failed", string(res.Message))
+		t.FailNow()
+	}
+	policies := []FunctionPolicy{}
+	err = json.Unmarshal(res.Payload, &policies)
+	if err != nil {
+		fmt.Println("Failed to decode json of FunctionPolicies:", err.Error())
+		t.FailNow()
+	}
+	if len(policies) != 0 {
+		fmt.Println("Size of response does not match")
+		t.FailNow()
+	}
+
+	res = stub.MockInvoke("1", [][]byte{[]byte("deleteFunctionPolicy"), []byte("createAuditor")})
+	checkErrorCode(t, res, ccerror.NOT_FOUND)
+}
+
+func TestFood_FunctionPolicyOfUnknownFunction(t *testing.T) {
+	scc := new(FoodChaincode)
+	stub := shim.NewMockStub("food", scc)
+
+	checkInit(t, stub, [][]byte{})
+
+	policy := FunctionPolicy{ObjectType: TYPE_POLICY, Function: "createAuditors", MSPIDs: []string{"Org2MSP"}}
+	policyAsBytes, err := json.Marshal(policy)
+	if err != nil {
+		fmt.Println("Failed to encode json")
+		t.FailNow()
+	}
+
+	setMockIdentity(TEST_MSP, map[string]string{ADMIN_ATTRIBUTE: "true"})
+	res := stub.MockInvoke("1", [][]byte{[]byte("setFunctionPolicy"), policyAsBytes})
+	body := checkErrorCode(t, res, ccerror.VALIDATION_FAILED)
+	if body.Details["function"] != "createAuditors" {
+		fmt.Println("Unexpected details", body.Details)
+		t.FailNow()
+	}
+}
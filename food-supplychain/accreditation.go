@@ -0,0 +1,209 @@
+package main
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"github.com/deevotech/sc-chaincode.deevo.io/lib/ccerror"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+const secondsPerDay = 24 * 60 * 60
+
+// checkAccreditations validates the accreditation records of an auditor
+func checkAccreditations(auditor Auditor) pb.Response {
+	for i, accreditation := range auditor.Accreditations {
+		name := "Accreditation " + strconv.Itoa(i) + " of auditor " + auditor.ID
+		if len(accreditation.Body) < 1 {
+			return ccerror.ValidationFailed(name + " has no accrediting body")
+		}
+		if accreditation.ValidTo <= 0 {
+			return ccerror.ValidationFailed(name + " has no validTo")
+		}
+		if accreditation.ValidFrom > accreditation.ValidTo {
+			return ccerror.ValidationFailed(name + " is valid from after it is valid to")
+		}
+	}
+	return shim.Success(nil)
+}
+
+// checkAuditorAccreditation rejects audits by unknown auditors and auditors
+// without a current accreditation covering the audited object. Validity is
+// checked at the transaction time, a backdated audit does not revive an
+// expired accreditation.
+func (t *FoodChaincode) checkAuditorAccreditation(stub shim.ChaincodeStubInterface, auditAction AuditAction, pending map[string]string) pb.Response {
+	result, auditor := t.getAuditor(stub, auditAction.Auditor)
+	if result.Status != shim.OK {
+		return result
+	}
+	if len(auditor.Accreditations) == 0 {
+		return ccerror.NotAccredited("Auditor " + auditor.ID + " is not accredited")
+	}
+
+	// Logs of the same batch are not stored yet, their CTE is unknown and
+	// only accreditations for all CTEs cover them
+	objectType, cte := pending[auditAction.ObjectID], ""
+	if len(objectType) < 1 {
+		objectAsBytes, err := stub.GetState(auditAction.ObjectID)
+		if err != nil {
+			return ccerror.Internal("Failed to get existed Object with ID: " + auditAction.ObjectID + ", error: " + err.Error())
+		}
+		if objectAsBytes != nil {
+			log := Log{}
+			err = json.Unmarshal(objectAsBytes, &log)
+			if err != nil {
+				return ccerror.Internal("Failed to get decode object: " + err.Error())
+			}
+			objectType = log.ObjectType
+			if objectType == TYPE_LOG {
+				cte = log.CTE
+			}
+		}
+	}
+
+	txTimestamp, err := stub.GetTxTimestamp()
+	if err != nil {
+		return ccerror.Internal("Failed to get transaction timestamp: " + err.Error())
+	}
+	now := txTimestamp.GetSeconds()
+
+	current := false
+	for _, accreditation := range auditor.Accreditations {
+		if accreditation.Suspended || now < accreditation.ValidFrom || now > accreditation.ValidTo {
+			continue
+		}
+		current = true
+		if inScope(accreditation.ObjectTypes, objectType) && inScope(accreditation.CTEs, cte) {
+			return shim.Success(nil)
+		}
+	}
+	if !current {
+		return ccerror.NotAccredited("Auditor " + auditor.ID + " has no current accreditation, all are expired, suspended or not yet valid")
+	}
+	if len(cte) > 0 {
+		return ccerror.NotAccredited("Auditor " + auditor.ID + " is not accredited for " + objectType + " " + auditAction.ObjectID + " with CTE " + cte)
+	}
+	return ccerror.NotAccredited("Auditor " + auditor.ID + " is not accredited for " + objectType + " " + auditAction.ObjectID)
+}
+
+func inScope(scope []string, value string) bool {
+	if len(scope) == 0 {
+		return true
+	}
+	for _, item := range scope {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *FoodChaincode) getAuditor(stub shim.ChaincodeStubInterface, ID string) (pb.Response, Auditor) {
+	auditor := Auditor{}
+	auditorAsBytes, err := stub.GetState(ID)
+	if err != nil {
+		return ccerror.Internal("Failed to get existed Auditor with ID: " + ID + ", error: " + err.Error()), auditor
+	} else if auditorAsBytes == nil {
+		return ccerror.NotFound("Auditor with ID " + ID + " does not exist"), auditor
+	}
+
+	err = json.Unmarshal(auditorAsBytes, &auditor)
+	if err != nil {
+		return ccerror.Internal("Failed to get decode Auditor: " + err.Error()), auditor
+	}
+	if auditor.ObjectType != TYPE_AUDITOR {
+		return ccerror.TypeMismatch("Object with ID: " + ID + " is not an Auditor"), auditor
+	}
+	return shim.Success(nil), auditor
+}
+
+// updateAuditorKeys moves the expiry keys of an auditor, old or new is nil
+// when the auditor is created or removed
+func (t *FoodChaincode) updateAuditorKeys(stub shim.ChaincodeStubInterface, oldAuditor *Auditor, newAuditor *Auditor) pb.Response {
+	if oldAuditor != nil {
+		for _, accreditation := range oldAuditor.Accreditations {
+			result := t.deleteCompositeKey(stub, CK_AUDITOR_EXPIRY, []string{timeKeyPart(accreditation.ValidTo), oldAuditor.ID})
+			if result.Status != shim.OK {
+				return result
+			}
+		}
+	}
+	if newAuditor != nil {
+		for _, accreditation := range newAuditor.Accreditations {
+			result := t.putCompositeKey(stub, CK_AUDITOR_EXPIRY, []string{timeKeyPart(accreditation.ValidTo), newAuditor.ID})
+			if result.Status != shim.OK {
+				return result
+			}
+		}
+	}
+	return shim.Success(nil)
+}
+
+// getExpiringAuditors returns the auditors with an accreditation which is
+// current now and expires within the given number of days
+func (t *FoodChaincode) getExpiringAuditors(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	logger.Debug(stub, "start getExpiringAuditors")
+	days, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil || days < 0 {
+		return ccerror.ValidationFailed("Days must be a number not below 0")
+	}
+
+	txTimestamp, err := stub.GetTxTimestamp()
+	if err != nil {
+		return ccerror.Internal("Failed to get transaction timestamp: " + err.Error())
+	}
+	now := txTimestamp.GetSeconds()
+	until := now + days*secondsPerDay
+
+	resultsIterator, err := stub.GetStateByPartialCompositeKey(CK_AUDITOR_EXPIRY, []string{})
+	if err != nil {
+		return ccerror.Internal(err.Error())
+	}
+	defer resultsIterator.Close()
+
+	// the keys are ordered by expiry
+	auditors := []Auditor{}
+	found := map[string]bool{}
+	for resultsIterator.HasNext() {
+		responseRange, err := resultsIterator.Next()
+		if err != nil {
+			return ccerror.Internal(err.Error())
+		}
+		_, compositeKeyParts, err := stub.SplitCompositeKey(responseRange.Key)
+		if err != nil {
+			return ccerror.Internal(err.Error())
+		}
+		if compositeKeyParts[0] < timeKeyPart(now) {
+			continue
+		}
+		if compositeKeyParts[0] > timeKeyPart(until) {
+			break
+		}
+		auditorID := compositeKeyParts[1]
+		if found[auditorID] {
+			continue
+		}
+
+		result, auditor := t.getAuditor(stub, auditorID)
+		if result.Status != shim.OK {
+			return result
+		}
+		for _, accreditation := range auditor.Accreditations {
+			if !accreditation.Suspended && accreditation.ValidFrom <= now && accreditation.ValidTo >= now && accreditation.ValidTo <= until {
+				found[auditorID] = true
+				auditors = append(auditors, auditor)
+				break
+			}
+		}
+	}
+
+	auditorsAsBytes, err := json.Marshal(auditors)
+	if err != nil {
+		return ccerror.Internal("Failed to get encode response: " + err.Error())
+	}
+
+	logger.Debug(stub, "end getExpiringAuditors (success)")
+	return shim.Success(auditorsAsBytes)
+}
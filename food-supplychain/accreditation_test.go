@@ -0,0 +1,144 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/deevotech/sc-chaincode.deevo.io/lib/ccerror"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+func initAccreditationData(t *testing.T, stub *shim.MockStub) {
+	checkInit(t, stub, [][]byte{})
+	checkCreateReferences(t, stub, map[string]string{"Product_1": TYPE_PRODUCT, "Supplychain_1": TYPE_SUPPLYCHAIN})
+
+	now := time.Now().Unix()
+	auditors := []Auditor{
+		{ObjectType: TYPE_AUDITOR, ID: "Auditor_Products", Accreditations: []Accreditation{
+			{Body: "Body_1", ObjectTypes: []string{TYPE_PRODUCT}, ValidFrom: now - 100, ValidTo: now + 10*secondsPerDay},
+		}},
+		{ObjectType: TYPE_AUDITOR, ID: "Auditor_Expired", Accreditations: []Accreditation{
+			{Body: "Body_1", ValidFrom: now - 200, ValidTo: now - 100},
+		}},
+		{ObjectType: TYPE_AUDITOR, ID: "Auditor_Suspended", Accreditations: []Accreditation{
+			{Body: "Body_1", ValidFrom: now - 100, ValidTo: now + 100*secondsPerDay, Suspended: true},
+		}},
+		{ObjectType: TYPE_AUDITOR, ID: "Auditor_Unaccredited"},
+	}
+	for _, auditor := range auditors {
+		auditorAsBytes, err := json.Marshal(auditor)
+		if err != nil {
+			fmt.Println("Failed to encode json")
+			t.FailNow()
+		}
+		checkCreateAuditor(t, stub, auditorAsBytes, auditor)
+	}
+}
+
+func TestFood_CreateAuditActionChecksAccreditation(t *testing.T) {
+	scc := new(FoodChaincode)
+	stub := shim.NewMockStub("food", scc)
+
+	initAccreditationData(t, stub)
+
+	audit := AuditAction{ObjectType: TYPE_AUDITACTION, ID: "AuditAction_1", Auditor: "Auditor_Products", ObjectID: "Product_1"}
+	auditAsBytes, err := json.Marshal(audit)
+	if err != nil {
+		fmt.Println("Failed to encode json")
+		t.FailNow()
+	}
+	checkCreateAuditAction(t, stub, auditAsBytes, audit)
+
+	rejected := map[string]AuditAction{
+		"out of scope": {ObjectType: TYPE_AUDITACTION, ID: "AuditAction_2", Auditor: "Auditor_Products", ObjectID: "Supplychain_1"},
+		"expired":      {ObjectType: TYPE_AUDITACTION, ID: "AuditAction_3", Auditor: "Auditor_Expired", ObjectID: "Product_1"},
+		"suspended":    {ObjectType: TYPE_AUDITACTION, ID: "AuditAction_4", Auditor: "Auditor_Suspended", ObjectID: "Product_1"},
+		"unaccredited": {ObjectType: TYPE_AUDITACTION, ID: "AuditAction_5", Auditor: "Auditor_Unaccredited", ObjectID: "Product_1"},
+	}
+	for description, audit := range rejected {
+		auditAsBytes, err := json.Marshal(audit)
+		if err != nil {
+			fmt.Println("Failed to encode json")
+			t.FailNow()
+		}
+		res := stub.MockInvoke("1", [][]byte{[]byte("createAuditAction"), auditAsBytes})
+		if res.Status == shim.OK {
+			fmt.Println("createAuditAction should fail for an", description, "auditor")
+			t.FailNow()
+		}
+	}
+}
+
+func TestFood_CreateAuditorWithInvalidAccreditation(t *testing.T) {
+	scc := new(FoodChaincode)
+	stub := shim.NewMockStub("food", scc)
+
+	checkInit(t, stub, [][]byte{})
+
+	res := stub.MockInvoke("1", [][]byte{[]byte("createAuditor"), []byte(`{"objectType": "auditor", "id": "Auditor_1", "accreditations": [{"validFrom": 100, "validTo": 200}]}`)})
+	if res.Status == shim.OK {
+		fmt.Println("createAuditor should fail without an accrediting body")
+		t.FailNow()
+	}
+	checkErrorCode(t, res, ccerror.VALIDATION_FAILED)
+	res = stub.MockInvoke("1", [][]byte{[]byte("createAuditor"), []byte(`{"objectType": "auditor", "id": "Auditor_1", "accreditations": [{"accreditingBody": "Body_1", "validFrom": 100}]}`)})
+	checkErrorCode(t, res, ccerror.VALIDATION_FAILED)
+	res = stub.MockInvoke("1", [][]byte{[]byte("createAuditor"), []byte(`{"objectType": "auditor", "id": "Auditor_1", "accreditations": [{"accreditingBody": "Body_1", "validFrom": 300, "validTo": 200}]}`)})
+	if res.Status == shim.OK {
+		fmt.Println("createAuditor should fail when validFrom is after validTo")
+		t.FailNow()
+	}
+	checkErrorCode(t, res, ccerror.VALIDATION_FAILED)
+}
+
+func TestFood_GetExpiringAuditors(t *testing.T) {
+	scc := new(FoodChaincode)
+	stub := shim.NewMockStub("food", scc)
+
+	initAccreditationData(t, stub)
+
+	auditors := checkExpiringAuditors(t, stub, "30")
+	if len(auditors) != 1 || auditors[0].ID != "Auditor_Products" {
+		fmt.Println("Expected Auditor_Products to expire within 30 days", auditors)
+		t.FailNow()
+	}
+	auditors = checkExpiringAuditors(t, stub, "5")
+	if len(auditors) != 0 {
+		fmt.Println("No auditor expires within 5 days", auditors)
+		t.FailNow()
+	}
+
+	// renewing the accreditation moves its expiry
+	renewed := Auditor{ObjectType: TYPE_AUDITOR, ID: "Auditor_Products", Accreditations: []Accreditation{
+		{Body: "Body_1", ObjectTypes: []string{TYPE_PRODUCT}, ValidTo: time.Now().Unix() + 365*secondsPerDay},
+	}, Version: 1}
+	renewedAsBytes, err := json.Marshal(renewed)
+	if err != nil {
+		fmt.Println("Failed to encode json")
+		t.FailNow()
+	}
+	checkUpdateAuditor(t, stub, renewedAsBytes, renewed)
+	auditors = checkExpiringAuditors(t, stub, "30")
+	if len(auditors) != 0 {
+		fmt.Println("Renewed auditor should not expire within 30 days", auditors)
+		t.FailNow()
+	}
+}
+
+func checkExpiringAuditors(t *testing.T, stub *shim.MockStub, days string) []Auditor {
+	res := stub.MockInvoke("1", [][]byte{[]byte("getExpiringAuditors"), []byte(days)})
+	if res.Status != shim.OK {
+		fmt.Println("failed", string(res.Message))
+		t.FailNow()
+	}
+	auditors := []Auditor{}
+	err := json.Unmarshal(res.Payload, &auditors)
+	if err != nil {
+		fmt.Println("Failed to decode json of Auditors:", err.Error())
+		t.FailNow()
+	}
+	return auditors
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"encoding/json"
+
+	"github.com/deevotech/sc-chaincode.deevo.io/lib/ccerror"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+// auditTransitions lists the statuses an audit can move to, an audit only
+// moves forward
+var auditTransitions = map[string][]string{
+	AUDIT_SCHEDULED:   {AUDIT_IN_PROGRESS},
+	AUDIT_IN_PROGRESS: {AUDIT_COMPLETED},
+	AUDIT_COMPLETED:   {AUDIT_CLOSED},
+}
+
+// setAuditDefaults fills in the status of a new audit and of its corrective
+// actions
+func setAuditDefaults(auditAction *AuditAction) {
+	if len(auditAction.Status) < 1 {
+		auditAction.Status = AUDIT_SCHEDULED
+	}
+	for i := range auditAction.CorrectiveActions {
+		if len(auditAction.CorrectiveActions[i].Status) < 1 {
+			auditAction.CorrectiveActions[i].Status = ACTION_OPEN
+		}
+	}
+}
+
+// checkAuditLifecycle validates the status, findings and corrective actions
+// of an audit. oldAuditAction is nil when the audit is created.
+func checkAuditLifecycle(oldAuditAction *AuditAction, newAuditAction AuditAction) pb.Response {
+	if oldAuditAction == nil {
+		if newAuditAction.Status == AUDIT_CLOSED {
+			return ccerror.ValidationFailed("Audit " + newAuditAction.ID + " can not be created " + AUDIT_CLOSED)
+		}
+		if _, ok := auditTransitions[newAuditAction.Status]; !ok {
+			return ccerror.ValidationFailed("Unknown audit status " + newAuditAction.Status)
+		}
+	} else {
+		// audits stored before the lifecycle have no status
+		oldStatus := oldAuditAction.Status
+		if len(oldStatus) < 1 {
+			oldStatus = AUDIT_SCHEDULED
+		}
+		if oldStatus == AUDIT_CLOSED {
+			return ccerror.ValidationFailed("Audit " + oldAuditAction.ID + " is " + AUDIT_CLOSED + " and can not be changed")
+		}
+		allowed := newAuditAction.Status == oldStatus
+		for _, next := range auditTransitions[oldStatus] {
+			if next == newAuditAction.Status {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return ccerror.ValidationFailed("Audit " + oldAuditAction.ID + " can not move from " + oldStatus + " to " + newAuditAction.Status)
+		}
+		if oldStatus == AUDIT_COMPLETED && !sameFindings(oldAuditAction.Findings, newAuditAction.Findings) {
+			return ccerror.ValidationFailed("Findings of audit " + oldAuditAction.ID + " can not change after it is " + AUDIT_COMPLETED)
+		}
+	}
+
+	if newAuditAction.Status == AUDIT_SCHEDULED && (len(newAuditAction.Findings) > 0 || len(newAuditAction.CorrectiveActions) > 0) {
+		return ccerror.ValidationFailed("Audit " + newAuditAction.ID + " has no findings before it is " + AUDIT_IN_PROGRESS)
+	}
+
+	failed := map[string]bool{}
+	for _, finding := range newAuditAction.Findings {
+		if len(finding.ID) < 1 {
+			return ccerror.ValidationFailed("Finding ID can not by empty")
+		}
+		if _, ok := failed[finding.ID]; ok {
+			return ccerror.ValidationFailed("Finding " + finding.ID + " is repeated")
+		}
+		if len(finding.Clause) < 1 {
+			return ccerror.ValidationFailed("Clause of finding " + finding.ID + " can not by empty")
+		}
+		if finding.Severity != FINDING_CRITICAL && finding.Severity != FINDING_MAJOR && finding.Severity != FINDING_MINOR {
+			return ccerror.ValidationFailed("Severity of finding " + finding.ID + " must be " + FINDING_CRITICAL + ", " + FINDING_MAJOR + " or " + FINDING_MINOR)
+		}
+		failed[finding.ID] = !finding.Passed
+	}
+
+	actionIDs := map[string]bool{}
+	for _, action := range newAuditAction.CorrectiveActions {
+		if len(action.ID) < 1 {
+			return ccerror.ValidationFailed("Corrective action ID can not by empty")
+		}
+		if actionIDs[action.ID] {
+			return ccerror.ValidationFailed("Corrective action " + action.ID + " is repeated")
+		}
+		actionIDs[action.ID] = true
+		if !failed[action.FindingID] {
+			return ccerror.ValidationFailed("Corrective action " + action.ID + " must reference a failed finding")
+		}
+		if len(action.Owner) < 1 {
+			return ccerror.ValidationFailed("Owner of corrective action " + action.ID + " can not by empty")
+		}
+		if action.DueDate <= 0 {
+			return ccerror.ValidationFailed("Due date of corrective action " + action.ID + " can not by empty")
+		}
+		if action.Status != ACTION_OPEN && action.Status != ACTION_RESOLVED {
+			return ccerror.ValidationFailed("Status of corrective action " + action.ID + " must be " + ACTION_OPEN + " or " + ACTION_RESOLVED)
+		}
+		if action.Status == ACTION_RESOLVED && action.Resolved <= 0 {
+			return ccerror.ValidationFailed("Resolved corrective action " + action.ID + " needs the time it was resolved")
+		}
+		if newAuditAction.Status == AUDIT_CLOSED && action.Status != ACTION_RESOLVED {
+			return ccerror.ValidationFailed("Audit " + newAuditAction.ID + " can not close while corrective action " + action.ID + " is " + action.Status)
+		}
+	}
+
+	return shim.Success(nil)
+}
+
+func sameFindings(findings []AuditFinding, others []AuditFinding) bool {
+	if len(findings) != len(others) {
+		return false
+	}
+	for i, finding := range findings {
+		if finding != others[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// getAuditsByStatus returns the audits with a lifecycle status
+func (t *FoodChaincode) getAuditsByStatus(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	logger.Debug(stub, "start getAuditsByStatus")
+	if _, ok := auditTransitions[args[0]]; !ok && args[0] != AUDIT_CLOSED {
+		return ccerror.ValidationFailed("Unknown audit status " + args[0])
+	}
+
+	result, audits := t.getAuditsByCompositeKey(stub, CK_AUDIT_STATUS, args[0])
+	if result.Status != shim.OK {
+		return result
+	}
+
+	auditsAsBytes, err := json.Marshal(audits)
+	if err != nil {
+		return ccerror.Internal("Failed to get encode response: " + err.Error())
+	}
+
+	logger.Debug(stub, "end getAuditsByStatus (success)")
+	return shim.Success(auditsAsBytes)
+}
+
+func (t *FoodChaincode) getAuditsByCompositeKey(stub shim.ChaincodeStubInterface, index string, value string) (pb.Response, []AuditAction) {
+	resultsIterator, err := stub.GetStateByPartialCompositeKey(index, []string{value})
+	if err != nil {
+		return ccerror.Internal(err.Error()), nil
+	}
+	defer resultsIterator.Close()
+
+	audits := []AuditAction{}
+	for resultsIterator.HasNext() {
+		responseRange, err := resultsIterator.Next()
+		if err != nil {
+			return ccerror.Internal(err.Error()), nil
+		}
+
+		_, compositeKeyParts, err := stub.SplitCompositeKey(responseRange.Key)
+		if err != nil {
+			return ccerror.Internal(err.Error()), nil
+		}
+		returnedAuditID := compositeKeyParts[1]
+
+		auditAsBytes, err := stub.GetState(returnedAuditID)
+		if err != nil {
+			return ccerror.Internal("Failed to get existed Audit with ID: " + returnedAuditID + ", error: " + err.Error()), nil
+		} else if auditAsBytes == nil {
+			return ccerror.NotFound("Audit with ID " + returnedAuditID + " does not exist"), nil
+		}
+
+		audit := AuditAction{}
+		err = json.Unmarshal(auditAsBytes, &audit)
+		if err != nil {
+			return ccerror.Internal("Failed to get decode audit: " + err.Error()), nil
+		}
+		audits = append(audits, audit)
+	}
+	return shim.Success(nil), audits
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+func initAuditLifecycleData(t *testing.T, stub *shim.MockStub) AuditAction {
+	checkInit(t, stub, [][]byte{})
+	checkCreateReferences(t, stub, map[string]string{"Product_1": TYPE_PRODUCT, "Auditor_1": TYPE_AUDITOR})
+
+	newAuditAction := AuditAction{
+		ObjectType: TYPE_AUDITACTION,
+		ID:         "AuditAction_1",
+		Auditor:    "Auditor_1",
+		Time:       100,
+		Location:   "Location_1",
+		ObjectID:   "Product_1",
+	}
+	newAuditActionAsBytes, err := json.Marshal(newAuditAction)
+	if err != nil {
+		fmt.Println("Failed to encode json")
+		t.FailNow()
+	}
+	checkCreateAuditAction(t, stub, newAuditActionAsBytes, newAuditAction)
+	newAuditAction.Status = AUDIT_SCHEDULED
+	newAuditAction.Version = 1
+	return newAuditAction
+}
+
+func TestFood_AuditLifecycle(t *testing.T) {
+	scc := new(FoodChaincode)
+	stub := shim.NewMockStub("food", scc)
+
+	audit := initAuditLifecycleData(t, stub)
+
+	// findings are recorded while the audit is in progress
+	audit.Findings = []AuditFinding{{ID: "F_1", Clause: "4.1", Severity: FINDING_MAJOR, Passed: false, Score: 2}}
+	checkUpdateAuditActionFails(t, stub, audit, "Findings of a scheduled audit")
+
+	audit.Status = AUDIT_IN_PROGRESS
+	audit.Findings = append(audit.Findings, AuditFinding{ID: "F_2", Clause: "4.2", Severity: FINDING_MINOR, Passed: true, Score: 9})
+	audit.CorrectiveActions = []CorrectiveAction{{ID: "CA_1", FindingID: "F_2", Description: "Clean", Owner: "Org1MSP", DueDate: 200}}
+	checkUpdateAuditActionFails(t, stub, audit, "Corrective action of a passed finding")
+
+	audit.CorrectiveActions[0].FindingID = "F_1"
+	checkAuditActionUpdate(t, stub, &audit)
+
+	checkAuditsByStatus(t, stub, AUDIT_SCHEDULED, 0)
+	checkAuditsByStatus(t, stub, AUDIT_IN_PROGRESS, 1)
+
+	audit.Status = AUDIT_CLOSED
+	checkUpdateAuditActionFails(t, stub, audit, "Skipping completed")
+
+	audit.Status = AUDIT_COMPLETED
+	checkAuditActionUpdate(t, stub, &audit)
+
+	audit.Findings[1].Score = 10
+	checkUpdateAuditActionFails(t, stub, audit, "Findings of a completed audit")
+	audit.Findings[1].Score = 9
+
+	audit.Status = AUDIT_CLOSED
+	checkUpdateAuditActionFails(t, stub, audit, "Closing with an open corrective action")
+
+	audit.Status = AUDIT_COMPLETED
+	audit.CorrectiveActions[0].Status = ACTION_RESOLVED
+	audit.CorrectiveActions[0].Resolved = 150
+	checkAuditActionUpdate(t, stub, &audit)
+
+	audit.Status = AUDIT_CLOSED
+	checkAuditActionUpdate(t, stub, &audit)
+	checkAuditsByStatus(t, stub, AUDIT_CLOSED, 1)
+
+	audit.Location = "Location_2"
+	checkUpdateAuditActionFails(t, stub, audit, "Changing a closed audit")
+
+	audit.Status = AUDIT_IN_PROGRESS
+	checkUpdateAuditActionFails(t, stub, audit, "Reopening a closed audit")
+}
+
+func TestFood_CreateClosedAuditAction(t *testing.T) {
+	scc := new(FoodChaincode)
+	stub := shim.NewMockStub("food", scc)
+
+	initAuditLifecycleData(t, stub)
+
+	res := stub.MockInvoke("1", [][]byte{[]byte("createAuditAction"), []byte(`{"objectType": "auditAction", "id": "AuditAction_2", "auditor": "Auditor_1", "objectID": "Product_1", "status": "closed"}`)})
+	if res.Status == shim.OK {
+		fmt.Println("createAuditAction should fail for a closed audit")
+		t.FailNow()
+	}
+}
+
+// checkAuditActionUpdate updates auditAction and moves it to the next version
+func checkAuditActionUpdate(t *testing.T, stub *shim.MockStub, auditAction *AuditAction) {
+	auditActionAsBytes, err := json.Marshal(auditAction)
+	if err != nil {
+		fmt.Println("Failed to encode json")
+		t.FailNow()
+	}
+	checkUpdateAuditAction(t, stub, auditActionAsBytes, *auditAction)
+	auditAction.Version++
+}
+
+func checkUpdateAuditActionFails(t *testing.T, stub *shim.MockStub, auditAction AuditAction, description string) {
+	auditActionAsBytes, err := json.Marshal(auditAction)
+	if err != nil {
+		fmt.Println("Failed to encode json")
+		t.FailNow()
+	}
+	res := stub.MockInvoke("1", [][]byte{[]byte("updateAuditAction"), auditActionAsBytes})
+	if res.Status == shim.OK {
+		fmt.Println(description, "should fail")
+		t.FailNow()
+	}
+}
+
+func checkAuditsByStatus(t *testing.T, stub *shim.MockStub, status string, expected int) {
+	res := stub.MockInvoke("1", [][]byte{[]byte("getAuditsByStatus"), []byte(status)})
+	if res.Status != shim.OK {
+		fmt.Println("failed", string(res.Message))
+		t.FailNow()
+	}
+	audits := []AuditAction{}
+	err := json.Unmarshal(res.Payload, &audits)
+	if err != nil {
+		fmt.Println("Failed to decode json of AuditActions:", err.Error())
+		t.FailNow()
+	}
+	if len(audits) != expected {
+		fmt.Println("Expected", expected, "audits", status, "but got", len(audits))
+		t.FailNow()
+	}
+}
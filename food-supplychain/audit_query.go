@@ -0,0 +1,81 @@
+package main
+
+import (
+	"sort"
+
+	"github.com/deevotech/sc-chaincode.deevo.io/lib/ccerror"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+func checkAuditFilter(filter *AuditFilter) pb.Response {
+	if len(filter.Sort) < 1 {
+		filter.Sort = SORT_DESC
+	}
+	if filter.Sort != SORT_ASC && filter.Sort != SORT_DESC {
+		return ccerror.ValidationFailed("Sort must be " + SORT_ASC + " or " + SORT_DESC)
+	}
+	if len(filter.Outcome) > 0 && filter.Outcome != OUTCOME_PASSED && filter.Outcome != OUTCOME_FAILED && filter.Outcome != OUTCOME_PENDING {
+		return ccerror.ValidationFailed("Outcome must be " + OUTCOME_PASSED + ", " + OUTCOME_FAILED + " or " + OUTCOME_PENDING)
+	}
+	if filter.To > 0 && filter.From > filter.To {
+		return ccerror.ValidationFailed("From can not be after To")
+	}
+	return shim.Success(nil)
+}
+
+// filterAudits returns the audits matching a filter, sorted by time
+func filterAudits(audits []AuditAction, filter AuditFilter) []AuditAction {
+	result := []AuditAction{}
+	for _, audit := range audits {
+		if len(filter.Auditor) > 0 && audit.Auditor != filter.Auditor {
+			continue
+		}
+		if filter.From > 0 && audit.Time < filter.From {
+			continue
+		}
+		if filter.To > 0 && audit.Time > filter.To {
+			continue
+		}
+		if len(filter.Outcome) > 0 && auditOutcome(audit) != filter.Outcome {
+			continue
+		}
+		result = append(result, audit)
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].Time != result[j].Time {
+			return (result[i].Time < result[j].Time) == (filter.Sort == SORT_ASC)
+		}
+		return result[i].ID < result[j].ID
+	})
+	return result
+}
+
+func summarizeAudits(audits []AuditAction) AuditSummary {
+	summary := AuditSummary{Count: len(audits)}
+	for i := range audits {
+		if summary.Latest == nil || audits[i].Time > summary.Latest.Time {
+			summary.Latest = &audits[i]
+		}
+	}
+	if summary.Latest != nil {
+		summary.LatestOutcome = auditOutcome(*summary.Latest)
+	}
+	return summary
+}
+
+// auditOutcome is pending until an audit is completed, then failed when any
+// of its findings failed
+func auditOutcome(audit AuditAction) string {
+	if audit.Status != AUDIT_COMPLETED && audit.Status != AUDIT_CLOSED {
+		return OUTCOME_PENDING
+	}
+	for _, finding := range audit.Findings {
+		if !finding.Passed {
+			return OUTCOME_FAILED
+		}
+	}
+	return OUTCOME_PASSED
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+func initAuditsOfObjectData(t *testing.T, stub *shim.MockStub) {
+	checkInit(t, stub, [][]byte{})
+	checkCreateReferences(t, stub, map[string]string{"Product_1": TYPE_PRODUCT, "Auditor_1": TYPE_AUDITOR, "Auditor_2": TYPE_AUDITOR})
+
+	passed := []AuditFinding{{ID: "F_1", Clause: "4.1", Severity: FINDING_MINOR, Passed: true, Score: 9}}
+	failed := []AuditFinding{{ID: "F_1", Clause: "4.1", Severity: FINDING_MAJOR, Passed: false, Score: 2}}
+	audits := []AuditAction{
+		{ObjectType: TYPE_AUDITACTION, ID: "AuditAction_1", Auditor: "Auditor_1", Time: 100, ObjectID: "Product_1", Status: AUDIT_COMPLETED, Findings: failed},
+		{ObjectType: TYPE_AUDITACTION, ID: "AuditAction_2", Auditor: "Auditor_2", Time: 300, ObjectID: "Product_1", Status: AUDIT_COMPLETED, Findings: passed},
+		{ObjectType: TYPE_AUDITACTION, ID: "AuditAction_3", Auditor: "Auditor_1", Time: 200, ObjectID: "Product_1"},
+	}
+	for _, audit := range audits {
+		auditAsBytes, err := json.Marshal(audit)
+		if err != nil {
+			fmt.Println("Failed to encode json")
+			t.FailNow()
+		}
+		checkCreateAuditAction(t, stub, auditAsBytes, audit)
+	}
+}
+
+func TestFood_GetAuditOfObject(t *testing.T) {
+	scc := new(FoodChaincode)
+	stub := shim.NewMockStub("food", scc)
+
+	initAuditsOfObjectData(t, stub)
+
+	audits := checkAuditsOfObject(t, stub, "Product_1")
+	checkAuditIDs(t, audits.Audits, []string{"AuditAction_2", "AuditAction_3", "AuditAction_1"})
+	if audits.Summary.Count != 3 || audits.Summary.Latest == nil || audits.Summary.Latest.ID != "AuditAction_2" || audits.Summary.LatestOutcome != OUTCOME_PASSED {
+		fmt.Println("Summary was not as expected", audits.Summary)
+		t.FailNow()
+	}
+
+	audits = checkAuditsOfObject(t, stub, "Product_1", `{"auditor": "Auditor_1", "sort": "asc"}`)
+	checkAuditIDs(t, audits.Audits, []string{"AuditAction_1", "AuditAction_3"})
+	if audits.Summary.Latest.ID != "AuditAction_3" || audits.Summary.LatestOutcome != OUTCOME_PENDING {
+		fmt.Println("Summary was not as expected", audits.Summary)
+		t.FailNow()
+	}
+
+	audits = checkAuditsOfObject(t, stub, "Product_1", `{"from": 150, "to": 300}`)
+	checkAuditIDs(t, audits.Audits, []string{"AuditAction_2", "AuditAction_3"})
+
+	audits = checkAuditsOfObject(t, stub, "Product_1", `{"outcome": "failed"}`)
+	checkAuditIDs(t, audits.Audits, []string{"AuditAction_1"})
+
+	audits = checkAuditsOfObject(t, stub, "Product_2")
+	if len(audits.Audits) != 0 || audits.Summary.Count != 0 || audits.Summary.Latest != nil {
+		fmt.Println("Product_2 has no audits")
+		t.FailNow()
+	}
+
+	res := stub.MockInvoke("1", [][]byte{[]byte("getAuditOfObject"), []byte("Product_1"), []byte(`{"sort": "random"}`)})
+	if res.Status == shim.OK {
+		fmt.Println("getAuditOfObject should fail for an unknown sort")
+		t.FailNow()
+	}
+}
+
+func checkAuditsOfObject(t *testing.T, stub *shim.MockStub, args ...string) AuditsOfObject {
+	invokeArgs := [][]byte{[]byte("getAuditOfObject")}
+	for _, arg := range args {
+		invokeArgs = append(invokeArgs, []byte(arg))
+	}
+	res := stub.MockInvoke("1", invokeArgs)
+	if res.Status != shim.OK {
+		fmt.Println("failed", string(res.Message))
+		t.FailNow()
+	}
+
+	audits := AuditsOfObject{}
+	err := json.Unmarshal(res.Payload, &audits)
+	if err != nil {
+		fmt.Println("Failed to decode json of AuditsOfObject:", err.Error())
+		t.FailNow()
+	}
+	return audits
+}
+
+func checkAuditIDs(t *testing.T, audits []AuditAction, expected []string) {
+	IDs := []string{}
+	for _, audit := range audits {
+		IDs = append(IDs, audit.ID)
+	}
+	if fmt.Sprint(IDs) != fmt.Sprint(expected) {
+		fmt.Println("Audits", IDs, "were not", expected)
+		t.FailNow()
+	}
+}
@@ -37,7 +37,7 @@ func TestFood_UpdateAuditor(t *testing.T) {
 	}
 	checkCreateAuditor(t, stub, newAuditorAsBytes, newAuditor)
 
-	updatedAuditor := Auditor{ObjectType: TYPE_AUDITOR, ID: "Auditor_1", Name: "Auditor 2"}
+	updatedAuditor := Auditor{ObjectType: TYPE_AUDITOR, ID: "Auditor_1", Name: "Auditor 2", Version: 1}
 	updatedAuditorAsBytes, err := json.Marshal(updatedAuditor)
 	if err != nil {
 		fmt.Println("Failed to encode json")
@@ -51,6 +51,7 @@ func TestFood_CreareAuditAction(t *testing.T) {
 	stub := shim.NewMockStub("food", scc)
 
 	checkInit(t, stub, [][]byte{})
+	checkCreateReferences(t, stub, map[string]string{"Product_1": TYPE_PRODUCT, "Auditor_1": TYPE_AUDITOR})
 
 	newAuditAction := AuditAction{
 		ObjectType: TYPE_AUDITACTION,
@@ -73,6 +74,7 @@ func TestFood_UpdateAuditAction(t *testing.T) {
 	stub := shim.NewMockStub("food", scc)
 
 	checkInit(t, stub, [][]byte{})
+	checkCreateReferences(t, stub, map[string]string{"Product_1": TYPE_PRODUCT, "Product_2": TYPE_PRODUCT, "Auditor_1": TYPE_AUDITOR})
 
 	newAuditAction := AuditAction{
 		ObjectType: TYPE_AUDITACTION,
@@ -96,6 +98,7 @@ func TestFood_UpdateAuditAction(t *testing.T) {
 		Time:       time.Now().Unix(),
 		Location:   "Location_2",
 		ObjectID:   "Product_2",
+		Version:    1,
 	}
 	updatedAuditActionAsBytes, err := json.Marshal(updatedAuditAction)
 	if err != nil {
@@ -105,6 +108,43 @@ func TestFood_UpdateAuditAction(t *testing.T) {
 	checkUpdateAuditAction(t, stub, updatedAuditActionAsBytes, updatedAuditAction)
 }
 
+func TestFood_UpdateAuditActionFromOtherMSP(t *testing.T) {
+	scc := new(FoodChaincode)
+	stub := shim.NewMockStub("food", scc)
+
+	checkInit(t, stub, [][]byte{})
+	checkCreateReferences(t, stub, map[string]string{"Product_1": TYPE_PRODUCT, "Auditor_1": TYPE_AUDITOR})
+
+	newAuditAction := AuditAction{
+		ObjectType: TYPE_AUDITACTION,
+		ID:         "AuditAction_1",
+		Auditor:    "Auditor_1",
+		Time:       time.Now().Unix(),
+		Location:   "Location_1",
+		ObjectID:   "Product_1",
+	}
+	newAuditActionAsBytes, err := json.Marshal(newAuditAction)
+	if err != nil {
+		fmt.Println("Failed to encode json")
+		t.FailNow()
+	}
+	checkCreateAuditAction(t, stub, newAuditActionAsBytes, newAuditAction)
+
+	setMockIdentity("Org2MSP", nil)
+	newAuditAction.Location = "Location_2"
+	newAuditAction.Version = 1
+	newAuditActionAsBytes, err = json.Marshal(newAuditAction)
+	if err != nil {
+		fmt.Println("Failed to encode json")
+		t.FailNow()
+	}
+	res := stub.MockInvoke("1", [][]byte{[]byte("updateAuditAction"), newAuditActionAsBytes})
+	if res.Status == shim.OK {
+		fmt.Println("Update from another MSP should be rejected")
+		t.FailNow()
+	}
+}
+
 func checkCreateAuditor(t *testing.T, stub *shim.MockStub, auditorAsJSON []byte, value Auditor) {
 	res := stub.MockInvoke("1", [][]byte{[]byte("createAuditor"), auditorAsJSON})
 	if res.Status != shim.OK {
@@ -123,7 +163,7 @@ func checkCreateAuditor(t *testing.T, stub *shim.MockStub, auditorAsJSON []byte,
 		fmt.Println("Failed to decode json of Auditor:", err.Error())
 		t.FailNow()
 	}
-	if resAuditor != value {
+	if !resAuditor.Equals(value) {
 		fmt.Println("Query value was not as expected")
 		t.FailNow()
 	}
@@ -147,7 +187,7 @@ func checkUpdateAuditor(t *testing.T, stub *shim.MockStub, auditorAsJSON []byte,
 		fmt.Println("Failed to decode json:", err.Error())
 		t.FailNow()
 	}
-	if resAuditor != value {
+	if !resAuditor.Equals(value) {
 		fmt.Println("Query value was not as expected")
 		t.FailNow()
 	}
@@ -171,7 +211,10 @@ func checkCreateAuditAction(t *testing.T, stub *shim.MockStub, auditActionAsJSON
 		fmt.Println("Failed to decode json of AuditAction:", err.Error())
 		t.FailNow()
 	}
-	if resAuditAction != value {
+	checkCreator(t, resAuditAction.Creator, TEST_MSP)
+	// the chaincode fills in the status of a new audit
+	setAuditDefaults(&value)
+	if !resAuditAction.Equals(value) {
 		fmt.Println("Query value was not as expected")
 		t.FailNow()
 	}
@@ -195,7 +238,10 @@ func checkUpdateAuditAction(t *testing.T, stub *shim.MockStub, auditActionAsJSON
 		fmt.Println("Failed to decode json:", err.Error())
 		t.FailNow()
 	}
-	if resAuditAction != value {
+	checkCreator(t, resAuditAction.Creator, TEST_MSP)
+	// the chaincode fills in the status of a new audit
+	setAuditDefaults(&value)
+	if !resAuditAction.Equals(value) {
 		fmt.Println("Query value was not as expected")
 		t.FailNow()
 	}
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/deevotech/sc-chaincode.deevo.io/lib/ccerror"
+	"github.com/deevotech/sc-chaincode.deevo.io/lib/logging"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+// bulkCreateLogs creates many Logs and AuditActions in one transaction and
+// reports the result of every item. The first argument is either a JSON
+// array of Log or a BulkData object, the optional second one BulkOptions.
+func (t *FoodChaincode) bulkCreateLogs(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	logger.Debug(stub, "start bulkCreateLogs")
+
+	data := BulkData{}
+	jsonBytes := bytes.TrimSpace([]byte(args[0]))
+	if len(jsonBytes) > 0 && jsonBytes[0] == '[' {
+		err := json.Unmarshal(jsonBytes, &data.Logs)
+		if err != nil {
+			return ccerror.ValidationFailed("Failed to decode json of Logs: " + err.Error())
+		}
+	} else {
+		err := json.Unmarshal(jsonBytes, &data)
+		if err != nil {
+			return ccerror.ValidationFailed("Failed to decode json of BulkData: " + err.Error())
+		}
+	}
+
+	options := BulkOptions{}
+	if len(args) == 2 {
+		err := json.Unmarshal([]byte(args[1]), &options)
+		if err != nil {
+			return ccerror.ValidationFailed("Failed to decode json of BulkOptions: " + err.Error())
+		}
+	}
+
+	result := t.bulkCreate(stub, data, options)
+
+	if result.Status == shim.OK {
+		logger.Debug(stub, "end bulkCreateLogs (success)")
+	}
+	return result
+}
+
+// bulkCreate validates all items first, then creates the valid ones
+func (t *FoodChaincode) bulkCreate(stub shim.ChaincodeStubInterface, data BulkData, options BulkOptions) pb.Response {
+	result, creator := t.getCreator(stub)
+	if result.Status != shim.OK {
+		return result
+	}
+
+	report := BulkResult{
+		AllOrNothing: options.AllOrNothing,
+		DryRun:       options.DryRun,
+		Items:        []BulkItemResult{},
+	}
+
+	// objects written in this transaction are not visible to GetState, so
+	// IDs repeated inside the batch are tracked here
+	batchIDs := map[string]bool{}
+	// Logs and AuditActions may reference Logs of the same batch. Every Log
+	// is pending while the Logs are checked, then the references to Logs
+	// which failed fail as well, until only valid Logs are pending.
+	pending := map[string]string{}
+	for _, newLog := range data.Logs {
+		pending[newLog.ID] = TYPE_LOG
+	}
+	for i, newLog := range data.Logs {
+		result := t.checkNewLog(stub, newLog, pending)
+		report.Items = append(report.Items, newBulkItemResult(i, TYPE_LOG, newLog.ID, result, batchIDs))
+	}
+	for {
+		pending = validBatchLogs(report.Items)
+		failedIDs := failedBatchLogs(report.Items, pending)
+		failed := false
+		for i := range report.Items {
+			item := &report.Items[i]
+			if item.Status == BULK_VALID && failBatchReferences(item, "Log", logReferences(data.Logs[item.Index]), failedIDs) {
+				failed = true
+			}
+		}
+		if !failed {
+			break
+		}
+	}
+
+	for i := range data.AuditActions {
+		setAuditDefaults(&data.AuditActions[i])
+	}
+	failedIDs := failedBatchLogs(report.Items, pending)
+	for i, newAuditAction := range data.AuditActions {
+		result := t.checkNewAuditAction(stub, newAuditAction, pending)
+		item := newBulkItemResult(i, TYPE_AUDITACTION, newAuditAction.ID, result, batchIDs)
+		if item.Status == BULK_VALID {
+			references := []batchReference{{"auditor", newAuditAction.Auditor}, {"objectID", newAuditAction.ObjectID}}
+			failBatchReferences(&item, "AuditAction", references, failedIDs)
+		}
+		report.Items = append(report.Items, item)
+	}
+	for _, item := range report.Items {
+		if item.Status == BULK_FAILED {
+			report.Failed++
+		}
+	}
+
+	if options.AllOrNothing && report.Failed > 0 {
+		return ccerror.WithDetails(ccerror.VALIDATION_FAILED, strconv.Itoa(report.Failed)+" of "+strconv.Itoa(len(report.Items))+" items failed",
+			map[string]interface{}{"report": report})
+	}
+
+	if !options.DryRun {
+		createdIDs := []string{}
+		for i := range report.Items {
+			item := &report.Items[i]
+			if item.Status != BULK_VALID {
+				continue
+			}
+
+			if item.ObjectType == TYPE_LOG {
+				newLog := data.Logs[item.Index]
+				newLog.Creator = creator
+				result = t.applyLogPrivate(stub, &newLog, nil)
+				if result.Status == shim.OK {
+					result = t.createLogHandler(stub, newLog)
+				}
+			} else {
+				newAuditAction := data.AuditActions[item.Index]
+				newAuditAction.Creator = creator
+				result = t.createAuditActionHandler(stub, newAuditAction)
+			}
+			if result.Status != shim.OK {
+				if options.AllOrNothing {
+					return result
+				}
+				body := ccerror.Parse(result)
+				item.Status = BULK_FAILED
+				item.Code = body.Code
+				item.Message = body.Message
+				report.Failed++
+				continue
+			}
+
+			item.Status = BULK_CREATED
+			report.Created++
+			createdIDs = append(createdIDs, item.ID)
+		}
+
+		if len(createdIDs) > 0 {
+			result = t.setChangeEvent(stub, ChangeEvent{EventType: EVENT_BULK_CREATED, ObjectIDs: createdIDs})
+			if result.Status != shim.OK {
+				return result
+			}
+		}
+	}
+
+	reportAsBytes, err := json.Marshal(report)
+	if err != nil {
+		return ccerror.Internal("Failed to get encode response: " + err.Error())
+	}
+
+	logger.Info(stub, "bulk created logs", logging.Fields{"created": report.Created, "failed": report.Failed})
+	return shim.Success(reportAsBytes)
+}
+
+func newBulkItemResult(index int, objectType string, ID string, result pb.Response, batchIDs map[string]bool) BulkItemResult {
+	item := BulkItemResult{Index: index, ObjectType: objectType, ID: ID, Status: BULK_VALID}
+	if result.Status != shim.OK {
+		body := ccerror.Parse(result)
+		item.Status = BULK_FAILED
+		item.Code = body.Code
+		item.Message = body.Message
+		return item
+	}
+
+	if batchIDs[ID] {
+		item.Status = BULK_FAILED
+		item.Code = ccerror.ALREADY_EXISTS
+		item.Message = "Object with ID " + ID + " is repeated in the batch"
+		return item
+	}
+	batchIDs[ID] = true
+	return item
+}
+
+// batchReference is a field of a bulk item which references another object
+type batchReference struct {
+	Field string
+	ID    string
+}
+
+func logReferences(log Log) []batchReference {
+	references := []batchReference{{"supplychain_id", log.Supplychain}, {"product", log.Product}}
+	for i, product := range log.Products {
+		references = append(references, batchReference{"products[" + strconv.Itoa(i) + "]", product})
+	}
+	for i, ref := range log.Ref {
+		references = append(references, batchReference{"ref[" + strconv.Itoa(i) + "]", ref})
+	}
+	return references
+}
+
+// validBatchLogs returns the types of the valid Logs of a batch, these may
+// be referenced by the other items
+func validBatchLogs(items []BulkItemResult) map[string]string {
+	pending := map[string]string{}
+	for _, item := range items {
+		if item.ObjectType == TYPE_LOG && item.Status == BULK_VALID {
+			pending[item.ID] = TYPE_LOG
+		}
+	}
+	return pending
+}
+
+// failedBatchLogs returns the IDs of the Logs of a batch which will not be
+// written. A Log which failed because its ID exists keeps the stored object.
+func failedBatchLogs(items []BulkItemResult, pending map[string]string) map[string]bool {
+	failedIDs := map[string]bool{}
+	for _, item := range items {
+		if item.ObjectType == TYPE_LOG && item.Status == BULK_FAILED && item.Code != ccerror.ALREADY_EXISTS && len(pending[item.ID]) == 0 {
+			failedIDs[item.ID] = true
+		}
+	}
+	return failedIDs
+}
+
+// failBatchReferences fails a valid item which references a Log of the batch
+// that failed. In every integrity mode, the item would point at an object
+// which is never written.
+func failBatchReferences(item *BulkItemResult, name string, references []batchReference, failedIDs map[string]bool) bool {
+	fieldErrors := []string{}
+	for _, reference := range references {
+		if len(reference.ID) > 0 && failedIDs[reference.ID] {
+			fieldErrors = append(fieldErrors, reference.Field+": "+reference.ID+" failed in the batch")
+		}
+	}
+	if len(fieldErrors) == 0 {
+		return false
+	}
+	item.Status = BULK_FAILED
+	item.Code = ccerror.INVALID_REFERENCE
+	item.Message = "Invalid references of " + name + " " + item.ID + ": " + strings.Join(fieldErrors, "; ")
+	return true
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/deevotech/sc-chaincode.deevo.io/lib/ccerror"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+func newBulkData() BulkData {
+	return BulkData{
+		Logs: []Log{
+			{ObjectType: TYPE_LOG, ID: "Log_1", Time: time.Now().Unix(), CTE: "shipping", Product: "Product_1"},
+			{ObjectType: "product", ID: "Log_2", Time: time.Now().Unix(), CTE: "shipping", Product: "Product_1"},
+			{ObjectType: TYPE_LOG, ID: "Log_1", Time: time.Now().Unix(), CTE: "receiving", Product: "Product_1"},
+			{ObjectType: TYPE_LOG, ID: "Log_3", Time: time.Now().Unix(), CTE: "receiving", Product: "Product_1"},
+		},
+		AuditActions: []AuditAction{
+			{ObjectType: TYPE_AUDITACTION, ID: "AuditAction_1", Auditor: "Auditor_1", ObjectID: "Log_1"},
+		},
+	}
+}
+
+func TestFood_BulkCreateLogs(t *testing.T) {
+	scc := new(FoodChaincode)
+	stub := shim.NewMockStub("food", scc)
+
+	checkInit(t, stub, [][]byte{})
+	checkCreateReferences(t, stub, map[string]string{"Product_1": TYPE_PRODUCT, "Auditor_1": TYPE_AUDITOR})
+
+	report := checkBulkCreateLogs(t, stub, newBulkData(), BulkOptions{}, true)
+	if report.Created != 3 || report.Failed != 2 {
+		fmt.Println("Bulk result was not as expected", report)
+		t.FailNow()
+	}
+	if report.Items[1].Code != ccerror.TYPE_MISMATCH || report.Items[2].Code != ccerror.ALREADY_EXISTS {
+		fmt.Println("Bulk result was not as expected", report.Items)
+		t.FailNow()
+	}
+	if stub.State["Log_3"] == nil || stub.State["AuditAction_1"] == nil {
+		fmt.Println("Valid items were not created")
+		t.FailNow()
+	}
+}
+
+func TestFood_BulkCreateLogsAllOrNothing(t *testing.T) {
+	scc := new(FoodChaincode)
+	stub := shim.NewMockStub("food", scc)
+
+	checkInit(t, stub, [][]byte{})
+
+	checkBulkCreateLogs(t, stub, newBulkData(), BulkOptions{AllOrNothing: true}, false)
+	if stub.State["Log_1"] != nil {
+		fmt.Println("Nothing should be created")
+		t.FailNow()
+	}
+}
+
+func TestFood_BulkCreateLogsDryRun(t *testing.T) {
+	scc := new(FoodChaincode)
+	stub := shim.NewMockStub("food", scc)
+
+	checkInit(t, stub, [][]byte{})
+	checkCreateReferences(t, stub, map[string]string{"Product_1": TYPE_PRODUCT, "Auditor_1": TYPE_AUDITOR})
+
+	report := checkBulkCreateLogs(t, stub, newBulkData(), BulkOptions{DryRun: true}, true)
+	if report.Created != 0 || report.Failed != 2 || report.Items[0].Status != BULK_VALID {
+		fmt.Println("Bulk result was not as expected", report)
+		t.FailNow()
+	}
+	if stub.State["Log_1"] != nil {
+		fmt.Println("Nothing should be created")
+		t.FailNow()
+	}
+}
+
+func TestFood_BulkCreateLogsWithFailedReference(t *testing.T) {
+	scc := new(FoodChaincode)
+	stub := shim.NewMockStub("food", scc)
+
+	checkInit(t, stub, [][]byte{})
+	checkSetIntegrityMode(t, stub, INTEGRITY_STRICT)
+	checkCreateReferences(t, stub, map[string]string{"Product_1": TYPE_PRODUCT, "Auditor_1": TYPE_AUDITOR})
+
+	data := BulkData{
+		Logs: []Log{
+			{ObjectType: TYPE_LOG, ID: "Log_1", Time: time.Now().Unix(), CTE: "shipping", Product: "Product_2"},
+			{ObjectType: TYPE_LOG, ID: "Log_2", Time: time.Now().Unix(), CTE: "receiving", Product: "Product_1", Ref: []string{"Log_1"}},
+		},
+		AuditActions: []AuditAction{
+			{ObjectType: TYPE_AUDITACTION, ID: "AuditAction_1", Auditor: "Auditor_1", ObjectID: "Log_1"},
+		},
+	}
+	report := checkBulkCreateLogs(t, stub, data, BulkOptions{}, true)
+	if report.Created != 0 || report.Failed != 3 {
+		fmt.Println("Bulk result was not as expected", report)
+		t.FailNow()
+	}
+	if report.Items[1].Code != ccerror.INVALID_REFERENCE || report.Items[2].Code != ccerror.INVALID_REFERENCE {
+		fmt.Println("Items referencing a failed Log should fail", report.Items)
+		t.FailNow()
+	}
+	if stub.State["Log_2"] != nil || stub.State["AuditAction_1"] != nil {
+		fmt.Println("Items referencing a failed Log should not be created")
+		t.FailNow()
+	}
+}
+
+func checkBulkCreateLogs(t *testing.T, stub *shim.MockStub, data BulkData, options BulkOptions, success bool) BulkResult {
+	dataAsBytes, err := json.Marshal(data)
+	if err != nil {
+		fmt.Println("Failed to encode json")
+		t.FailNow()
+	}
+	optionsAsBytes, err := json.Marshal(options)
+	if err != nil {
+		fmt.Println("Failed to encode json")
+		t.FailNow()
+	}
+
+	res := stub.MockInvoke("1", [][]byte{[]byte("bulkCreateLogs"), dataAsBytes, optionsAsBytes})
+	report := BulkResult{}
+	if !success {
+		if res.Status == shim.OK {
+			fmt.Println("bulkCreateLogs should fail")
+			t.FailNow()
+		}
+		return report
+	}
+	if res.Status != shim.OK {
+		fmt.Println("failed", string(res.Message))
+		t.FailNow()
+	}
+
+	err = json.Unmarshal(res.Payload, &report)
+	if err != nil {
+		fmt.Println("Failed to decode json of BulkResult:", err.Error())
+		t.FailNow()
+	}
+	return report
+}
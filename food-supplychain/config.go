@@ -0,0 +1,243 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/deevotech/sc-chaincode.deevo.io/lib/ccconfig"
+	"github.com/deevotech/sc-chaincode.deevo.io/lib/ccerror"
+	"github.com/deevotech/sc-chaincode.deevo.io/lib/logging"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+// readConfig returns the stored config. Before Init stored one, the config
+// has no restrictions and the integrity mode set by setIntegrityMode.
+func (t *FoodChaincode) readConfig(stub shim.ChaincodeStubInterface) (pb.Response, ChaincodeConfig) {
+	config := ChaincodeConfig{ObjectType: TYPE_CONFIG, IntegrityMode: INTEGRITY_LENIENT}
+
+	configAsBytes, err := ccconfig.Read(stub)
+	if err != nil {
+		return ccerror.Internal("Failed to get config, error: " + err.Error()), config
+	} else if configAsBytes == nil {
+		result, mode := t.readLegacyIntegrityMode(stub)
+		if result.Status != shim.OK {
+			return result, config
+		}
+		if len(mode) > 0 {
+			config.IntegrityMode = mode
+		}
+		return shim.Success(nil), config
+	}
+
+	err = json.Unmarshal(configAsBytes, &config)
+	if err != nil {
+		return ccerror.Internal("Failed to decode json of ChaincodeConfig: " + err.Error()), config
+	}
+	return shim.Success(nil), config
+}
+
+// readLegacyIntegrityMode returns the integrity mode which was stored on its
+// own before the config existed
+func (t *FoodChaincode) readLegacyIntegrityMode(stub shim.ChaincodeStubInterface) (pb.Response, string) {
+	settingKey, err := stub.CreateCompositeKey(CK_SETTING, []string{SETTING_INTEGRITY_MODE})
+	if err != nil {
+		return ccerror.Internal("Failed to create composite key: " + err.Error()), ""
+	}
+	modeAsBytes, err := stub.GetState(settingKey)
+	if err != nil {
+		return ccerror.Internal("Failed to get integrity mode, error: " + err.Error()), ""
+	}
+	return shim.Success(nil), string(modeAsBytes)
+}
+
+// loggingSettings returns the logging settings of the stored config and
+// the version of the config
+func (t *FoodChaincode) loggingSettings(stub shim.ChaincodeStubInterface) (logging.Settings, int64, error) {
+	result, config := t.readConfig(stub)
+	if result.Status != shim.OK {
+		return logging.Settings{}, 0, errors.New(result.Message)
+	}
+	return config.Logging, config.Version, nil
+}
+
+func checkConfig(config *ChaincodeConfig) pb.Response {
+	if config.ObjectType != TYPE_CONFIG {
+		return ccerror.TypeMismatch("Expexted objectType " + TYPE_CONFIG + " for ChaincodeConfig")
+	}
+	if len(config.IntegrityMode) < 1 {
+		config.IntegrityMode = INTEGRITY_LENIENT
+	}
+	if config.IntegrityMode != INTEGRITY_STRICT && config.IntegrityMode != INTEGRITY_LENIENT {
+		return ccerror.ValidationFailed("Integrity mode must be " + INTEGRITY_STRICT + " or " + INTEGRITY_LENIENT)
+	}
+
+	err := config.Logging.Check()
+	if err != nil {
+		return ccerror.ValidationFailed("Invalid logging settings: " + err.Error())
+	}
+
+	for name, values := range map[string][]string{"allowedCTEs": config.AllowedCTEs, "adminMSPs": config.AdminMSPs} {
+		seen := map[string]bool{}
+		for _, value := range values {
+			if len(value) < 1 {
+				return ccerror.ValidationFailed("Entries of " + name + " can not by empty")
+			}
+			if seen[value] {
+				return ccerror.ValidationFailed("Entry " + value + " of " + name + " is repeated")
+			}
+			seen[value] = true
+		}
+	}
+	return shim.Success(nil)
+}
+
+// putConfig stores a config as the given version and adds it to the history
+func (t *FoodChaincode) putConfig(stub shim.ChaincodeStubInterface, config ChaincodeConfig, version int64) pb.Response {
+	result, updater := t.getCreator(stub)
+	if result.Status != shim.OK {
+		return result
+	}
+	config.Updater = ccconfig.Updater(updater)
+	config.Version = version
+	config.SchemaVersion = SCHEMA_VERSION
+
+	configAsBytes, err := json.Marshal(config)
+	if err != nil {
+		return ccerror.Internal("Failed to encode json of ChaincodeConfig: " + err.Error())
+	}
+	err = ccconfig.Put(stub, configAsBytes, version)
+	if err != nil {
+		return ccerror.Internal("Failed to save config, error: " + err.Error())
+	}
+	return shim.Success(nil)
+}
+
+// initConfig stores the config passed to Init. Without one, an instantiate
+// stores the default config and an upgrade keeps the current one.
+func (t *FoodChaincode) initConfig(stub shim.ChaincodeStubInterface) pb.Response {
+	_, args := stub.GetFunctionAndParameters()
+	if len(args) > 1 {
+		return ccerror.ValidationFailed("Incorrect number of arguments. Expecting 0 or 1")
+	}
+
+	result, current := t.readConfig(stub)
+	if result.Status != shim.OK {
+		return result
+	}
+	if len(args) == 0 {
+		if current.Version > 0 {
+			return shim.Success(nil)
+		}
+		result = t.putConfig(stub, current, 1)
+	} else {
+		newConfig := ChaincodeConfig{}
+		err := json.Unmarshal([]byte(args[0]), &newConfig)
+		if err != nil {
+			return ccerror.ValidationFailed("Failed to decode json of ChaincodeConfig: " + err.Error())
+		}
+		result = checkConfig(&newConfig)
+		if result.Status != shim.OK {
+			return result
+		}
+		result = t.putConfig(stub, newConfig, current.Version+1)
+	}
+	if result.Status != shim.OK {
+		return result
+	}
+
+	// the integrity mode is part of the config now
+	result, mode := t.readLegacyIntegrityMode(stub)
+	if result.Status != shim.OK || len(mode) == 0 {
+		return result
+	}
+	return t.deleteCompositeKey(stub, CK_SETTING, []string{SETTING_INTEGRITY_MODE})
+}
+
+func (t *FoodChaincode) getConfig(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	logger.Debug(stub, "start getConfig")
+
+	result, config := t.readConfig(stub)
+	if result.Status != shim.OK {
+		return result
+	}
+	configAsBytes, err := json.Marshal(config)
+	if err != nil {
+		return ccerror.Internal("Failed to get encode response: " + err.Error())
+	}
+
+	logger.Debug(stub, "end getConfig (success)")
+	return shim.Success(configAsBytes)
+}
+
+// updateConfig replaces the config. The version of the new config is the
+// one it replaces, so concurrent changes are not lost.
+func (t *FoodChaincode) updateConfig(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	logger.Debug(stub, "start updateConfig")
+
+	newConfig := ChaincodeConfig{}
+	err := json.Unmarshal([]byte(args[0]), &newConfig)
+	if err != nil {
+		return ccerror.ValidationFailed("Failed to decode json of ChaincodeConfig: " + err.Error())
+	}
+	result := checkConfig(&newConfig)
+	if result.Status != shim.OK {
+		return result
+	}
+
+	result, current := t.readConfig(stub)
+	if result.Status != shim.OK {
+		return result
+	}
+	if newConfig.Version != current.Version {
+		return versionConflict(SETTING_CONFIG, newConfig.Version, current.Version)
+	}
+
+	// an admin can not lock its own MSP out
+	result, updater := t.getCreator(stub)
+	if result.Status != shim.OK {
+		return result
+	}
+	if !ccconfig.IsAdmin(newConfig.AdminMSPs, updater.MSPID) {
+		return ccerror.ValidationFailed("adminMSPs of ChaincodeConfig have to include " + updater.MSPID)
+	}
+
+	result = t.putConfig(stub, newConfig, current.Version+1)
+	if result.Status != shim.OK {
+		return result
+	}
+
+	logger.Debug(stub, "end updateConfig (success)")
+	return shim.Success(nil)
+}
+
+// getConfigHistory returns every version of the config, oldest first
+func (t *FoodChaincode) getConfigHistory(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	logger.Debug(stub, "start getConfigHistory")
+
+	configs, err := ccconfig.History(stub)
+	if err != nil {
+		return ccerror.Internal("Failed to get history of config: " + err.Error())
+	}
+
+	configsAsBytes, err := json.Marshal(configs)
+	if err != nil {
+		return ccerror.Internal("Failed to get encode response: " + err.Error())
+	}
+
+	logger.Debug(stub, "end getConfigHistory (success)")
+	return shim.Success(configsAsBytes)
+}
+
+func isAllowedCTE(config ChaincodeConfig, cte string) bool {
+	if len(config.AllowedCTEs) == 0 {
+		return true
+	}
+	for _, allowedCTE := range config.AllowedCTEs {
+		if allowedCTE == cte {
+			return true
+		}
+	}
+	return false
+}
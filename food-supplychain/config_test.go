@@ -0,0 +1,153 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/deevotech/sc-chaincode.deevo.io/lib/ccerror"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+func initConfigData(t *testing.T, stub *shim.MockStub, config ChaincodeConfig) {
+	configAsBytes, err := json.Marshal(config)
+	if err != nil {
+		fmt.Println("Failed to encode json")
+		t.FailNow()
+	}
+	checkInit(t, stub, [][]byte{[]byte("init"), configAsBytes})
+}
+
+func TestFood_InitWithConfig(t *testing.T) {
+	scc := new(FoodChaincode)
+	stub := shim.NewMockStub("food", scc)
+
+	initConfigData(t, stub, ChaincodeConfig{ObjectType: TYPE_CONFIG, AllowedCTEs: []string{"shipping"}})
+
+	config := checkGetConfig(t, stub)
+	if config.Version != 1 || config.IntegrityMode != INTEGRITY_LENIENT || config.Updater.MSPID != TEST_MSP {
+		fmt.Println("Config was not as expected", config)
+		t.FailNow()
+	}
+
+	checkCreateLogAt(t, stub, Log{ObjectType: TYPE_LOG, ID: "Log_1", Time: 100, Ref: []string{}, CTE: "shipping"})
+	res := stub.MockInvoke("1", [][]byte{[]byte("createLog"), []byte(`{"objectType": "log", "id": "Log_2", "time": 100, "ref": [], "cte": "receiving"}`)})
+	if res.Status == shim.OK {
+		fmt.Println("createLog should fail for a CTE which is not allowed")
+		t.FailNow()
+	}
+
+	// an upgrade without config keeps the config
+	checkInit(t, stub, [][]byte{})
+	if checkGetConfig(t, stub).Version != 1 {
+		fmt.Println("Upgrade should keep the config")
+		t.FailNow()
+	}
+}
+
+func TestFood_InitWithInvalidConfig(t *testing.T) {
+	scc := new(FoodChaincode)
+	stub := shim.NewMockStub("food", scc)
+
+	setMockIdentity(TEST_MSP, nil)
+	res := stub.MockInit("1", [][]byte{[]byte("init"), []byte(`{"objectType": "config", "integrityMode": "loose"}`)})
+	if res.Status == shim.OK {
+		fmt.Println("Init should fail for an unknown integrity mode")
+		t.FailNow()
+	}
+}
+
+func TestFood_UpdateConfig(t *testing.T) {
+	scc := new(FoodChaincode)
+	stub := shim.NewMockStub("food", scc)
+
+	checkInit(t, stub, [][]byte{})
+
+	newConfig := ChaincodeConfig{ObjectType: TYPE_CONFIG, AdminMSPs: []string{TEST_MSP}, IntegrityMode: INTEGRITY_LENIENT, Version: 1}
+	res := checkUpdateConfig(stub, newConfig)
+	if res.Status == shim.OK {
+		fmt.Println("updateConfig should fail without " + ADMIN_ATTRIBUTE)
+		t.FailNow()
+	}
+
+	setMockIdentity(TEST_MSP, map[string]string{ADMIN_ATTRIBUTE: "true"})
+	res = checkUpdateConfig(stub, newConfig)
+	if res.Status != shim.OK {
+		fmt.Println("failed", string(res.Message))
+		t.FailNow()
+	}
+	res = stub.MockInvoke("1", [][]byte{[]byte("getIntegrityMode")})
+	if string(res.Payload) != INTEGRITY_LENIENT {
+		fmt.Println("Integrity mode should come from the config")
+		t.FailNow()
+	}
+
+	res = checkUpdateConfig(stub, newConfig)
+	if res.Status != ccerror.Status(ccerror.VERSION_CONFLICT) {
+		fmt.Println("updateConfig should fail with a version conflict", res.Message)
+		t.FailNow()
+	}
+
+	newConfig.AdminMSPs = []string{"Org2MSP"}
+	newConfig.Version = 2
+	res = checkUpdateConfig(stub, newConfig)
+	if res.Status == shim.OK {
+		fmt.Println("updateConfig should not lock out the MSP of the admin")
+		t.FailNow()
+	}
+
+	res = stub.MockInvoke("1", [][]byte{[]byte("getConfigHistory")})
+	if res.Status != shim.OK {
+		fmt.Println("failed", string(res.Message))
+		t.FailNow()
+	}
+	history := []ChaincodeConfig{}
+	json.Unmarshal(res.Payload, &history)
+	if len(history) != 2 || history[0].Version != 1 || history[1].IntegrityMode != INTEGRITY_LENIENT {
+		fmt.Println("History of config was not as expected", history)
+		t.FailNow()
+	}
+}
+
+func TestFood_AdminMSPs(t *testing.T) {
+	scc := new(FoodChaincode)
+	stub := shim.NewMockStub("food", scc)
+
+	initConfigData(t, stub, ChaincodeConfig{ObjectType: TYPE_CONFIG, AdminMSPs: []string{"Org2MSP"}})
+
+	setMockIdentity(TEST_MSP, map[string]string{ADMIN_ATTRIBUTE: "true"})
+	res := stub.MockInvoke("1", [][]byte{[]byte("setIntegrityMode"), []byte(INTEGRITY_LENIENT)})
+	if res.Status == shim.OK {
+		fmt.Println("Admins of other MSPs than adminMSPs should be rejected")
+		t.FailNow()
+	}
+
+	setMockIdentity("Org2MSP", map[string]string{ADMIN_ATTRIBUTE: "true"})
+	res = stub.MockInvoke("1", [][]byte{[]byte("setIntegrityMode"), []byte(INTEGRITY_LENIENT)})
+	if res.Status != shim.OK {
+		fmt.Println("failed", string(res.Message))
+		t.FailNow()
+	}
+}
+
+func checkGetConfig(t *testing.T, stub *shim.MockStub) ChaincodeConfig {
+	res := stub.MockInvoke("1", [][]byte{[]byte("getConfig")})
+	if res.Status != shim.OK {
+		fmt.Println("failed", string(res.Message))
+		t.FailNow()
+	}
+	config := ChaincodeConfig{}
+	err := json.Unmarshal(res.Payload, &config)
+	if err != nil {
+		fmt.Println("Failed to decode json of ChaincodeConfig:", err.Error())
+		t.FailNow()
+	}
+	return config
+}
+
+func checkUpdateConfig(stub *shim.MockStub, config ChaincodeConfig) pb.Response {
+	configAsBytes, _ := json.Marshal(config)
+	return stub.MockInvoke("1", [][]byte{[]byte("updateConfig"), configAsBytes})
+}
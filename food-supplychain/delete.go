@@ -0,0 +1,290 @@
+package main
+
+import (
+	"encoding/json"
+
+	"github.com/deevotech/sc-chaincode.deevo.io/lib/ccerror"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+// deleteObject removes an object and its index keys. A tombstone with the
+// reason and the deleter is left when a reason is given.
+func (t *FoodChaincode) deleteObject(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	logger.Debug(stub, "start deleteObject")
+
+	reason := ""
+	if len(args) == 3 {
+		reason = args[2]
+	}
+
+	result := t.removeObject(stub, args[0], args[1], reason, false)
+
+	if result.Status == shim.OK {
+		logger.Debug(stub, "end deleteObject (success)")
+	}
+	return result
+}
+
+// archiveObject removes an object and its index keys like deleteObject, but
+// keeps a copy of the object in its tombstone
+func (t *FoodChaincode) archiveObject(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	logger.Debug(stub, "start archiveObject")
+	result := t.removeObject(stub, args[0], args[1], args[2], true)
+
+	if result.Status == shim.OK {
+		logger.Debug(stub, "end archiveObject (success)")
+	}
+	return result
+}
+
+func (t *FoodChaincode) getTombstone(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	logger.Debug(stub, "start getTombstone")
+
+	ID := args[0]
+	tombstoneKey, err := stub.CreateCompositeKey(CK_TOMBSTONE, []string{ID})
+	if err != nil {
+		return ccerror.Internal("Failed to create composite key: " + err.Error())
+	}
+	tombstoneAsBytes, err := stub.GetState(tombstoneKey)
+	if err != nil {
+		return ccerror.Internal("Failed to get tombstone of Object with ID: " + ID + ", error: " + err.Error())
+	} else if tombstoneAsBytes == nil {
+		return ccerror.NotFound("Tombstone of Object with ID " + ID + " does not exist")
+	}
+
+	logger.Debug(stub, "end getTombstone (success)")
+	return shim.Success(tombstoneAsBytes)
+}
+
+func (t *FoodChaincode) removeObject(stub shim.ChaincodeStubInterface, ID string, objectType string, reason string, archive bool) pb.Response {
+	existedObjectAsBytes, err := stub.GetState(ID)
+	if err != nil {
+		return ccerror.Internal("Failed to get existed Object with ID: " + ID + ", error: " + err.Error())
+	} else if existedObjectAsBytes == nil {
+		return ccerror.NotFound("Object with ID " + ID + " does not exist")
+	}
+
+	liteModel := LiteModel{}
+	err = json.Unmarshal(existedObjectAsBytes, &liteModel)
+	if err != nil {
+		return ccerror.Internal("Failed to get decode object: " + err.Error())
+	}
+	if liteModel.ObjectType != objectType {
+		return ccerror.TypeMismatch("ObjectType does not match")
+	}
+
+	// Traceables and Auditors record no creator, only admins remove them
+	if isTraceableType(objectType) || objectType == TYPE_AUDITOR {
+		result := t.checkAdmin(stub)
+		if result.Status != shim.OK {
+			return result
+		}
+	}
+
+	result, deleter := t.getCreator(stub)
+	if result.Status != shim.OK {
+		return result
+	}
+
+	event := ChangeEvent{ObjectID: ID, ObjectType: objectType}
+	if objectType == TYPE_LOG {
+		result, event = t.removeLogKeys(stub, existedObjectAsBytes, deleter, event)
+	} else if objectType == TYPE_AUDITACTION {
+		result, event = t.removeAuditActionKeys(stub, existedObjectAsBytes, deleter, event)
+	} else if objectType == TYPE_AUDITOR {
+		result = t.removeAuditorKeys(stub, existedObjectAsBytes)
+	} else if objectType == TYPE_RECALL {
+		result = ccerror.ValidationFailed("Recalls can not be removed, close them instead")
+	} else {
+		result, event = t.removeTraceableKeys(stub, existedObjectAsBytes, event)
+	}
+	if result.Status != shim.OK {
+		return result
+	}
+
+	err = stub.DelState(ID)
+	if err != nil {
+		return ccerror.Internal("Failed to delete the object with ID: " + ID + ", error: " + err.Error())
+	}
+
+	if archive || len(reason) > 0 {
+		tombstone := Tombstone{
+			ObjectType:  TYPE_TOMBSTONE,
+			ID:          ID,
+			DeletedType: objectType,
+			Reason:      reason,
+			Deleter:     deleter,
+			Archived:    archive,
+		}
+		if archive {
+			tombstone.Data = existedObjectAsBytes
+		}
+		result = t.putTombstone(stub, tombstone)
+		if result.Status != shim.OK {
+			return result
+		}
+	}
+
+	if archive {
+		event.EventType = EVENT_OBJECT_ARCHIVED
+	} else {
+		event.EventType = EVENT_OBJECT_DELETED
+	}
+	return t.setChangeEvent(stub, event)
+}
+
+func (t *FoodChaincode) removeLogKeys(stub shim.ChaincodeStubInterface, logAsBytes []byte, deleter Creator, event ChangeEvent) (pb.Response, ChangeEvent) {
+	oldLog := Log{}
+	err := json.Unmarshal(logAsBytes, &oldLog)
+	if err != nil {
+		return ccerror.Internal("Failed to get decode Log: " + err.Error()), event
+	}
+
+	result := t.checkCreatorMSP(oldLog.ID, oldLog.Creator, deleter)
+	if result.Status != shim.OK {
+		return result, event
+	}
+	result = t.checkNotReferenced(stub, oldLog.ID, []string{CK_REF_LOG, CK_AUDIT_OBJ})
+	if result.Status != shim.OK {
+		return result, event
+	}
+
+	if len(oldLog.Supplychain) > 0 {
+		result = t.deleteCompositeKey(stub, CK_SC_LOG, []string{oldLog.Supplychain, oldLog.ID})
+		if result.Status != shim.OK {
+			return result, event
+		}
+	}
+	result = t.updateProductKeys(stub, oldLog.ID, logProducts(oldLog), nil)
+	if result.Status != shim.OK {
+		return result, event
+	}
+	result = t.updateLogKeys(stub, &oldLog, nil)
+	if result.Status != shim.OK {
+		return result, event
+	}
+	result = t.updateRefKeys(stub, oldLog.ID, oldLog.Ref, []string{})
+	if result.Status != shim.OK {
+		return result, event
+	}
+	result = t.updateDocumentKeys(stub, oldLog.ID, oldLog.Documents, nil)
+	if result.Status != shim.OK {
+		return result, event
+	}
+	if len(oldLog.PrivateHashes) > 0 {
+		err = stub.DelPrivateData(COLLECTION_LOG_PRIVATE, oldLog.ID)
+		if err != nil {
+			return ccerror.Internal("Failed to delete private data of Log " + oldLog.ID + ": " + err.Error()), event
+		}
+	}
+
+	event.Supplychain = oldLog.Supplychain
+	event.Product = oldLog.Product
+	event.OldIndexes = logIndexes(oldLog)
+	return shim.Success(nil), event
+}
+
+func (t *FoodChaincode) removeAuditActionKeys(stub shim.ChaincodeStubInterface, auditActionAsBytes []byte, deleter Creator, event ChangeEvent) (pb.Response, ChangeEvent) {
+	oldAuditAction := AuditAction{}
+	err := json.Unmarshal(auditActionAsBytes, &oldAuditAction)
+	if err != nil {
+		return ccerror.Internal("Failed to get decode AuditAction: " + err.Error()), event
+	}
+
+	result := t.checkCreatorMSP(oldAuditAction.ID, oldAuditAction.Creator, deleter)
+	if result.Status != shim.OK {
+		return result, event
+	}
+
+	result = t.deleteCompositeKey(stub, CK_AUDITOR_AUDIT, []string{oldAuditAction.Auditor, oldAuditAction.ID})
+	if result.Status != shim.OK {
+		return result, event
+	}
+	result = t.deleteCompositeKey(stub, CK_AUDIT_OBJ, []string{oldAuditAction.ObjectID, oldAuditAction.ID})
+	if result.Status != shim.OK {
+		return result, event
+	}
+	result = t.deleteCompositeKey(stub, CK_AUDIT_STATUS, []string{oldAuditAction.Status, oldAuditAction.ID})
+	if result.Status != shim.OK {
+		return result, event
+	}
+	result = t.updateDocumentKeys(stub, oldAuditAction.ID, oldAuditAction.Documents, nil)
+	if result.Status != shim.OK {
+		return result, event
+	}
+
+	event.OldIndexes = auditActionIndexes(oldAuditAction)
+	return shim.Success(nil), event
+}
+
+func (t *FoodChaincode) removeAuditorKeys(stub shim.ChaincodeStubInterface, auditorAsBytes []byte) pb.Response {
+	oldAuditor := Auditor{}
+	err := json.Unmarshal(auditorAsBytes, &oldAuditor)
+	if err != nil {
+		return ccerror.Internal("Failed to get decode Auditor: " + err.Error())
+	}
+
+	result := t.checkNotReferenced(stub, oldAuditor.ID, []string{CK_AUDITOR_AUDIT})
+	if result.Status != shim.OK {
+		return result
+	}
+	return t.updateAuditorKeys(stub, &oldAuditor, nil)
+}
+
+func (t *FoodChaincode) removeTraceableKeys(stub shim.ChaincodeStubInterface, traceableAsBytes []byte, event ChangeEvent) (pb.Response, ChangeEvent) {
+	oldTraceable := Traceable{}
+	err := json.Unmarshal(traceableAsBytes, &oldTraceable)
+	if err != nil {
+		return ccerror.Internal("Failed to get decode Traceable: " + err.Error()), event
+	}
+
+	result := t.checkNotReferenced(stub, oldTraceable.ID, []string{CK_SC_LOG, CK_PRODUCT_LOG, CK_REF_LOG, CK_PARENT_CHILD, CK_AUDIT_OBJ, CK_AFFECTED_RECALL})
+	if result.Status != shim.OK {
+		return result, event
+	}
+
+	if len(oldTraceable.ParentID()) > 0 {
+		result = t.deleteCompositeKey(stub, CK_PARENT_CHILD, []string{oldTraceable.ParentID(), oldTraceable.ID})
+		if result.Status != shim.OK {
+			return result, event
+		}
+	}
+
+	event.OldIndexes = traceableIndexes(oldTraceable)
+	return shim.Success(nil), event
+}
+
+// checkNotReferenced fails if the object has entries in any of the indexes
+func (t *FoodChaincode) checkNotReferenced(stub shim.ChaincodeStubInterface, ID string, indexes []string) pb.Response {
+	for _, index := range indexes {
+		resultsIterator, err := stub.GetStateByPartialCompositeKey(index, []string{ID})
+		if err != nil {
+			return ccerror.Internal(err.Error())
+		}
+		referenced := resultsIterator.HasNext()
+		resultsIterator.Close()
+
+		if referenced {
+			return ccerror.ValidationFailed("Object with ID " + ID + " is still referenced in " + index)
+		}
+	}
+	return shim.Success(nil)
+}
+
+func (t *FoodChaincode) putTombstone(stub shim.ChaincodeStubInterface, tombstone Tombstone) pb.Response {
+	tombstoneKey, err := stub.CreateCompositeKey(CK_TOMBSTONE, []string{tombstone.ID})
+	if err != nil {
+		return ccerror.Internal("Failed to create composite key: " + err.Error())
+	}
+	tombstoneAsBytes, err := json.Marshal(tombstone)
+	if err != nil {
+		return ccerror.Internal("Failed to encode json of Tombstone: " + err.Error())
+	}
+	err = stub.PutState(tombstoneKey, tombstoneAsBytes)
+	if err != nil {
+		return ccerror.Internal("Failed to save tombstone of Object with ID: " + tombstone.ID + ", error: " + err.Error())
+	}
+	return shim.Success(nil)
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/deevotech/sc-chaincode.deevo.io/lib/ccerror"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+func TestFood_DeleteLogAndProduct(t *testing.T) {
+	scc := new(FoodChaincode)
+	stub := shim.NewMockStub("food", scc)
+
+	checkInit(t, stub, [][]byte{})
+	initTraceData(t, stub)
+
+	// Product_A is still referenced by Log_B
+	setMockIdentity(TEST_MSP, map[string]string{ADMIN_ATTRIBUTE: "true"})
+	res := stub.MockInvoke("1", [][]byte{[]byte("deleteObject"), []byte("Product_A"), []byte(TYPE_PRODUCT)})
+	setMockIdentity(TEST_MSP, nil)
+	if res.Status == shim.OK {
+		fmt.Println("Referenced product should not be deleted")
+		t.FailNow()
+	}
+
+	res = stub.MockInvoke("1", [][]byte{[]byte("deleteObject"), []byte("Log_B"), []byte(TYPE_LOG), []byte("wrong lot")})
+	if res.Status != shim.OK {
+		fmt.Println("failed", string(res.Message))
+		t.FailNow()
+	}
+	if stub.State["Log_B"] != nil {
+		fmt.Println("Log_B was not deleted")
+		t.FailNow()
+	}
+	checkLastEvent(t, stub, EVENT_OBJECT_DELETED, "Log_B")
+
+	tombstone := checkTombstone(t, stub, "Log_B")
+	if tombstone.Reason != "wrong lot" || tombstone.Archived || tombstone.Deleter.MSPID != TEST_MSP {
+		fmt.Println("Tombstone was not as expected")
+		t.FailNow()
+	}
+
+	res = stub.MockInvoke("1", [][]byte{[]byte("getLogsOfProduct"), []byte("Product_B")})
+	if res.Status != shim.OK {
+		fmt.Println("failed", string(res.Message))
+		t.FailNow()
+	}
+	resLogs := []Log{}
+	err := json.Unmarshal(res.Payload, &resLogs)
+	if err != nil {
+		fmt.Println("Failed to decode json of Logs:", err.Error())
+		t.FailNow()
+	}
+	if len(resLogs) != 0 {
+		fmt.Println("Size of response does not match")
+		t.FailNow()
+	}
+
+	setMockIdentity(TEST_MSP, map[string]string{ADMIN_ATTRIBUTE: "true"})
+	res = stub.MockInvoke("1", [][]byte{[]byte("deleteObject"), []byte("Product_A"), []byte(TYPE_PRODUCT)})
+	setMockIdentity(TEST_MSP, nil)
+	if res.Status != shim.OK {
+		fmt.Println("failed", string(res.Message))
+		t.FailNow()
+	}
+
+	// the ID can be used again
+	product := Traceable{ObjectType: TYPE_PRODUCT, ID: "Product_A", Name: "Product A"}
+	productAsBytes, err := json.Marshal(product)
+	if err != nil {
+		fmt.Println("Failed to encode json")
+		t.FailNow()
+	}
+	checkCreateTraceable(t, stub, productAsBytes, product)
+}
+
+func TestFood_DeleteTraceableWithoutAdmin(t *testing.T) {
+	scc := new(FoodChaincode)
+	stub := shim.NewMockStub("food", scc)
+
+	checkInit(t, stub, [][]byte{})
+	checkCreateReferences(t, stub, map[string]string{"Product_1": TYPE_PRODUCT, "Auditor_1": TYPE_AUDITOR})
+
+	// members of other MSPs can not remove what TEST_MSP created
+	setMockIdentity("Org2MSP", nil)
+	res := stub.MockInvoke("1", [][]byte{[]byte("deleteObject"), []byte("Product_1"), []byte(TYPE_PRODUCT)})
+	checkErrorCode(t, res, ccerror.FORBIDDEN)
+	res = stub.MockInvoke("1", [][]byte{[]byte("archiveObject"), []byte("Auditor_1"), []byte(TYPE_AUDITOR), []byte("retired")})
+	checkErrorCode(t, res, ccerror.FORBIDDEN)
+	if stub.State["Product_1"] == nil || stub.State["Auditor_1"] == nil {
+		fmt.Println("Objects should not be removed")
+		t.FailNow()
+	}
+
+	setMockIdentity(TEST_MSP, map[string]string{ADMIN_ATTRIBUTE: "true"})
+	res = stub.MockInvoke("1", [][]byte{[]byte("archiveObject"), []byte("Auditor_1"), []byte(TYPE_AUDITOR), []byte("retired")})
+	if res.Status != shim.OK {
+		fmt.Println("failed", string(res.Message))
+		t.FailNow()
+	}
+}
+
+func TestFood_ArchiveAuditAction(t *testing.T) {
+	scc := new(FoodChaincode)
+	stub := shim.NewMockStub("food", scc)
+
+	checkInit(t, stub, [][]byte{})
+	checkCreateReferences(t, stub, map[string]string{"Product_1": TYPE_PRODUCT, "Auditor_1": TYPE_AUDITOR})
+
+	newAuditAction := AuditAction{
+		ObjectType: TYPE_AUDITACTION,
+		ID:         "AuditAction_1",
+		Auditor:    "Auditor_1",
+		Time:       time.Now().Unix(),
+		Location:   "Location_1",
+		ObjectID:   "Product_1",
+	}
+	newAuditActionAsBytes, err := json.Marshal(newAuditAction)
+	if err != nil {
+		fmt.Println("Failed to encode json")
+		t.FailNow()
+	}
+	checkCreateAuditAction(t, stub, newAuditActionAsBytes, newAuditAction)
+
+	res := stub.MockInvoke("1", [][]byte{[]byte("archiveObject"), []byte("AuditAction_1"), []byte(TYPE_AUDITACTION), []byte("duplicate")})
+	if res.Status != shim.OK {
+		fmt.Println("failed", string(res.Message))
+		t.FailNow()
+	}
+
+	tombstone := checkTombstone(t, stub, "AuditAction_1")
+	archived := AuditAction{}
+	err = json.Unmarshal(tombstone.Data, &archived)
+	if err != nil || !tombstone.Archived || archived.ID != "AuditAction_1" {
+		fmt.Println("Tombstone was not as expected")
+		t.FailNow()
+	}
+
+	res = stub.MockInvoke("1", [][]byte{[]byte("getAuditsOfAuditor"), []byte("Auditor_1")})
+	if res.Status != shim.OK {
+		fmt.Println("failed", string(res.Message))
+		t.FailNow()
+	}
+	audits := []AuditAction{}
+	err = json.Unmarshal(res.Payload, &audits)
+	if err != nil {
+		fmt.Println("Failed to decode json of AuditActions:", err.Error())
+		t.FailNow()
+	}
+	if len(audits) != 0 {
+		fmt.Println("Size of response does not match")
+		t.FailNow()
+	}
+}
+
+func checkTombstone(t *testing.T, stub *shim.MockStub, ID string) Tombstone {
+	res := stub.MockInvoke("1", [][]byte{[]byte("getTombstone"), []byte(ID)})
+	if res.Status != shim.OK {
+		fmt.Println("failed", string(res.Message))
+		t.FailNow()
+	}
+
+	tombstone := Tombstone{}
+	err := json.Unmarshal(res.Payload, &tombstone)
+	if err != nil {
+		fmt.Println("Failed to decode json of Tombstone:", err.Error())
+		t.FailNow()
+	}
+	return tombstone
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/deevotech/sc-chaincode.deevo.io/lib/ccerror"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+// checkDocuments validates the document references of a Log or AuditAction.
+// Digests are lower case hex, so the document~object index has one key per
+// document.
+func checkDocuments(ID string, documents []DocumentRef) pb.Response {
+	digests := map[string]bool{}
+	for i, document := range documents {
+		name := "Document " + strconv.Itoa(i) + " of " + ID
+		if len(document.URI) < 1 {
+			return ccerror.ValidationFailed(name + " has no uri")
+		}
+		decoded, err := hex.DecodeString(document.Digest)
+		if err != nil || len(decoded) != 32 || strings.ToLower(document.Digest) != document.Digest {
+			return ccerror.ValidationFailed(name + " needs a sha256 of 64 lower case hex characters")
+		}
+		if document.Size < 0 {
+			return ccerror.ValidationFailed(name + " has a negative size")
+		}
+		if digests[document.Digest] {
+			return ccerror.ValidationFailed(name + " is repeated")
+		}
+		digests[document.Digest] = true
+	}
+	return shim.Success(nil)
+}
+
+// updateDocumentKeys maintains the document~object index of a Log or
+// AuditAction
+func (t *FoodChaincode) updateDocumentKeys(stub shim.ChaincodeStubInterface, objectID string, oldDocuments []DocumentRef, newDocuments []DocumentRef) pb.Response {
+	newSet := map[string]bool{}
+	for _, document := range newDocuments {
+		newSet[document.Digest] = true
+	}
+	oldSet := map[string]bool{}
+	for _, document := range oldDocuments {
+		oldSet[document.Digest] = true
+		if !newSet[document.Digest] {
+			result := t.deleteCompositeKey(stub, CK_DOCUMENT_OBJECT, []string{document.Digest, objectID})
+			if result.Status != shim.OK {
+				return result
+			}
+		}
+	}
+	for _, document := range newDocuments {
+		if !oldSet[document.Digest] {
+			result := t.putCompositeKey(stub, CK_DOCUMENT_OBJECT, []string{document.Digest, objectID})
+			if result.Status != shim.OK {
+				return result
+			}
+		}
+	}
+	return shim.Success(nil)
+}
+
+// verifyDocument checks the digest of a document against the one anchored
+// on a Log or AuditAction. With a uri the document is found by its uri, so a
+// changed document is reported together with the anchored digest.
+func (t *FoodChaincode) verifyDocument(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	logger.Debug(stub, "start verifyDocument")
+
+	objectID := args[0]
+	digest := strings.ToLower(args[1])
+	uri := ""
+	if len(args) == 3 {
+		uri = args[2]
+	}
+
+	objectAsBytes, err := stub.GetState(objectID)
+	if err != nil {
+		return ccerror.Internal("Failed to get existed Object with ID: " + objectID + ", error: " + err.Error())
+	} else if objectAsBytes == nil {
+		return ccerror.NotFound("Object with ID " + objectID + " does not exist")
+	}
+
+	// Logs and AuditActions share the fields which are needed here
+	object := struct {
+		ObjectType string        `json:"objectType"`
+		Documents  []DocumentRef `json:"documents"`
+	}{}
+	err = json.Unmarshal(objectAsBytes, &object)
+	if err != nil {
+		return ccerror.Internal("Failed to get decode object: " + err.Error())
+	}
+	if object.ObjectType != TYPE_LOG && object.ObjectType != TYPE_AUDITACTION {
+		return ccerror.TypeMismatch("Object with ID: " + objectID + " is not a Log or AuditAction")
+	}
+
+	verification := DocumentVerification{ObjectID: objectID, Digest: digest}
+	for i, document := range object.Documents {
+		if len(uri) > 0 && document.URI != uri {
+			continue
+		}
+		if len(uri) > 0 || document.Digest == digest {
+			verification.Document = &object.Documents[i]
+			verification.Verified = document.Digest == digest
+			break
+		}
+	}
+
+	verificationAsBytes, err := json.Marshal(verification)
+	if err != nil {
+		return ccerror.Internal("Failed to get encode response: " + err.Error())
+	}
+
+	logger.Debug(stub, "end verifyDocument (success)")
+	return shim.Success(verificationAsBytes)
+}
+
+// getObjectsByDocument returns the Logs and AuditActions which cite a
+// document digest
+func (t *FoodChaincode) getObjectsByDocument(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	logger.Debug(stub, "start getObjectsByDocument")
+
+	resultsIterator, err := stub.GetStateByPartialCompositeKey(CK_DOCUMENT_OBJECT, []string{strings.ToLower(args[0])})
+	if err != nil {
+		return ccerror.Internal(err.Error())
+	}
+	defer resultsIterator.Close()
+
+	objects := []json.RawMessage{}
+	for resultsIterator.HasNext() {
+		responseRange, err := resultsIterator.Next()
+		if err != nil {
+			return ccerror.Internal(err.Error())
+		}
+		_, compositeKeyParts, err := stub.SplitCompositeKey(responseRange.Key)
+		if err != nil {
+			return ccerror.Internal(err.Error())
+		}
+		objectID := compositeKeyParts[1]
+
+		objectAsBytes, err := stub.GetState(objectID)
+		if err != nil {
+			return ccerror.Internal("Failed to get existed Object with ID: " + objectID + ", error: " + err.Error())
+		} else if objectAsBytes == nil {
+			return ccerror.NotFound("Object with ID " + objectID + " does not exist")
+		}
+		objects = append(objects, objectAsBytes)
+	}
+
+	objectsAsBytes, err := json.Marshal(objects)
+	if err != nil {
+		return ccerror.Internal("Failed to get encode response: " + err.Error())
+	}
+
+	logger.Debug(stub, "end getObjectsByDocument (success)")
+	return shim.Success(objectsAsBytes)
+}
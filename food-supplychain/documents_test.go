@@ -0,0 +1,163 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/deevotech/sc-chaincode.deevo.io/lib/ccerror"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+const (
+	testLabReportDigest = "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+	testCertDigest      = "60303ae22b998861bce3b28f33eec1be758a213c86c93c076dbe9f558c11c752"
+)
+
+func initDocumentData(t *testing.T, stub *shim.MockStub) {
+	checkInit(t, stub, [][]byte{})
+	checkCreateReferences(t, stub, map[string]string{"Product_1": TYPE_PRODUCT, "Auditor_1": TYPE_AUDITOR})
+
+	labReport := DocumentRef{URI: "https://docs.example.com/lab/1.pdf", MediaType: "application/pdf", Digest: testLabReportDigest, Size: 1024}
+	certificate := DocumentRef{URI: "https://docs.example.com/cert/1.pdf", MediaType: "application/pdf", Digest: testCertDigest, Size: 2048}
+
+	newLog := Log{ObjectType: TYPE_LOG, ID: "Log_1", Time: 100, Ref: []string{}, CTE: "receiving", Product: "Product_1", Documents: []DocumentRef{labReport}}
+	checkCreateLogAt(t, stub, newLog)
+
+	audit := AuditAction{ObjectType: TYPE_AUDITACTION, ID: "AuditAction_1", Auditor: "Auditor_1", ObjectID: "Product_1", Documents: []DocumentRef{labReport, certificate}}
+	auditAsBytes, err := json.Marshal(audit)
+	if err != nil {
+		fmt.Println("Failed to encode json")
+		t.FailNow()
+	}
+	checkCreateAuditAction(t, stub, auditAsBytes, audit)
+}
+
+func TestFood_VerifyDocument(t *testing.T) {
+	scc := new(FoodChaincode)
+	stub := shim.NewMockStub("food", scc)
+
+	initDocumentData(t, stub)
+
+	verification := checkVerifyDocument(t, stub, "Log_1", testLabReportDigest)
+	if !verification.Verified || verification.Document == nil || verification.Document.Size != 1024 {
+		fmt.Println("Lab report should be verified", verification)
+		t.FailNow()
+	}
+
+	// a changed document is reported with the anchored digest
+	verification = checkVerifyDocument(t, stub, "Log_1", testCertDigest, "https://docs.example.com/lab/1.pdf")
+	if verification.Verified || verification.Document == nil || verification.Document.Digest != testLabReportDigest {
+		fmt.Println("Changed lab report should not be verified", verification)
+		t.FailNow()
+	}
+
+	verification = checkVerifyDocument(t, stub, "Log_1", testCertDigest)
+	if verification.Verified || verification.Document != nil {
+		fmt.Println("Certificate is not anchored on Log_1", verification)
+		t.FailNow()
+	}
+}
+
+func TestFood_GetObjectsByDocument(t *testing.T) {
+	scc := new(FoodChaincode)
+	stub := shim.NewMockStub("food", scc)
+
+	initDocumentData(t, stub)
+
+	checkObjectsByDocument(t, stub, testLabReportDigest, []string{"AuditAction_1", "Log_1"})
+	checkObjectsByDocument(t, stub, testCertDigest, []string{"AuditAction_1"})
+
+	// removing the document from the log removes it from the index
+	updatedLog := Log{ObjectType: TYPE_LOG, ID: "Log_1", Time: 100, Ref: []string{}, CTE: "receiving", Product: "Product_1", Version: 1}
+	updatedLogAsBytes, err := json.Marshal(updatedLog)
+	if err != nil {
+		fmt.Println("Failed to encode json")
+		t.FailNow()
+	}
+	res := stub.MockInvoke("1", [][]byte{[]byte("updateLog"), updatedLogAsBytes})
+	if res.Status != shim.OK {
+		fmt.Println("failed", string(res.Message))
+		t.FailNow()
+	}
+	checkObjectsByDocument(t, stub, testLabReportDigest, []string{"AuditAction_1"})
+}
+
+func TestFood_CreateLogWithInvalidDocument(t *testing.T) {
+	scc := new(FoodChaincode)
+	stub := shim.NewMockStub("food", scc)
+
+	checkInit(t, stub, [][]byte{})
+
+	newLog := Log{ObjectType: TYPE_LOG, ID: "Log_1", Time: 100, Ref: []string{}, CTE: "receiving", Documents: []DocumentRef{{URI: "https://docs.example.com/lab/1.pdf", Digest: "abc"}}}
+	newLogAsBytes, err := json.Marshal(newLog)
+	if err != nil {
+		fmt.Println("Failed to encode json")
+		t.FailNow()
+	}
+	res := stub.MockInvoke("1", [][]byte{[]byte("createLog"), newLogAsBytes})
+	if res.Status == shim.OK {
+		fmt.Println("createLog should fail for a digest which is not a sha256")
+		t.FailNow()
+	}
+	checkErrorCode(t, res, ccerror.VALIDATION_FAILED)
+
+	digest := strings.Repeat("ab", 32)
+	for _, documents := range [][]DocumentRef{
+		{{Digest: digest}},
+		{{URI: "https://docs.example.com/lab/1.pdf", Digest: digest, Size: -1}},
+		{{URI: "https://docs.example.com/lab/1.pdf", Digest: digest}, {URI: "https://docs.example.com/lab/2.pdf", Digest: digest}},
+	} {
+		newLog.Documents = documents
+		newLogAsBytes, err = json.Marshal(newLog)
+		if err != nil {
+			fmt.Println("Failed to encode json")
+			t.FailNow()
+		}
+		res = stub.MockInvoke("1", [][]byte{[]byte("createLog"), newLogAsBytes})
+		checkErrorCode(t, res, ccerror.VALIDATION_FAILED)
+	}
+}
+
+func checkVerifyDocument(t *testing.T, stub *shim.MockStub, args ...string) DocumentVerification {
+	invokeArgs := [][]byte{[]byte("verifyDocument")}
+	for _, arg := range args {
+		invokeArgs = append(invokeArgs, []byte(arg))
+	}
+	res := stub.MockInvoke("1", invokeArgs)
+	if res.Status != shim.OK {
+		fmt.Println("failed", string(res.Message))
+		t.FailNow()
+	}
+	verification := DocumentVerification{}
+	err := json.Unmarshal(res.Payload, &verification)
+	if err != nil {
+		fmt.Println("Failed to decode json of DocumentVerification:", err.Error())
+		t.FailNow()
+	}
+	return verification
+}
+
+func checkObjectsByDocument(t *testing.T, stub *shim.MockStub, digest string, expected []string) {
+	res := stub.MockInvoke("1", [][]byte{[]byte("getObjectsByDocument"), []byte(digest)})
+	if res.Status != shim.OK {
+		fmt.Println("failed", string(res.Message))
+		t.FailNow()
+	}
+	objects := []LiteModel{}
+	err := json.Unmarshal(res.Payload, &objects)
+	if err != nil {
+		fmt.Println("Failed to decode json of objects:", err.Error())
+		t.FailNow()
+	}
+	IDs := []string{}
+	for _, object := range objects {
+		IDs = append(IDs, object.ID)
+	}
+	if fmt.Sprint(IDs) != fmt.Sprint(expected) {
+		fmt.Println("Objects", IDs, "were not", expected)
+		t.FailNow()
+	}
+}
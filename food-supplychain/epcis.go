@@ -0,0 +1,254 @@
+package main
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/deevotech/sc-chaincode.deevo.io/lib/ccerror"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+// bizStep prefixes of the CBV, the CTE of a Log holds the bare value
+var epcisBizStepPrefixes = []string{
+	"urn:epcglobal:cbv:bizstep:",
+	"https://ref.gs1.org/cbv/BizStep-",
+	"https://gs1.org/voc/Bizstep-",
+}
+
+// importEPCIS creates a Log for every ObjectEvent, AggregationEvent and
+// TransformationEvent of an EPCIS 2.0 document. The optional arguments are
+// the supply chain of the Logs and BulkOptions, the result is a BulkResult.
+func (t *FoodChaincode) importEPCIS(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	logger.Debug(stub, "start importEPCIS")
+
+	document := EPCISDocument{}
+	err := json.Unmarshal([]byte(args[0]), &document)
+	if err != nil {
+		return ccerror.ValidationFailed("Failed to decode json of EPCISDocument: " + err.Error())
+	}
+	if document.Type != EPCIS_DOCUMENT {
+		return ccerror.TypeMismatch("Expexted type " + EPCIS_DOCUMENT + " for EPCIS document")
+	}
+
+	supplychainID := ""
+	if len(args) > 1 {
+		supplychainID = args[1]
+	}
+
+	options := BulkOptions{}
+	if len(args) == 3 {
+		err = json.Unmarshal([]byte(args[2]), &options)
+		if err != nil {
+			return ccerror.ValidationFailed("Failed to decode json of BulkOptions: " + err.Error())
+		}
+	}
+
+	data := BulkData{Logs: []Log{}}
+	for i, rawEvent := range document.EPCISBody.EventList {
+		result, newLog := t.epcisEventToLog(stub, i, rawEvent)
+		if result.Status != shim.OK {
+			return ccerror.Prefix("Event "+strconv.Itoa(i)+": ", result)
+		}
+		newLog.Supplychain = supplychainID
+		data.Logs = append(data.Logs, newLog)
+	}
+
+	result := t.bulkCreate(stub, data, options)
+
+	if result.Status == shim.OK {
+		logger.Debug(stub, "end importEPCIS (success)")
+	}
+	return result
+}
+
+// exportEPCIS returns the Logs of a supply chain or a product as an EPCIS 2.0
+// document, ordered by time
+func (t *FoodChaincode) exportEPCIS(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	logger.Debug(stub, "start exportEPCIS")
+
+	var index string
+	if args[0] == EPCIS_EXPORT_SUPPLYCHAIN {
+		index = CK_SC_LOG
+	} else if args[0] == EPCIS_EXPORT_PRODUCT {
+		index = CK_PRODUCT_LOG
+	} else {
+		return ccerror.ValidationFailed("Export must be " + EPCIS_EXPORT_SUPPLYCHAIN + " or " + EPCIS_EXPORT_PRODUCT)
+	}
+	result, logs := t.getLogsByCompositeKey(stub, index, args[1])
+	if result.Status != shim.OK {
+		return result
+	}
+	sortLogsByTime(logs)
+
+	// the transaction timestamp is the same on every endorser
+	txTimestamp, err := stub.GetTxTimestamp()
+	if err != nil {
+		return ccerror.Internal("Failed to get transaction timestamp: " + err.Error())
+	}
+
+	document := EPCISDocument{
+		Context:       []string{EPCIS_CONTEXT},
+		Type:          EPCIS_DOCUMENT,
+		SchemaVersion: EPCIS_SCHEMA_VERSION,
+		CreationDate:  time.Unix(txTimestamp.GetSeconds(), 0).UTC().Format(time.RFC3339),
+		EPCISBody:     EPCISBody{EventList: []json.RawMessage{}},
+	}
+	for _, log := range logs {
+		eventAsBytes, err := json.Marshal(logToEPCISEvent(log))
+		if err != nil {
+			return ccerror.Internal("Failed to encode EPCIS event of Log " + log.ID + ": " + err.Error())
+		}
+		document.EPCISBody.EventList = append(document.EPCISBody.EventList, eventAsBytes)
+	}
+
+	documentAsBytes, err := json.Marshal(document)
+	if err != nil {
+		return ccerror.Internal("Failed to get encode response: " + err.Error())
+	}
+
+	logger.Debug(stub, "end exportEPCIS (success)")
+	return shim.Success(documentAsBytes)
+}
+
+// epcisEventToLog maps an event to a Log. The raw event is kept in Content,
+// so events are exported again with all of their fields. Events without an
+// eventID get one from the transaction ID and their position.
+func (t *FoodChaincode) epcisEventToLog(stub shim.ChaincodeStubInterface, position int, rawEvent json.RawMessage) (pb.Response, Log) {
+	event := EPCISEvent{}
+	err := json.Unmarshal(rawEvent, &event)
+	if err != nil {
+		return ccerror.ValidationFailed("Failed to decode json of EPCIS event: " + err.Error()), Log{}
+	}
+
+	newLog := Log{
+		ObjectType: TYPE_LOG,
+		ID:         event.EventID,
+		Ref:        []string{},
+		CTE:        normalizeBizStep(event.BizStep),
+		Content:    string(rawEvent),
+	}
+	if len(newLog.ID) < 1 {
+		newLog.ID = stub.GetTxID() + "-" + strconv.Itoa(position)
+	}
+
+	eventTime, err := time.Parse(time.RFC3339, event.EventTime)
+	if err != nil {
+		return ccerror.ValidationFailed("Invalid eventTime " + event.EventTime), Log{}
+	}
+	newLog.Time = eventTime.Unix()
+
+	if event.ReadPoint != nil && len(event.ReadPoint.ID) > 0 {
+		newLog.Location = event.ReadPoint.ID
+	} else if event.BizLocation != nil {
+		newLog.Location = event.BizLocation.ID
+	}
+
+	// the Log is indexed under every EPC of the event
+	if event.Type == EPCIS_OBJECT_EVENT {
+		newLog.Product, newLog.Products = splitEPCs(epcs(event.EPCList, event.QuantityList))
+	} else if event.Type == EPCIS_AGGREGATION_EVENT {
+		// the parent is the product, its children are its inputs
+		newLog.Product = event.ParentID
+		newLog.Ref = epcs(event.ChildEPCs, event.ChildQuantityList)
+	} else if event.Type == EPCIS_TRANSFORMATION_EVENT {
+		newLog.Product, newLog.Products = splitEPCs(epcs(event.OutputEPCList, event.OutputQuantityList))
+		newLog.Ref = epcs(event.InputEPCList, event.InputQuantityList)
+	} else {
+		return ccerror.ValidationFailed("Unsupported EPCIS event type " + event.Type), Log{}
+	}
+	if len(newLog.Product) < 1 {
+		return ccerror.ValidationFailed(event.Type + " " + newLog.ID + " has no EPC"), Log{}
+	}
+
+	return shim.Success(nil), newLog
+}
+
+// logToEPCISEvent returns the event a Log was imported from, updated with
+// the current fields of the Log, or builds a new one. Logs with Ref become
+// a TransformationEvent, other Logs an ObjectEvent.
+func logToEPCISEvent(log Log) map[string]interface{} {
+	event := map[string]interface{}{}
+	err := json.Unmarshal([]byte(log.Content), &event)
+	if err != nil || !isEPCISEventType(event["type"]) {
+		event = map[string]interface{}{"eventTimeZoneOffset": "+00:00"}
+		if len(log.Ref) > 0 {
+			event["type"] = EPCIS_TRANSFORMATION_EVENT
+			event["inputEPCList"] = log.Ref
+			event["outputEPCList"] = logProducts(log)
+		} else {
+			event["type"] = EPCIS_OBJECT_EVENT
+			event["action"] = "OBSERVE"
+			event["epcList"] = logProducts(log)
+		}
+	}
+
+	event["eventID"] = log.ID
+
+	// keep the original precision and time zone unless the time changed
+	eventTime, _ := event["eventTime"].(string)
+	parsedTime, err := time.Parse(time.RFC3339, eventTime)
+	if err != nil || parsedTime.Unix() != log.Time {
+		event["eventTime"] = time.Unix(log.Time, 0).UTC().Format(time.RFC3339)
+		event["eventTimeZoneOffset"] = "+00:00"
+	}
+
+	bizStep, _ := event["bizStep"].(string)
+	if normalizeBizStep(bizStep) != log.CTE {
+		if len(log.CTE) > 0 {
+			event["bizStep"] = log.CTE
+		} else {
+			delete(event, "bizStep")
+		}
+	}
+
+	if len(log.Location) > 0 {
+		readPoint, _ := event["readPoint"].(map[string]interface{})
+		if readPoint == nil || readPoint["id"] != log.Location {
+			event["readPoint"] = EPCISLocation{ID: log.Location}
+		}
+	}
+
+	return event
+}
+
+func normalizeBizStep(bizStep string) string {
+	for _, prefix := range epcisBizStepPrefixes {
+		if strings.HasPrefix(bizStep, prefix) {
+			return strings.TrimPrefix(bizStep, prefix)
+		}
+	}
+	return bizStep
+}
+
+func isEPCISEventType(eventType interface{}) bool {
+	return eventType == EPCIS_OBJECT_EVENT || eventType == EPCIS_AGGREGATION_EVENT || eventType == EPCIS_TRANSFORMATION_EVENT
+}
+
+// epcs returns the EPCs of a list followed by the classes of a quantity list
+func epcs(epcList []string, quantityList []EPCISQuantity) []string {
+	result := []string{}
+	for _, epc := range epcList {
+		if len(epc) > 0 {
+			result = append(result, epc)
+		}
+	}
+	for _, quantity := range quantityList {
+		if len(quantity.EPCClass) > 0 {
+			result = append(result, quantity.EPCClass)
+		}
+	}
+	return result
+}
+
+// splitEPCs returns the first EPC as the product of a Log and the others
+// as its further products
+func splitEPCs(all []string) (string, []string) {
+	if len(all) == 0 {
+		return "", nil
+	}
+	return all[0], all[1:]
+}
@@ -0,0 +1,255 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/deevotech/sc-chaincode.deevo.io/lib/ccerror"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+const testEPCISDocument = `{
+	"@context": ["https://ref.gs1.org/standards/epcis/epcis-context.jsonld"],
+	"type": "EPCISDocument",
+	"schemaVersion": "2.0",
+	"creationDate": "2020-03-04T11:00:30.000+01:00",
+	"epcisBody": {
+		"eventList": [
+			{
+				"type": "ObjectEvent",
+				"eventID": "Event_1",
+				"eventTime": "2020-03-01T10:00:00.000+01:00",
+				"eventTimeZoneOffset": "+01:00",
+				"action": "ADD",
+				"bizStep": "urn:epcglobal:cbv:bizstep:harvesting",
+				"epcList": ["urn:epc:id:sgtin:4012345.011111.1"],
+				"readPoint": {"id": "urn:epc:id:sgln:4012345.00001.0"}
+			},
+			{
+				"type": "TransformationEvent",
+				"eventID": "Event_2",
+				"eventTime": "2020-03-02T10:00:00.000+01:00",
+				"eventTimeZoneOffset": "+01:00",
+				"bizStep": "commissioning",
+				"inputEPCList": ["urn:epc:id:sgtin:4012345.011111.1"],
+				"outputQuantityList": [{"epcClass": "urn:epc:class:lgtin:4012345.022222.L1", "quantity": 200, "uom": "KGM"}],
+				"bizLocation": {"id": "urn:epc:id:sgln:4012345.00002.0"}
+			},
+			{
+				"type": "AggregationEvent",
+				"eventID": "Event_3",
+				"eventTime": "2020-03-03T10:00:00.000+01:00",
+				"eventTimeZoneOffset": "+01:00",
+				"action": "ADD",
+				"bizStep": "packing",
+				"parentID": "urn:epc:id:sscc:4012345.0000000001",
+				"childQuantityList": [{"epcClass": "urn:epc:class:lgtin:4012345.022222.L1", "quantity": 200, "uom": "KGM"}]
+			}
+		]
+	}
+}`
+
+func TestFood_ImportEPCIS(t *testing.T) {
+	scc := new(FoodChaincode)
+	stub := shim.NewMockStub("food", scc)
+
+	checkInit(t, stub, [][]byte{})
+	// EPCs of partners are not on the ledger
+	checkSetIntegrityMode(t, stub, INTEGRITY_LENIENT)
+
+	res := stub.MockInvoke("1", [][]byte{[]byte("importEPCIS"), []byte(testEPCISDocument), []byte("Supplychain_1")})
+	if res.Status != shim.OK {
+		fmt.Println("importEPCIS failed", res.Message)
+		t.FailNow()
+	}
+	report := BulkResult{}
+	json.Unmarshal(res.Payload, &report)
+	if report.Created != 3 {
+		fmt.Println("Bulk result was not as expected", report)
+		t.FailNow()
+	}
+
+	logs := map[string]Log{}
+	for _, ID := range []string{"Event_1", "Event_2", "Event_3"} {
+		log := Log{}
+		json.Unmarshal(stub.State[ID], &log)
+		logs[ID] = log
+	}
+	if logs["Event_1"].CTE != "harvesting" || logs["Event_1"].Product != "urn:epc:id:sgtin:4012345.011111.1" ||
+		logs["Event_1"].Location != "urn:epc:id:sgln:4012345.00001.0" || logs["Event_1"].Supplychain != "Supplychain_1" {
+		fmt.Println("ObjectEvent was not mapped as expected", logs["Event_1"])
+		t.FailNow()
+	}
+	if logs["Event_1"].Time != time.Date(2020, 3, 1, 9, 0, 0, 0, time.UTC).Unix() {
+		fmt.Println("eventTime was not mapped as expected", logs["Event_1"].Time)
+		t.FailNow()
+	}
+	if logs["Event_2"].Product != "urn:epc:class:lgtin:4012345.022222.L1" || len(logs["Event_2"].Ref) != 1 ||
+		logs["Event_2"].Location != "urn:epc:id:sgln:4012345.00002.0" {
+		fmt.Println("TransformationEvent was not mapped as expected", logs["Event_2"])
+		t.FailNow()
+	}
+	if logs["Event_3"].Product != "urn:epc:id:sscc:4012345.0000000001" || logs["Event_3"].Ref[0] != "urn:epc:class:lgtin:4012345.022222.L1" {
+		fmt.Println("AggregationEvent was not mapped as expected", logs["Event_3"])
+		t.FailNow()
+	}
+
+	key, _ := stub.CreateCompositeKey(CK_PRODUCT_LOG, []string{"urn:epc:id:sscc:4012345.0000000001", "Event_3"})
+	if stub.State[key] == nil {
+		fmt.Println("Log index was not created")
+		t.FailNow()
+	}
+}
+
+func TestFood_ImportEPCISWithUnsupportedEvent(t *testing.T) {
+	scc := new(FoodChaincode)
+	stub := shim.NewMockStub("food", scc)
+
+	checkInit(t, stub, [][]byte{})
+
+	document := `{"type": "EPCISDocument", "schemaVersion": "2.0", "epcisBody": {"eventList": [
+		{"type": "AssociationEvent", "eventID": "Event_1", "eventTime": "2020-03-01T10:00:00Z"}]}}`
+	res := stub.MockInvoke("1", [][]byte{[]byte("importEPCIS"), []byte(document)})
+	if res.Status == shim.OK {
+		fmt.Println("importEPCIS should fail")
+		t.FailNow()
+	}
+}
+
+func TestFood_ImportEPCISWithoutEPC(t *testing.T) {
+	scc := new(FoodChaincode)
+	stub := shim.NewMockStub("food", scc)
+
+	checkInit(t, stub, [][]byte{})
+
+	document := `{"type": "EPCISDocument", "schemaVersion": "2.0", "epcisBody": {"eventList": [
+		{"type": "ObjectEvent", "eventID": "Event_1", "eventTime": "2020-03-01T10:00:00Z", "action": "OBSERVE", "epcList": []}]}}`
+	res := stub.MockInvoke("1", [][]byte{[]byte("importEPCIS"), []byte(document)})
+	checkErrorCode(t, res, ccerror.VALIDATION_FAILED)
+}
+
+func TestFood_ImportEPCISWithSeveralEPCs(t *testing.T) {
+	scc := new(FoodChaincode)
+	stub := shim.NewMockStub("food", scc)
+
+	checkInit(t, stub, [][]byte{})
+	checkCreateReferences(t, stub, map[string]string{
+		"urn:epc:id:sgtin:4012345.011111.1":     TYPE_PRODUCT,
+		"urn:epc:id:sgtin:4012345.011111.2":     TYPE_PRODUCT,
+		"urn:epc:class:lgtin:4012345.022222.L1": TYPE_PRODUCT,
+	})
+
+	document := `{"type": "EPCISDocument", "schemaVersion": "2.0", "epcisBody": {"eventList": [
+		{"type": "ObjectEvent", "eventID": "Event_1", "eventTime": "2020-03-01T10:00:00Z", "action": "OBSERVE",
+		"epcList": ["urn:epc:id:sgtin:4012345.011111.1", "urn:epc:id:sgtin:4012345.011111.2"],
+		"quantityList": [{"epcClass": "urn:epc:class:lgtin:4012345.022222.L1", "quantity": 10}]}]}}`
+	res := stub.MockInvoke("1", [][]byte{[]byte("importEPCIS"), []byte(document)})
+	if res.Status != shim.OK {
+		fmt.Println("importEPCIS failed", res.Message)
+		t.FailNow()
+	}
+
+	log := Log{}
+	json.Unmarshal(stub.State["Event_1"], &log)
+	if log.Product != "urn:epc:id:sgtin:4012345.011111.1" || len(log.Products) != 2 {
+		fmt.Println("ObjectEvent was not mapped as expected", log)
+		t.FailNow()
+	}
+
+	// every EPC finds the Log
+	for _, epc := range []string{"urn:epc:id:sgtin:4012345.011111.1", "urn:epc:id:sgtin:4012345.011111.2", "urn:epc:class:lgtin:4012345.022222.L1"} {
+		res = stub.MockInvoke("1", [][]byte{[]byte("getLogsOfProduct"), []byte(epc)})
+		logs := []Log{}
+		json.Unmarshal(res.Payload, &logs)
+		if res.Status != shim.OK || len(logs) != 1 || logs[0].ID != "Event_1" {
+			fmt.Println("Log of", epc, "was not found", res.Message)
+			t.FailNow()
+		}
+
+		key, _ := stub.CreateCompositeKey(CK_PRODUCT_TIME_LOG, []string{epc, timeKeyPart(log.Time), "Event_1"})
+		if stub.State[key] == nil {
+			fmt.Println("Time index of", epc, "was not created")
+			t.FailNow()
+		}
+	}
+
+	// deleting the Log removes the keys of every EPC
+	res = stub.MockInvoke("1", [][]byte{[]byte("deleteObject"), []byte("Event_1"), []byte(TYPE_LOG)})
+	if res.Status != shim.OK {
+		fmt.Println("deleteObject failed", res.Message)
+		t.FailNow()
+	}
+	key, _ := stub.CreateCompositeKey(CK_PRODUCT_LOG, []string{"urn:epc:id:sgtin:4012345.011111.2", "Event_1"})
+	if stub.State[key] != nil {
+		fmt.Println("Log index was not deleted")
+		t.FailNow()
+	}
+}
+
+func TestFood_ExportEPCIS(t *testing.T) {
+	scc := new(FoodChaincode)
+	stub := shim.NewMockStub("food", scc)
+
+	checkInit(t, stub, [][]byte{})
+	checkCreateReferences(t, stub, map[string]string{"Supplychain_1": TYPE_SUPPLYCHAIN, "Product_1": TYPE_PRODUCT})
+	// EPCs of partners are not on the ledger
+	checkSetIntegrityMode(t, stub, INTEGRITY_LENIENT)
+
+	res := stub.MockInvoke("1", [][]byte{[]byte("importEPCIS"), []byte(testEPCISDocument), []byte("Supplychain_1")})
+	if res.Status != shim.OK {
+		fmt.Println("importEPCIS failed", res.Message)
+		t.FailNow()
+	}
+	newLog := Log{ObjectType: TYPE_LOG, ID: "Log_1", Time: time.Date(2020, 3, 5, 0, 0, 0, 0, time.UTC).Unix(),
+		CTE: "shipping", Supplychain: "Supplychain_1", Product: "Product_1", Location: "Location_1", Ref: []string{}}
+	logAsJSON, _ := json.Marshal(newLog)
+	res = stub.MockInvoke("1", [][]byte{[]byte("createLog"), logAsJSON})
+	if res.Status != shim.OK {
+		fmt.Println("createLog failed", res.Message)
+		t.FailNow()
+	}
+
+	res = stub.MockInvoke("1", [][]byte{[]byte("exportEPCIS"), []byte(EPCIS_EXPORT_SUPPLYCHAIN), []byte("Supplychain_1")})
+	if res.Status != shim.OK {
+		fmt.Println("exportEPCIS failed", res.Message)
+		t.FailNow()
+	}
+
+	document := EPCISDocument{}
+	json.Unmarshal(res.Payload, &document)
+	if document.Type != EPCIS_DOCUMENT || document.SchemaVersion != EPCIS_SCHEMA_VERSION || len(document.EPCISBody.EventList) != 4 {
+		fmt.Println("EPCIS document was not as expected", string(res.Payload))
+		t.FailNow()
+	}
+
+	events := []map[string]interface{}{}
+	for _, rawEvent := range document.EPCISBody.EventList {
+		event := map[string]interface{}{}
+		json.Unmarshal(rawEvent, &event)
+		events = append(events, event)
+	}
+	if events[0]["eventID"] != "Event_1" || events[0]["eventTime"] != "2020-03-01T10:00:00.000+01:00" ||
+		events[0]["bizStep"] != "urn:epcglobal:cbv:bizstep:harvesting" {
+		fmt.Println("Imported event was not exported unchanged", events[0])
+		t.FailNow()
+	}
+	if events[2]["type"] != EPCIS_AGGREGATION_EVENT || events[2]["parentID"] != "urn:epc:id:sscc:4012345.0000000001" {
+		fmt.Println("Imported event was not exported unchanged", events[2])
+		t.FailNow()
+	}
+	readPoint, _ := events[3]["readPoint"].(map[string]interface{})
+	if events[3]["type"] != EPCIS_OBJECT_EVENT || events[3]["eventID"] != "Log_1" || events[3]["bizStep"] != "shipping" ||
+		events[3]["eventTime"] != "2020-03-05T00:00:00Z" || readPoint["id"] != "Location_1" {
+		fmt.Println("Log was not exported as expected", events[3])
+		t.FailNow()
+	}
+
+	res = stub.MockInvoke("1", [][]byte{[]byte("exportEPCIS"), []byte("auditor"), []byte("Supplychain_1")})
+	if res.Status == shim.OK {
+		fmt.Println("exportEPCIS should fail")
+		t.FailNow()
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/deevotech/sc-chaincode.deevo.io/lib/ccerror"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+func checkErrorCode(t *testing.T, res pb.Response, code ccerror.Code) ccerror.Body {
+	body := ccerror.Parse(res)
+	if res.Status != ccerror.Status(code) || body.Code != code {
+		fmt.Println("Expected", code, "but got", res.Status, res.Message)
+		t.FailNow()
+	}
+	return body
+}
+
+func TestFood_ErrorCodes(t *testing.T) {
+	scc := new(FoodChaincode)
+	stub := shim.NewMockStub("food", scc)
+
+	checkInit(t, stub, [][]byte{})
+
+	res := stub.MockInvoke("1", [][]byte{[]byte("getObject"), []byte("Product_1"), []byte(TYPE_PRODUCT)})
+	checkErrorCode(t, res, ccerror.NOT_FOUND)
+
+	res = stub.MockInvoke("1", [][]byte{[]byte("getObject")})
+	checkErrorCode(t, res, ccerror.VALIDATION_FAILED)
+
+	res = stub.MockInvoke("1", [][]byte{[]byte("getConfigHistory"), []byte("1")})
+	checkErrorCode(t, res, ccerror.VALIDATION_FAILED)
+
+	res = stub.MockInvoke("1", [][]byte{[]byte("migrate"), []byte(TYPE_LOG)})
+	checkErrorCode(t, res, ccerror.FORBIDDEN)
+
+	traceable := Traceable{ObjectType: TYPE_PRODUCT, ID: "Product_1", Name: "Product 1"}
+	traceableAsBytes, err := json.Marshal(traceable)
+	if err != nil {
+		fmt.Println("Failed to encode json")
+		t.FailNow()
+	}
+	res = stub.MockInvoke("1", [][]byte{[]byte("createTraceable"), traceableAsBytes})
+	if res.Status != shim.OK {
+		fmt.Println("failed", string(res.Message))
+		t.FailNow()
+	}
+	res = stub.MockInvoke("1", [][]byte{[]byte("createTraceable"), traceableAsBytes})
+	checkErrorCode(t, res, ccerror.ALREADY_EXISTS)
+
+	res = stub.MockInvoke("1", [][]byte{[]byte("getObject"), []byte("Product_1"), []byte(TYPE_LOG)})
+	checkErrorCode(t, res, ccerror.TYPE_MISMATCH)
+
+	checkSetIntegrityMode(t, stub, INTEGRITY_STRICT)
+	newLog := Log{ObjectType: TYPE_LOG, ID: "Log_1", CTE: "shipping", Product: "Product_2"}
+	newLogAsBytes, err := json.Marshal(newLog)
+	if err != nil {
+		fmt.Println("Failed to encode json")
+		t.FailNow()
+	}
+	res = stub.MockInvoke("1", [][]byte{[]byte("createLog"), newLogAsBytes})
+	body := checkErrorCode(t, res, ccerror.INVALID_REFERENCE)
+	if body.Details["fields"] == nil {
+		fmt.Println("Details should have the invalid fields", res.Message)
+		t.FailNow()
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/json"
+
+	"github.com/deevotech/sc-chaincode.deevo.io/lib/ccerror"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+// setChangeEvent emits the chaincode event of a mutation. Fabric keeps only
+// the last event of a transaction, so each handler sets at most one.
+func (t *FoodChaincode) setChangeEvent(stub shim.ChaincodeStubInterface, event ChangeEvent) pb.Response {
+	event.Version = EVENT_VERSION
+	event.TxID = stub.GetTxID()
+
+	eventAsBytes, err := json.Marshal(event)
+	if err != nil {
+		return ccerror.Internal("Failed to encode json of event: " + err.Error())
+	}
+	err = stub.SetEvent(event.EventType, eventAsBytes)
+	if err != nil {
+		return ccerror.Internal("Failed to set event " + event.EventType + ": " + err.Error())
+	}
+	return shim.Success(nil)
+}
+
+func logIndexes(log Log) map[string]string {
+	return map[string]string{
+		CK_SC_LOG:      log.Supplychain,
+		CK_PRODUCT_LOG: log.Product,
+	}
+}
+
+func traceableIndexes(traceable Traceable) map[string]string {
+	return map[string]string{
+		CK_PARENT_CHILD: traceable.ParentID(),
+	}
+}
+
+func auditActionIndexes(auditAction AuditAction) map[string]string {
+	return map[string]string{
+		CK_AUDITOR_AUDIT: auditAction.Auditor,
+		CK_AUDIT_OBJ:     auditAction.ObjectID,
+		CK_AUDIT_STATUS:  auditAction.Status,
+	}
+}
+
+func newLogEvent(eventType string, oldLog *Log, newLog Log) ChangeEvent {
+	event := ChangeEvent{
+		EventType:   eventType,
+		ObjectID:    newLog.ID,
+		ObjectType:  newLog.ObjectType,
+		Supplychain: newLog.Supplychain,
+		Product:     newLog.Product,
+		NewIndexes:  logIndexes(newLog),
+	}
+	if oldLog != nil {
+		event.OldIndexes = logIndexes(*oldLog)
+	}
+	return event
+}
+
+func newTraceableEvent(eventType string, oldTraceable *Traceable, newTraceable Traceable) ChangeEvent {
+	event := ChangeEvent{
+		EventType:  eventType,
+		ObjectID:   newTraceable.ID,
+		ObjectType: newTraceable.ObjectType,
+		NewIndexes: traceableIndexes(newTraceable),
+	}
+	if newTraceable.ObjectType == TYPE_SUPPLYCHAIN {
+		event.Supplychain = newTraceable.ID
+	} else if newTraceable.ObjectType == TYPE_PRODUCT {
+		event.Product = newTraceable.ID
+	}
+	if oldTraceable != nil {
+		event.OldIndexes = traceableIndexes(*oldTraceable)
+	}
+	return event
+}
+
+func newAuditActionEvent(eventType string, oldAuditAction *AuditAction, newAuditAction AuditAction) ChangeEvent {
+	event := ChangeEvent{
+		EventType:  eventType,
+		ObjectID:   newAuditAction.ID,
+		ObjectType: newAuditAction.ObjectType,
+		NewIndexes: auditActionIndexes(newAuditAction),
+	}
+	if oldAuditAction != nil {
+		event.OldIndexes = auditActionIndexes(*oldAuditAction)
+	}
+	return event
+}
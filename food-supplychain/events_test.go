@@ -0,0 +1,112 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+func TestFood_LogEvents(t *testing.T) {
+	scc := new(FoodChaincode)
+	stub := shim.NewMockStub("food", scc)
+
+	checkInit(t, stub, [][]byte{})
+
+	for _, traceable := range []Traceable{
+		{ObjectType: TYPE_SUPPLYCHAIN, ID: "sc_1", Name: "supplychain 1"},
+		{ObjectType: TYPE_PRODUCT, ID: "Product_1", Name: "Product 1"},
+		{ObjectType: TYPE_PRODUCT, ID: "Product_2", Name: "Product 2"},
+	} {
+		traceableAsBytes, err := json.Marshal(traceable)
+		if err != nil {
+			fmt.Println("Failed to encode json")
+			t.FailNow()
+		}
+		checkCreateTraceable(t, stub, traceableAsBytes, traceable)
+		event := checkLastEvent(t, stub, EVENT_TRACEABLE_CREATED, traceable.ID)
+		if traceable.ObjectType == TYPE_PRODUCT && event.Product != traceable.ID {
+			fmt.Println("Product of event does not match")
+			t.FailNow()
+		}
+	}
+
+	newLog := Log{
+		ObjectType:  TYPE_LOG,
+		ID:          "Log_1",
+		Time:        time.Now().Unix(),
+		CTE:         "test_action",
+		Supplychain: "sc_1",
+		Product:     "Product_1",
+	}
+	newLogAsBytes, err := json.Marshal(newLog)
+	if err != nil {
+		fmt.Println("Failed to encode json")
+		t.FailNow()
+	}
+	res := stub.MockInvoke("1", [][]byte{[]byte("createLog"), newLogAsBytes})
+	if res.Status != shim.OK {
+		fmt.Println("failed", string(res.Message))
+		t.FailNow()
+	}
+	event := checkLastEvent(t, stub, EVENT_LOG_CREATED, newLog.ID)
+	if event.Supplychain != "sc_1" || event.NewIndexes[CK_PRODUCT_LOG] != "Product_1" {
+		fmt.Println("Event value was not as expected")
+		t.FailNow()
+	}
+
+	newLog.Product = "Product_2"
+	newLog.Version = 1
+	newLogAsBytes, err = json.Marshal(newLog)
+	if err != nil {
+		fmt.Println("Failed to encode json")
+		t.FailNow()
+	}
+	res = stub.MockInvoke("1", [][]byte{[]byte("updateLog"), newLogAsBytes})
+	if res.Status != shim.OK {
+		fmt.Println("failed", string(res.Message))
+		t.FailNow()
+	}
+	event = checkLastEvent(t, stub, EVENT_LOG_UPDATED, newLog.ID)
+	if event.OldIndexes[CK_PRODUCT_LOG] != "Product_1" || event.NewIndexes[CK_PRODUCT_LOG] != "Product_2" {
+		fmt.Println("Event value was not as expected")
+		t.FailNow()
+	}
+}
+
+// checkLastEvent drains the events of the stub and checks the last one
+func checkLastEvent(t *testing.T, stub *shim.MockStub, eventType string, ID string) ChangeEvent {
+	var last *pb.ChaincodeEvent
+	for {
+		select {
+		case chaincodeEvent := <-stub.ChaincodeEventsChannel:
+			last = chaincodeEvent
+			continue
+		default:
+		}
+		break
+	}
+	if last == nil {
+		fmt.Println("No event was set")
+		t.FailNow()
+	}
+	if last.EventName != eventType {
+		fmt.Println("Event", last.EventName, "was not", eventType)
+		t.FailNow()
+	}
+
+	event := ChangeEvent{}
+	err := json.Unmarshal(last.Payload, &event)
+	if err != nil {
+		fmt.Println("Failed to decode json of ChangeEvent:", err.Error())
+		t.FailNow()
+	}
+	if event.Version != EVENT_VERSION || event.ObjectID != ID {
+		fmt.Println("Event value was not as expected")
+		t.FailNow()
+	}
+	return event
+}
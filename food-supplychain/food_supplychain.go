@@ -3,158 +3,155 @@ package main
 import (
 	"bytes"
 	"encoding/json"
-	"fmt"
 	"strconv"
+	"sync"
 	"time"
 
+	"github.com/deevotech/sc-chaincode.deevo.io/lib/ccerror"
+	"github.com/deevotech/sc-chaincode.deevo.io/lib/logging"
+	"github.com/deevotech/sc-chaincode.deevo.io/lib/router"
+
 	"github.com/hyperledger/fabric/core/chaincode/shim"
 	pb "github.com/hyperledger/fabric/protos/peer"
 )
 
+var logger = logging.New("food-supplychain")
+
 // FoodChaincode demo chaincode
 type FoodChaincode struct {
+	routerOnce sync.Once
+	router     *router.Router
 }
 
 func main() {
 	err := shim.Start(new(FoodChaincode))
 	if err != nil {
-		fmt.Printf("Error starting Simple chaincode: %s", err)
+		logger.Error(nil, "Error starting Simple chaincode", logging.Fields{"error": err})
 	}
 }
 
-// Init initializes chaincode
+// Init initializes chaincode, on instantiate and on every upgrade
 // ===========================
 func (t *FoodChaincode) Init(stub shim.ChaincodeStubInterface) pb.Response {
-	return shim.Success(nil)
+	result := t.initConfig(stub)
+	if result.Status != shim.OK {
+		return result
+	}
+	return t.initSchemaVersion(stub)
 }
 
 // Invoke - Our entry point for Invocations
 // ========================================
 func (t *FoodChaincode) Invoke(stub shim.ChaincodeStubInterface) pb.Response {
-	function, args := stub.GetFunctionAndParameters()
-	fmt.Println("invoke is running " + function)
-
-	// Handle different functions
-	if function == "initOrgData" {
-		return t.initOrgData(stub, args)
-	} else if function == "createLog" { //create a new org
-		return t.createLog(stub, args)
-	} else if function == "updateLog" {
-		return t.updateLog(stub, args)
-	} else if function == "createAuditor" { //create a new org
-		return t.createAuditor(stub, args)
-	} else if function == "updateAuditor" {
-		return t.updateAuditor(stub, args)
-	} else if function == "createAuditAction" { //create a new org
-		return t.createAuditAction(stub, args)
-	} else if function == "updateAuditAction" {
-		return t.updateAuditAction(stub, args)
-	} else if function == "getObject" {
-		return t.getObject(stub, args)
-	} else if function == "getAuditOfObject" {
-		return t.getAuditOfObject(stub, args)
-	} else if function == "getAuditsOfAuditor" {
-		return t.getAuditsOfAuditor(stub, args)
-	} else if function == "createTraceable" {
-		return t.createTraceable(stub, args)
-	} else if function == "updateTraceable" {
-		return t.updateTraceable(stub, args)
-	} else if function == "getLogsOfSupplychain" {
-		return t.getLogsOfSupplychain(stub, args)
-	} else if function == "getLogsOfProduct" {
-		return t.getLogsOfProduct(stub, args)
-	} else if function == "getQueryResultForQueryString" {
-		return t.getQueryResultForQueryString(stub, args)
-	} else if function == "getHistoryOfObject" {
-		return t.getHistoryOfObject(stub, args)
-	}
-	// getHistory AgriProduct, get HistoryProduct
-	fmt.Println("invoke did not find func: " + function) //error
-	return shim.Error("Received unknown function invocation")
+	t.routerOnce.Do(func() {
+		t.router = t.newRouter()
+	})
+	return t.router.Invoke(stub)
 }
 
 // Init all data of an ORGs
 // ========================================
 func (t *FoodChaincode) initOrgData(stub shim.ChaincodeStubInterface, args []string) pb.Response {
-	fmt.Println("- start initOrgData", args)
-	if len(args) != 1 {
-		return shim.Error("Incorrect number of arguments. Expecting 1")
-	}
+	logger.Debug(stub, "start initOrgData")
 
 	newData := InitData{}
 	err := json.Unmarshal([]byte(args[0]), &newData)
 	if err != nil {
-		return shim.Error("Failed to decode json of ORG: " + err.Error())
+		return ccerror.ValidationFailed("Failed to decode json of ORG: " + err.Error())
 	}
 
 	for _, traceable := range newData.Traceable {
 		traceableAsBytes, err := json.Marshal(traceable)
 		if err != nil {
-			return shim.Error("Failed to encode json of object " + traceable.ID)
+			return ccerror.Internal("Failed to encode json of object " + traceable.ID)
 		}
 		result := t.createObject(stub, traceableAsBytes, traceable.ID)
 		if result.Status != shim.OK {
 			return result
 		}
+		result = t.putParentKey(stub, traceable)
+		if result.Status != shim.OK {
+			return result
+		}
 	}
 
 	for _, auditor := range newData.Auditors {
 		auditorAsBytes, err := json.Marshal(auditor)
 		if err != nil {
-			return shim.Error("Failed to encode json of Auditor " + auditor.ID)
+			return ccerror.Internal("Failed to encode json of Auditor " + auditor.ID)
 		}
 		if auditor.ObjectType != TYPE_AUDITOR {
-			return shim.Error("Expexted objectType " + TYPE_AUDITOR + " for Auditor")
+			return ccerror.TypeMismatch("Expexted objectType " + TYPE_AUDITOR + " for Auditor")
+		}
+		result := checkAccreditations(auditor)
+		if result.Status != shim.OK {
+			return result
+		}
+		result = t.createObject(stub, auditorAsBytes, auditor.ID)
+		if result.Status != shim.OK {
+			return result
 		}
-		result := t.createObject(stub, auditorAsBytes, auditor.ID)
+		result = t.updateAuditorKeys(stub, nil, &auditor)
 		if result.Status != shim.OK {
 			return result
 		}
 	}
 
-	fmt.Println("- end initOrgData (success)")
+	logger.Debug(stub, "end initOrgData (success)")
 	return shim.Success(nil)
 }
 
 // Methods of Traceable data
 func (t *FoodChaincode) createTraceable(stub shim.ChaincodeStubInterface, args []string) pb.Response {
-	fmt.Println("- start createTraceable", args)
-	if len(args) != 1 {
-		return shim.Error("Incorrect number of arguments. Expecting 1")
-	}
+	logger.Debug(stub, "start createTraceable")
 
 	jsonBytes := []byte(args[0])
 	newTraceable := Traceable{}
 	err := json.Unmarshal(jsonBytes, &newTraceable)
 	if err != nil {
-		return shim.Error("Failed to decode json: " + err.Error())
+		return ccerror.ValidationFailed("Failed to decode json: " + err.Error())
+	}
+	if !isTraceableType(newTraceable.ObjectType) {
+		return ccerror.TypeMismatch("Objects of type " + newTraceable.ObjectType + " are not Traceables")
 	}
 
 	result := t.createObject(stub, jsonBytes, newTraceable.ID)
+	if result.Status != shim.OK {
+		logger.Debug(stub, "end createTraceable (failed)")
+		return result
+	}
+
+	result = t.putParentKey(stub, newTraceable)
+	if result.Status != shim.OK {
+		logger.Debug(stub, "end createTraceable (failed)")
+		return result
+	}
+
+	result = t.setChangeEvent(stub, newTraceableEvent(EVENT_TRACEABLE_CREATED, nil, newTraceable))
 
 	if result.Status == shim.OK {
-		fmt.Println("- end createTraceable (success)")
+		logger.Debug(stub, "end createTraceable (success)")
 	}
 	return result
 }
 
 func (t *FoodChaincode) updateTraceable(stub shim.ChaincodeStubInterface, args []string) pb.Response {
-	fmt.Println("- start updateTraceable", args)
-	if len(args) != 1 {
-		return shim.Error("Incorrect number of arguments. Expecting 1")
-	}
+	logger.Debug(stub, "start updateTraceable")
 
 	jsonBytes := []byte(args[0])
 	newTraceable := Traceable{}
 	err := json.Unmarshal(jsonBytes, &newTraceable)
 	if err != nil {
-		return shim.Error("Failed to decode json: " + err.Error())
+		return ccerror.ValidationFailed("Failed to decode json: " + err.Error())
+	}
+	if !isTraceableType(newTraceable.ObjectType) {
+		return ccerror.TypeMismatch("Objects of type " + newTraceable.ObjectType + " are not Traceables")
 	}
 
-	result := t.updateObject(stub, jsonBytes, newTraceable.ID)
+	result := t.updateTraceableHandler(stub, jsonBytes, newTraceable)
 
 	if result.Status == shim.OK {
-		fmt.Println("- end updateTraceable (success)")
+		logger.Debug(stub, "end updateTraceable (success)")
 	}
 	return result
 }
@@ -162,364 +159,547 @@ func (t *FoodChaincode) updateTraceable(stub shim.ChaincodeStubInterface, args [
 // Methods on Log
 // ========================================
 func (t *FoodChaincode) createLog(stub shim.ChaincodeStubInterface, args []string) pb.Response {
-	fmt.Println("- start createLog", args)
-	if len(args) != 1 {
-		return shim.Error("Incorrect number of arguments. Expecting 1")
-	}
+	logger.Debug(stub, "start createLog")
 
 	jsonBytes := []byte(args[0])
 	newLog := Log{}
 	err := json.Unmarshal(jsonBytes, &newLog)
 	if err != nil {
-		return shim.Error("Failed to decode json of Log: " + err.Error())
+		return ccerror.ValidationFailed("Failed to decode json of Log: " + err.Error())
 	}
-	if newLog.ObjectType != TYPE_LOG {
-		return shim.Error("Expexted objectType " + TYPE_LOG + " for Log")
+
+	result := t.checkNewLog(stub, newLog, nil)
+	if result.Status != shim.OK {
+		return result
 	}
 
-	result := t.createObject(stub, jsonBytes, newLog.ID)
+	result, creator := t.getCreator(stub)
+	if result.Status != shim.OK {
+		return result
+	}
+	newLog.Creator = creator
 
-	if len(newLog.Supplychain) > 0 {
-		result = t.putCompositeKey(stub, CK_SC_LOG, []string{newLog.Supplychain, newLog.ID})
-		if result.Status != shim.OK {
-			fmt.Println("- end createLog (failed)")
-			return result
-		}
+	result = t.applyLogPrivate(stub, &newLog, nil)
+	if result.Status != shim.OK {
+		return result
 	}
 
-	if len(newLog.Product) > 0 {
-		result = t.putCompositeKey(stub, CK_PRODUCT_LOG, []string{newLog.Product, newLog.ID})
-		if result.Status != shim.OK {
-			fmt.Println("- end createLog (failed)")
-			return result
-		}
+	result = t.createLogHandler(stub, newLog)
+	if result.Status != shim.OK {
+		logger.Debug(stub, "end createLog (failed)")
+		return result
 	}
 
+	result = t.setChangeEvent(stub, newLogEvent(EVENT_LOG_CREATED, nil, newLog))
+
 	if result.Status == shim.OK {
-		fmt.Println("- end createLog (success)")
+		logger.Debug(stub, "end createLog (success)")
 	}
 	return result
 }
 
 func (t *FoodChaincode) updateLog(stub shim.ChaincodeStubInterface, args []string) pb.Response {
-	fmt.Println("- start updateLog", args)
-	if len(args) != 1 {
-		return shim.Error("Incorrect number of arguments. Expecting 1")
-	}
+	logger.Debug(stub, "start updateLog")
 
 	jsonBytes := []byte(args[0])
 	newLog := Log{}
 	err := json.Unmarshal(jsonBytes, &newLog)
 	if err != nil {
-		return shim.Error("Failed to decode json of Log: " + err.Error())
+		return ccerror.ValidationFailed("Failed to decode json of Log: " + err.Error())
 	}
 	if newLog.ObjectType != TYPE_LOG {
-		return shim.Error("Expexted objectType " + TYPE_LOG + " for Log")
+		return ccerror.TypeMismatch("Expexted objectType " + TYPE_LOG + " for Log")
+	}
+	result := checkGeoLocation(newLog)
+	if result.Status != shim.OK {
+		return result
+	}
+	result = checkDocuments(newLog.ID, newLog.Documents)
+	if result.Status != shim.OK {
+		return result
+	}
+	result = t.checkLogSchema(stub, newLog)
+	if result.Status != shim.OK {
+		return result
+	}
+	result = t.checkLogReferences(stub, newLog, nil)
+	if result.Status != shim.OK {
+		return result
 	}
 
-	result := t.updateLogHandler(stub, jsonBytes, newLog)
+	result = t.updateLogHandler(stub, newLog)
 
 	if result.Status == shim.OK {
-		fmt.Println("- end updateLog (success)")
+		logger.Debug(stub, "end updateLog (success)")
 	}
-	return shim.Success(nil)
+	return result
 }
 
 // Methods on Auditor
 // ========================================
 func (t *FoodChaincode) createAuditor(stub shim.ChaincodeStubInterface, args []string) pb.Response {
-	fmt.Println("- start createAuditor", args)
-	if len(args) != 1 {
-		return shim.Error("Incorrect number of arguments. Expecting 1")
-	}
+	logger.Debug(stub, "start createAuditor")
 
 	jsonBytes := []byte(args[0])
 	newAuditor := Auditor{}
 	err := json.Unmarshal(jsonBytes, &newAuditor)
 	if err != nil {
-		return shim.Error("Failed to decode json of Auditor: " + err.Error())
+		return ccerror.ValidationFailed("Failed to decode json of Auditor: " + err.Error())
 	}
 	if newAuditor.ObjectType != TYPE_AUDITOR {
-		return shim.Error("Expexted objectType " + TYPE_AUDITOR + " for Auditor")
+		return ccerror.TypeMismatch("Expexted objectType " + TYPE_AUDITOR + " for Auditor")
+	}
+	result := checkAccreditations(newAuditor)
+	if result.Status != shim.OK {
+		return result
 	}
 
-	result := t.createObject(stub, jsonBytes, newAuditor.ID)
+	result = t.createObject(stub, jsonBytes, newAuditor.ID)
+	if result.Status != shim.OK {
+		return result
+	}
 
+	result = t.updateAuditorKeys(stub, nil, &newAuditor)
 	if result.Status == shim.OK {
-		fmt.Println("- end createAuditor (success)")
+		logger.Debug(stub, "end createAuditor (success)")
 	}
 	return result
 }
 
 func (t *FoodChaincode) updateAuditor(stub shim.ChaincodeStubInterface, args []string) pb.Response {
-	fmt.Println("- start updateAuditor", args)
-	if len(args) != 1 {
-		return shim.Error("Incorrect number of arguments. Expecting 1")
-	}
+	logger.Debug(stub, "start updateAuditor")
 
 	jsonBytes := []byte(args[0])
 	newAuditor := Auditor{}
 	err := json.Unmarshal(jsonBytes, &newAuditor)
 	if err != nil {
-		return shim.Error("Failed to decode json of Auditor: " + err.Error())
+		return ccerror.ValidationFailed("Failed to decode json of Auditor: " + err.Error())
 	}
 	if newAuditor.ObjectType != TYPE_AUDITOR {
-		return shim.Error("Expexted objectType " + TYPE_AUDITOR + " for Auditor")
+		return ccerror.TypeMismatch("Expexted objectType " + TYPE_AUDITOR + " for Auditor")
+	}
+	result := checkAccreditations(newAuditor)
+	if result.Status != shim.OK {
+		return result
 	}
 
-	result := t.updateObject(stub, jsonBytes, newAuditor.ID)
+	result, oldAuditor := t.getAuditor(stub, newAuditor.ID)
+	if result.Status != shim.OK {
+		return result
+	}
+
+	result = t.updateObject(stub, jsonBytes, newAuditor.ID)
+	if result.Status != shim.OK {
+		return result
+	}
 
+	result = t.updateAuditorKeys(stub, &oldAuditor, &newAuditor)
 	if result.Status == shim.OK {
-		fmt.Println("- end updateAuditor (success)")
+		logger.Debug(stub, "end updateAuditor (success)")
 	}
-	return shim.Success(nil)
+	return result
 }
 
 // Methods on AuditActions
 // ========================================
 func (t *FoodChaincode) createAuditAction(stub shim.ChaincodeStubInterface, args []string) pb.Response {
-	fmt.Println("- start createAuditAction", args)
-	if len(args) != 1 {
-		return shim.Error("Incorrect number of arguments. Expecting 1")
-	}
+	logger.Debug(stub, "start createAuditAction")
 
 	jsonBytes := []byte(args[0])
 	newAuditAction := AuditAction{}
 	err := json.Unmarshal(jsonBytes, &newAuditAction)
 	if err != nil {
-		return shim.Error("Failed to decode json of AuditAction: " + err.Error())
+		return ccerror.ValidationFailed("Failed to decode json of AuditAction: " + err.Error())
 	}
+	setAuditDefaults(&newAuditAction)
 
-	if len(newAuditAction.ObjectID) < 1 {
-		return shim.Error("ObjectID can not by empty")
-	}
-	if len(newAuditAction.Auditor) < 1 {
-		return shim.Error("AuditorID can not by empty")
-	}
-	if newAuditAction.ObjectType != TYPE_AUDITACTION {
-		return shim.Error("Expexted objectType " + TYPE_AUDITACTION + " for AuditAction")
+	result := t.checkNewAuditAction(stub, newAuditAction, nil)
+	if result.Status != shim.OK {
+		return result
 	}
 
-	result := t.createObject(stub, jsonBytes, newAuditAction.ID)
-
+	result, creator := t.getCreator(stub)
 	if result.Status != shim.OK {
-		fmt.Println("- end createAuditAction (failed)")
 		return result
 	}
+	newAuditAction.Creator = creator
 
-	result = t.putCompositeKey(stub, CK_AUDITOR_AUDIT, []string{newAuditAction.Auditor, newAuditAction.ID})
+	result = t.createAuditActionHandler(stub, newAuditAction)
 	if result.Status != shim.OK {
-		fmt.Println("- end createAuditAction (failed)")
+		logger.Debug(stub, "end createAuditAction (failed)")
 		return result
 	}
 
-	result = t.putCompositeKey(stub, CK_AUDIT_OBJ, []string{newAuditAction.ObjectID, newAuditAction.ID})
+	result = t.setChangeEvent(stub, newAuditActionEvent(EVENT_AUDITACTION_CREATED, nil, newAuditAction))
 	if result.Status != shim.OK {
-		fmt.Println("- end createAuditAction (failed)")
+		logger.Debug(stub, "end createAuditAction (failed)")
 		return result
 	}
 
-	fmt.Println("- end createAuditAction (success)")
+	logger.Debug(stub, "end createAuditAction (success)")
 
 	return result
 }
 
 func (t *FoodChaincode) updateAuditAction(stub shim.ChaincodeStubInterface, args []string) pb.Response {
-	fmt.Println("- start updateAuditAction", args)
-	if len(args) != 1 {
-		return shim.Error("Incorrect number of arguments. Expecting 1")
-	}
+	logger.Debug(stub, "start updateAuditAction")
 
 	jsonBytes := []byte(args[0])
 	newAuditActions := AuditAction{}
 	err := json.Unmarshal(jsonBytes, &newAuditActions)
 	if err != nil {
-		return shim.Error("Failed to decode json of AuditAction: " + err.Error())
+		return ccerror.ValidationFailed("Failed to decode json of AuditAction: " + err.Error())
 	}
 
+	if len(newAuditActions.ObjectID) < 1 {
+		return ccerror.ValidationFailed("ObjectID can not by empty")
+	}
+	if len(newAuditActions.Auditor) < 1 {
+		return ccerror.ValidationFailed("AuditorID can not by empty")
+	}
 	if newAuditActions.ObjectType != TYPE_AUDITACTION {
-		return shim.Error("Expexted objectType " + TYPE_AUDITACTION + " for AuditAction")
+		return ccerror.TypeMismatch("Expexted objectType " + TYPE_AUDITACTION + " for AuditAction")
+	}
+	result := checkDocuments(newAuditActions.ID, newAuditActions.Documents)
+	if result.Status != shim.OK {
+		return result
+	}
+	result = t.checkAuditActionReferences(stub, newAuditActions, nil)
+	if result.Status != shim.OK {
+		return result
+	}
+
+	existedObjectAsBytes, err := stub.GetState(newAuditActions.ID)
+	if err != nil {
+		return ccerror.Internal("Failed to get existed Object with ID: " + newAuditActions.ID + ", error: " + err.Error())
+	} else if existedObjectAsBytes == nil {
+		return ccerror.NotFound("Object with ID " + newAuditActions.ID + " does not exist")
+	}
+
+	oldAuditAction := AuditAction{}
+	err = json.Unmarshal(existedObjectAsBytes, &oldAuditAction)
+	if err != nil {
+		return ccerror.Internal("Failed to get decode AuditAction: " + err.Error())
+	}
+
+	result, creator := t.getCreator(stub)
+	if result.Status != shim.OK {
+		return result
+	}
+	result = t.checkCreatorMSP(oldAuditAction.ID, oldAuditAction.Creator, creator)
+	if result.Status != shim.OK {
+		logger.Debug(stub, "end updateAuditAction (failed)")
+		return result
+	}
+	newAuditActions.Creator = oldAuditAction.Creator
+
+	if newAuditActions.Auditor != oldAuditAction.Auditor || newAuditActions.ObjectID != oldAuditAction.ObjectID {
+		result = t.checkAuditorAccreditation(stub, newAuditActions, nil)
+		if result.Status != shim.OK {
+			logger.Debug(stub, "end updateAuditAction (failed)")
+			return result
+		}
+	}
+
+	if len(newAuditActions.Status) < 1 {
+		newAuditActions.Status = oldAuditAction.Status
+	}
+	setAuditDefaults(&newAuditActions)
+	result = checkAuditLifecycle(&oldAuditAction, newAuditActions)
+	if result.Status != shim.OK {
+		logger.Debug(stub, "end updateAuditAction (failed)")
+		return result
+	}
+
+	result = t.updateCompositeKey(
+		stub,
+		CK_AUDITOR_AUDIT,
+		[]string{oldAuditAction.Auditor, oldAuditAction.ID},
+		[]string{newAuditActions.Auditor, newAuditActions.ID})
+	if result.Status != shim.OK {
+		logger.Debug(stub, "end updateAuditAction (failed)")
+		return result
 	}
 
-	result := t.updateObject(stub, jsonBytes, newAuditActions.ID)
+	result = t.updateCompositeKey(
+		stub,
+		CK_AUDIT_OBJ,
+		[]string{oldAuditAction.ObjectID, oldAuditAction.ID},
+		[]string{newAuditActions.ObjectID, newAuditActions.ID})
+	if result.Status != shim.OK {
+		logger.Debug(stub, "end updateAuditAction (failed)")
+		return result
+	}
+
+	result = t.updateCompositeKey(
+		stub,
+		CK_AUDIT_STATUS,
+		[]string{oldAuditAction.Status, oldAuditAction.ID},
+		[]string{newAuditActions.Status, newAuditActions.ID})
+	if result.Status != shim.OK {
+		logger.Debug(stub, "end updateAuditAction (failed)")
+		return result
+	}
+
+	result = t.updateDocumentKeys(stub, newAuditActions.ID, oldAuditAction.Documents, newAuditActions.Documents)
+	if result.Status != shim.OK {
+		logger.Debug(stub, "end updateAuditAction (failed)")
+		return result
+	}
+
+	jsonBytes, err = json.Marshal(newAuditActions)
+	if err != nil {
+		return ccerror.Internal("Failed to encode json of AuditAction: " + err.Error())
+	}
+
+	result = t.updateObject(stub, jsonBytes, newAuditActions.ID)
+	if result.Status != shim.OK {
+		logger.Debug(stub, "end updateAuditAction (failed)")
+		return result
+	}
+
+	result = t.setChangeEvent(stub, newAuditActionEvent(EVENT_AUDITACTION_UPDATED, &oldAuditAction, newAuditActions))
 
 	if result.Status == shim.OK {
-		fmt.Println("- end updateAuditAction (success)")
+		logger.Debug(stub, "end updateAuditAction (success)")
 	}
-	return shim.Success(nil)
+	return result
 }
 
 // Query methods
 // ========================================
 func (t *FoodChaincode) getObject(stub shim.ChaincodeStubInterface, args []string) pb.Response {
-	fmt.Println("- start getObject", args)
-	if len(args) != 2 {
-		return shim.Error("Incorrect number of arguments. Expecting 2")
-	}
+	logger.Debug(stub, "start getObject")
 
 	ID := args[0]
 	objectType := args[1]
 	existedObjectAsBytes, err := stub.GetState(ID)
 	if err != nil {
-		return shim.Error("Failed to get existed Object with ID: " + ID + ", error: " + err.Error())
+		return ccerror.Internal("Failed to get existed Object with ID: " + ID + ", error: " + err.Error())
 	} else if existedObjectAsBytes == nil {
-		return shim.Error("Object with ID " + ID + " does not exist")
+		return ccerror.NotFound("Object with ID " + ID + " does not exist")
 	}
 
 	liteModel := LiteModel{}
 	err = json.Unmarshal(existedObjectAsBytes, &liteModel)
 	if err != nil {
-		return shim.Error("Failed to get decode object: " + err.Error())
+		return ccerror.Internal("Failed to get decode object: " + err.Error())
 	}
 	if liteModel.ObjectType != objectType {
-		return shim.Error("ObjectType does not match")
+		return ccerror.TypeMismatch("ObjectType does not match")
 	}
 
-	fmt.Println("- end getObject (success)")
+	logger.Debug(stub, "end getObject (success)")
 	return shim.Success(existedObjectAsBytes)
 }
 
+// getLogsOfSupplychain returns all logs of a supplychain. When a page size
+// and a bookmark are passed as well, only one page of logs is returned.
 func (t *FoodChaincode) getLogsOfSupplychain(stub shim.ChaincodeStubInterface, args []string) pb.Response {
-	fmt.Println("- start getLogsOfSupplychain", args)
-	if len(args) != 1 {
-		return shim.Error("Incorrect number of arguments. Expecting 1")
-	}
+	logger.Debug(stub, "start getLogsOfSupplychain")
 
 	ID := args[0]
 
 	existedObjectAsBytes, err := stub.GetState(ID)
 	if err != nil {
-		return shim.Error("Failed to get existed Supplychain with ID: " + ID + ", error: " + err.Error())
+		return ccerror.Internal("Failed to get existed Supplychain with ID: " + ID + ", error: " + err.Error())
 	} else if existedObjectAsBytes == nil {
-		return shim.Error("Supplychain with ID " + ID + " does not exist")
+		return ccerror.NotFound("Supplychain with ID " + ID + " does not exist")
 	}
 
 	sc := Traceable{}
 	err = json.Unmarshal(existedObjectAsBytes, &sc)
 	if err != nil {
-		return shim.Error("Failed to get decode object: " + err.Error())
+		return ccerror.Internal("Failed to get decode object: " + err.Error())
 	}
 
 	if sc.ObjectType != TYPE_SUPPLYCHAIN {
-		return shim.Error("Object with ID: " + ID + "is not a Supplychain")
+		return ccerror.TypeMismatch("Object with ID: " + ID + "is not a Supplychain")
+	}
+
+	if len(args) == 3 {
+		result := t.getLogsPage(stub, CK_SC_LOG, ID, args[1], args[2])
+		if result.Status == shim.OK {
+			logger.Debug(stub, "end getLogsOfSupplychain (success)")
+		}
+		return result
 	}
 
 	resultsIterator, err := stub.GetStateByPartialCompositeKey(CK_SC_LOG, []string{ID})
 	if err != nil {
-		return shim.Error(err.Error())
+		return ccerror.Internal(err.Error())
 	}
 	defer resultsIterator.Close()
 
 	result, responseAsBytes := t.getLogsFromIterator(stub, resultsIterator)
 	if err != nil {
-		return shim.Error("Failed to get encode response: " + err.Error())
+		return ccerror.Internal("Failed to get encode response: " + err.Error())
 	}
 
 	if result.Status != shim.OK {
-		fmt.Println("- end getLogsOfSupplychain (failed)")
+		logger.Debug(stub, "end getLogsOfSupplychain (failed)")
 		return result
 	}
 
-	fmt.Println("- end getLogsOfSupplychain (success)")
+	logger.Debug(stub, "end getLogsOfSupplychain (success)")
 	return shim.Success(responseAsBytes)
 }
 
+// getLogsOfProduct returns all logs of a product. When a page size and a
+// bookmark are passed as well, only one page of logs is returned.
 func (t *FoodChaincode) getLogsOfProduct(stub shim.ChaincodeStubInterface, args []string) pb.Response {
-	fmt.Println("- start getLogsOfProduct", args)
-	if len(args) != 1 {
-		return shim.Error("Incorrect number of arguments. Expecting 1")
-	}
+	logger.Debug(stub, "start getLogsOfProduct")
 
 	ID := args[0]
 
 	existedObjectAsBytes, err := stub.GetState(ID)
 	if err != nil {
-		return shim.Error("Failed to get existed Product with ID: " + ID + ", error: " + err.Error())
+		return ccerror.Internal("Failed to get existed Product with ID: " + ID + ", error: " + err.Error())
 	} else if existedObjectAsBytes == nil {
-		return shim.Error("Product with ID " + ID + " does not exist")
+		return ccerror.NotFound("Product with ID " + ID + " does not exist")
 	}
 
 	product := Traceable{}
 	err = json.Unmarshal(existedObjectAsBytes, &product)
 	if err != nil {
-		return shim.Error("Failed to get decode object: " + err.Error())
+		return ccerror.Internal("Failed to get decode object: " + err.Error())
 	}
 
 	if product.ObjectType != TYPE_PRODUCT {
-		return shim.Error("Object with ID: " + ID + "is not a Product")
+		return ccerror.TypeMismatch("Object with ID: " + ID + "is not a Product")
+	}
+
+	if len(args) == 3 {
+		result := t.getLogsPage(stub, CK_PRODUCT_LOG, ID, args[1], args[2])
+		if result.Status == shim.OK {
+			logger.Debug(stub, "end getLogsOfProduct (success)")
+		}
+		return result
 	}
 
 	resultsIterator, err := stub.GetStateByPartialCompositeKey(CK_PRODUCT_LOG, []string{ID})
 	if err != nil {
-		return shim.Error(err.Error())
+		return ccerror.Internal(err.Error())
 	}
 	defer resultsIterator.Close()
 
 	result, responseAsBytes := t.getLogsFromIterator(stub, resultsIterator)
 	if err != nil {
-		return shim.Error("Failed to get encode response: " + err.Error())
+		return ccerror.Internal("Failed to get encode response: " + err.Error())
 	}
 
 	if result.Status != shim.OK {
-		fmt.Println("- end getLogsOfProduct (failed)")
+		logger.Debug(stub, "end getLogsOfProduct (failed)")
 		return result
 	}
 
-	fmt.Println("- end getLogsOfProduct (success)")
+	logger.Debug(stub, "end getLogsOfProduct (success)")
 	return shim.Success(responseAsBytes)
 }
 
-func (t *FoodChaincode) getAuditOfObject(stub shim.ChaincodeStubInterface, args []string) pb.Response {
-	fmt.Println("- start getAuditOfObject", args)
-	if len(args) != 1 {
-		return shim.Error("Incorrect number of arguments. Expecting 1")
-	}
+func (t *FoodChaincode) getChildrenOfTraceable(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	logger.Debug(stub, "start getChildrenOfTraceable")
 
 	ID := args[0]
-	resultsIterator, err := stub.GetStateByPartialCompositeKey(CK_AUDIT_OBJ, []string{ID})
+	result, _ := t.getTraceable(stub, ID)
+	if result.Status != shim.OK {
+		return result
+	}
+
+	result, children := t.getChildren(stub, ID)
+	if result.Status != shim.OK {
+		logger.Debug(stub, "end getChildrenOfTraceable (failed)")
+		return result
+	}
+
+	responseAsBytes, err := json.Marshal(children)
 	if err != nil {
-		return shim.Error(err.Error())
+		return ccerror.Internal("Failed to get encode response: " + err.Error())
 	}
-	defer resultsIterator.Close()
 
-	var i int
-	for i = 0; resultsIterator.HasNext(); i++ {
-		responseRange, err := resultsIterator.Next()
-		if err != nil {
-			return shim.Error(err.Error())
-		}
+	logger.Debug(stub, "end getChildrenOfTraceable (success)")
+	return shim.Success(responseAsBytes)
+}
 
-		_, compositeKeyParts, err := stub.SplitCompositeKey(responseRange.Key)
-		if err != nil {
-			return shim.Error(err.Error())
+// getTraceableTree returns a Traceable with its descendants nested under it,
+// down to an optional depth
+func (t *FoodChaincode) getTraceableTree(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	logger.Debug(stub, "start getTraceableTree")
+
+	ID := args[0]
+	maxDepth := DEFAULT_TREE_DEPTH
+	if len(args) == 2 {
+		depth, err := strconv.Atoi(args[1])
+		if err != nil || depth < 0 || depth > MAX_TREE_DEPTH {
+			return ccerror.ValidationFailed("Depth must be a number between 0 and " + strconv.Itoa(MAX_TREE_DEPTH))
 		}
-		returnedAuditID := compositeKeyParts[1]
-		fmt.Printf("- found an audit:%s\n", returnedAuditID)
+		maxDepth = depth
+	}
 
-		auditAsBytes, err := stub.GetState(returnedAuditID)
+	result, root := t.getTraceable(stub, ID)
+	if result.Status != shim.OK {
+		return result
+	}
+
+	visited := map[string]bool{ID: true}
+	result, tree := t.buildTraceableTree(stub, root, maxDepth, visited)
+	if result.Status != shim.OK {
+		logger.Debug(stub, "end getTraceableTree (failed)")
+		return result
+	}
+
+	responseAsBytes, err := json.Marshal(tree)
+	if err != nil {
+		return ccerror.Internal("Failed to get encode response: " + err.Error())
+	}
+
+	logger.Debug(stub, "end getTraceableTree (success)")
+	return shim.Success(responseAsBytes)
+}
+
+// getAuditOfObject returns every audit of an object and a summary of them.
+// The optional AuditFilter selects the audits and their order, latest first
+// by default.
+func (t *FoodChaincode) getAuditOfObject(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	logger.Debug(stub, "start getAuditOfObject")
+
+	filter := AuditFilter{Sort: SORT_DESC}
+	if len(args) == 2 {
+		err := json.Unmarshal([]byte(args[1]), &filter)
 		if err != nil {
-			return shim.Error("Failed to get existed Audit with ID: " + returnedAuditID + ", error: " + err.Error())
-		} else if auditAsBytes == nil {
-			return shim.Error("Audit with ID " + returnedAuditID + " does not exist")
+			return ccerror.ValidationFailed("Failed to decode json of AuditFilter: " + err.Error())
 		}
-		fmt.Println("- end getAuditOfObject (success)")
-		return shim.Success(auditAsBytes)
+	}
+	result := checkAuditFilter(&filter)
+	if result.Status != shim.OK {
+		return result
+	}
+
+	ID := args[0]
+	result, audits := t.getAuditsByCompositeKey(stub, CK_AUDIT_OBJ, ID)
+	if result.Status != shim.OK {
+		return result
 	}
 
-	fmt.Println("- end getAuditOfObject (failed)")
-	return shim.Error("No audit was found")
+	response := AuditsOfObject{ObjectID: ID, Audits: filterAudits(audits, filter)}
+	response.Summary = summarizeAudits(response.Audits)
+
+	responseAsBytes, err := json.Marshal(response)
+	if err != nil {
+		return ccerror.Internal("Failed to get encode response: " + err.Error())
+	}
+
+	logger.Debug(stub, "end getAuditOfObject (success)")
+	return shim.Success(responseAsBytes)
 }
 
 func (t *FoodChaincode) getAuditsOfAuditor(stub shim.ChaincodeStubInterface, args []string) pb.Response {
-	fmt.Println("- start getAuditsOfAuditor", args)
-	if len(args) != 1 {
-		return shim.Error("Incorrect number of arguments. Expecting 1")
-	}
+	logger.Debug(stub, "start getAuditsOfAuditor")
 
 	ID := args[0]
 	resultsIterator, err := stub.GetStateByPartialCompositeKey(CK_AUDITOR_AUDIT, []string{ID})
 	if err != nil {
-		return shim.Error(err.Error())
+		return ccerror.Internal(err.Error())
 	}
 	defer resultsIterator.Close()
 
@@ -528,37 +708,37 @@ func (t *FoodChaincode) getAuditsOfAuditor(stub shim.ChaincodeStubInterface, arg
 	for i = 0; resultsIterator.HasNext(); i++ {
 		responseRange, err := resultsIterator.Next()
 		if err != nil {
-			return shim.Error(err.Error())
+			return ccerror.Internal(err.Error())
 		}
 
 		_, compositeKeyParts, err := stub.SplitCompositeKey(responseRange.Key)
 		if err != nil {
-			return shim.Error(err.Error())
+			return ccerror.Internal(err.Error())
 		}
 		returnedAuditID := compositeKeyParts[1]
-		fmt.Printf("- found an audit:%s\n", returnedAuditID)
+		logger.Debug(stub, "found an audit", logging.Fields{"ID": returnedAuditID})
 
 		auditAsBytes, err := stub.GetState(returnedAuditID)
 		if err != nil {
-			return shim.Error("Failed to get existed Audit with ID: " + returnedAuditID + ", error: " + err.Error())
+			return ccerror.Internal("Failed to get existed Audit with ID: " + returnedAuditID + ", error: " + err.Error())
 		} else if auditAsBytes == nil {
-			return shim.Error("Audit with ID " + returnedAuditID + " does not exist")
+			return ccerror.NotFound("Audit with ID " + returnedAuditID + " does not exist")
 		}
 
 		audit := AuditAction{}
 		err = json.Unmarshal(auditAsBytes, &audit)
 		if err != nil {
-			return shim.Error("Failed to get decode audit: " + err.Error())
+			return ccerror.Internal("Failed to get decode audit: " + err.Error())
 		}
 		response = append(response, audit)
 	}
 
 	responseAsBytes, err := json.Marshal(response)
 	if err != nil {
-		return shim.Error("Failed to get encode response: " + err.Error())
+		return ccerror.Internal("Failed to get encode response: " + err.Error())
 	}
 
-	fmt.Println("- end getAuditsOfAuditor (success)")
+	logger.Debug(stub, "end getAuditsOfAuditor (success)")
 	return shim.Success(responseAsBytes)
 }
 
@@ -568,17 +748,13 @@ func (t *FoodChaincode) getAuditsOfAuditor(stub shim.ChaincodeStubInterface, arg
 // =========================================================================================
 func (t *FoodChaincode) getQueryResultForQueryString(stub shim.ChaincodeStubInterface, args []string) pb.Response {
 
-	fmt.Printf("- getQueryResultForQueryString args:\n%s\n", args)
-
-	if len(args) != 1 {
-		return shim.Error("Incorrect number of arguments. Expecting 1")
-	}
+	logger.Debug(stub, "start getQueryResultForQueryString")
 
 	queryString := args[0]
 
 	resultsIterator, err := stub.GetQueryResult(queryString)
 	if err != nil {
-		return shim.Error(err.Error())
+		return ccerror.Internal(err.Error())
 	}
 	defer resultsIterator.Close()
 
@@ -587,10 +763,11 @@ func (t *FoodChaincode) getQueryResultForQueryString(stub shim.ChaincodeStubInte
 	buffer.WriteString("[")
 
 	bArrayMemberAlreadyWritten := false
+	count := 0
 	for resultsIterator.HasNext() {
 		queryResponse, err := resultsIterator.Next()
 		if err != nil {
-			return shim.Error(err.Error())
+			return ccerror.Internal(err.Error())
 		}
 		// Add a comma before array members, suppress it for the first array member
 		if bArrayMemberAlreadyWritten == true {
@@ -606,10 +783,11 @@ func (t *FoodChaincode) getQueryResultForQueryString(stub shim.ChaincodeStubInte
 		buffer.WriteString(string(queryResponse.Value))
 		buffer.WriteString("}")
 		bArrayMemberAlreadyWritten = true
+		count++
 	}
 	buffer.WriteString("]")
 
-	fmt.Printf("- getQueryResultForQueryString queryResult:\n%s\n", buffer.String())
+	logger.Debug(stub, "end getQueryResultForQueryString (success)", logging.Fields{"results": count})
 
 	return shim.Success(buffer.Bytes())
 }
@@ -619,17 +797,13 @@ func (t *FoodChaincode) getQueryResultForQueryString(stub shim.ChaincodeStubInte
 // =========================================================================================
 func (t *FoodChaincode) getHistoryOfObject(stub shim.ChaincodeStubInterface, args []string) pb.Response {
 
-	fmt.Printf("- getHistoryOfObject args:\n%s\n", args)
-
-	if len(args) != 1 {
-		return shim.Error("Incorrect number of arguments. Expecting 1")
-	}
+	logger.Debug(stub, "start getHistoryOfObject", logging.Fields{"ID": args[0]})
 
 	ID := args[0]
 
 	resultsIterator, err := stub.GetHistoryForKey(ID)
 	if err != nil {
-		return shim.Error(err.Error())
+		return ccerror.Internal(err.Error())
 	}
 	defer resultsIterator.Close()
 
@@ -638,10 +812,11 @@ func (t *FoodChaincode) getHistoryOfObject(stub shim.ChaincodeStubInterface, arg
 	buffer.WriteString("[")
 
 	bArrayMemberAlreadyWritten := false
+	count := 0
 	for resultsIterator.HasNext() {
 		response, err := resultsIterator.Next()
 		if err != nil {
-			return shim.Error(err.Error())
+			return ccerror.Internal(err.Error())
 		}
 		// Add a comma before array members, suppress it for the first array member
 		if bArrayMemberAlreadyWritten == true {
@@ -674,10 +849,11 @@ func (t *FoodChaincode) getHistoryOfObject(stub shim.ChaincodeStubInterface, arg
 
 		buffer.WriteString("}")
 		bArrayMemberAlreadyWritten = true
+		count++
 	}
 	buffer.WriteString("]")
 
-	fmt.Printf("- getHistoryOfObject returning:\n%s\n", buffer.String())
+	logger.Debug(stub, "end getHistoryOfObject (success)", logging.Fields{"versions": count})
 
 	return shim.Success(buffer.Bytes())
 }
@@ -687,14 +863,19 @@ func (t *FoodChaincode) getHistoryOfObject(stub shim.ChaincodeStubInterface, arg
 func (t *FoodChaincode) createObject(stub shim.ChaincodeStubInterface, bytes []byte, ID string) pb.Response {
 	existedObjectAsBytes, err := stub.GetState(ID)
 	if err != nil {
-		return shim.Error("Failed to get existed Object with ID: " + ID + ", error: " + err.Error())
+		return ccerror.Internal("Failed to get existed Object with ID: " + ID + ", error: " + err.Error())
 	} else if existedObjectAsBytes != nil {
-		return shim.Error("Object with ID " + ID + " already existed")
+		return ccerror.AlreadyExists("Object with ID " + ID + " already existed")
+	}
+
+	bytes, err = withVersion(bytes, 1)
+	if err != nil {
+		return ccerror.Internal("Failed to set version of Object with ID: " + ID + ", error: " + err.Error())
 	}
 
 	err = stub.PutState(ID, bytes)
 	if err != nil {
-		return shim.Error("Failed to create new object with ID: " + ID + ", error: " + err.Error())
+		return ccerror.Internal("Failed to create new object with ID: " + ID + ", error: " + err.Error())
 	}
 
 	return shim.Success(nil)
@@ -703,33 +884,199 @@ func (t *FoodChaincode) createObject(stub shim.ChaincodeStubInterface, bytes []b
 func (t *FoodChaincode) updateObject(stub shim.ChaincodeStubInterface, bytes []byte, ID string) pb.Response {
 	existedObjectAsBytes, err := stub.GetState(ID)
 	if err != nil {
-		return shim.Error("Failed to get existed Object with ID: " + ID + ", error: " + err.Error())
+		return ccerror.Internal("Failed to get existed Object with ID: " + ID + ", error: " + err.Error())
 	} else if existedObjectAsBytes == nil {
-		return shim.Error("Object with ID " + ID + " does not exist")
+		return ccerror.NotFound("Object with ID " + ID + " does not exist")
+	}
+
+	result, bytes := checkVersion(ID, existedObjectAsBytes, bytes)
+	if result.Status != shim.OK {
+		return result
 	}
 
 	err = stub.PutState(ID, bytes)
 	if err != nil {
-		return shim.Error("Failed to update the object with ID: " + ID + ", error: " + err.Error())
+		return ccerror.Internal("Failed to update the object with ID: " + ID + ", error: " + err.Error())
 	}
 	return shim.Success(nil)
 }
 
-func (t *FoodChaincode) updateLogHandler(stub shim.ChaincodeStubInterface, bytes []byte, newLog Log) pb.Response {
+// checkNewLog validates a Log before it is created. The code of the error
+// tells bulk callers why the Log was rejected.
+func (t *FoodChaincode) checkNewLog(stub shim.ChaincodeStubInterface, newLog Log, pending map[string]string) pb.Response {
+	if newLog.ObjectType != TYPE_LOG {
+		return ccerror.TypeMismatch("Expexted objectType " + TYPE_LOG + " for Log")
+	}
+	if len(newLog.ID) < 1 {
+		return ccerror.ValidationFailed("ID can not by empty")
+	}
+	if len(newLog.PrivateHashes) > 0 {
+		return ccerror.ValidationFailed("privateHashes of Log " + newLog.ID + " are set from the transient map")
+	}
+	result := checkGeoLocation(newLog)
+	if result.Status != shim.OK {
+		return result
+	}
+	result = checkDocuments(newLog.ID, newLog.Documents)
+	if result.Status != shim.OK {
+		return result
+	}
+	result = t.checkLogSchema(stub, newLog)
+	if result.Status != shim.OK {
+		return result
+	}
+	result = t.checkLogReferences(stub, newLog, pending)
+	if result.Status != shim.OK {
+		return result
+	}
+	return t.checkNotExists(stub, newLog.ID)
+}
+
+// createLogHandler stores a checked Log and its index keys
+func (t *FoodChaincode) createLogHandler(stub shim.ChaincodeStubInterface, newLog Log) pb.Response {
+	jsonBytes, err := json.Marshal(newLog)
+	if err != nil {
+		return ccerror.Internal("Failed to encode json of Log: " + err.Error())
+	}
+
+	result := t.createObject(stub, jsonBytes, newLog.ID)
+	if result.Status != shim.OK {
+		return result
+	}
+
+	if len(newLog.Supplychain) > 0 {
+		result = t.putCompositeKey(stub, CK_SC_LOG, []string{newLog.Supplychain, newLog.ID})
+		if result.Status != shim.OK {
+			return result
+		}
+	}
+
+	result = t.updateProductKeys(stub, newLog.ID, nil, logProducts(newLog))
+	if result.Status != shim.OK {
+		return result
+	}
+
+	result = t.updateLogKeys(stub, nil, &newLog)
+	if result.Status != shim.OK {
+		return result
+	}
+
+	result = t.updateDocumentKeys(stub, newLog.ID, nil, newLog.Documents)
+	if result.Status != shim.OK {
+		return result
+	}
+
+	return t.updateRefKeys(stub, newLog.ID, []string{}, newLog.Ref)
+}
+
+// checkNewAuditAction validates an AuditAction before it is created. The
+// code of the error tells bulk callers why the AuditAction was rejected.
+func (t *FoodChaincode) checkNewAuditAction(stub shim.ChaincodeStubInterface, newAuditAction AuditAction, pending map[string]string) pb.Response {
+	if len(newAuditAction.ObjectID) < 1 {
+		return ccerror.ValidationFailed("ObjectID can not by empty")
+	}
+	if len(newAuditAction.Auditor) < 1 {
+		return ccerror.ValidationFailed("AuditorID can not by empty")
+	}
+	if newAuditAction.ObjectType != TYPE_AUDITACTION {
+		return ccerror.TypeMismatch("Expexted objectType " + TYPE_AUDITACTION + " for AuditAction")
+	}
+	if len(newAuditAction.ID) < 1 {
+		return ccerror.ValidationFailed("ID can not by empty")
+	}
+	result := checkAuditLifecycle(nil, newAuditAction)
+	if result.Status != shim.OK {
+		return result
+	}
+	result = checkDocuments(newAuditAction.ID, newAuditAction.Documents)
+	if result.Status != shim.OK {
+		return result
+	}
+	result = t.checkAuditActionReferences(stub, newAuditAction, pending)
+	if result.Status != shim.OK {
+		return result
+	}
+	result = t.checkAuditorAccreditation(stub, newAuditAction, pending)
+	if result.Status != shim.OK {
+		return result
+	}
+	return t.checkNotExists(stub, newAuditAction.ID)
+}
+
+// createAuditActionHandler stores a checked AuditAction and its index keys
+func (t *FoodChaincode) createAuditActionHandler(stub shim.ChaincodeStubInterface, newAuditAction AuditAction) pb.Response {
+	jsonBytes, err := json.Marshal(newAuditAction)
+	if err != nil {
+		return ccerror.Internal("Failed to encode json of AuditAction: " + err.Error())
+	}
+
+	result := t.createObject(stub, jsonBytes, newAuditAction.ID)
+	if result.Status != shim.OK {
+		return result
+	}
+
+	result = t.putCompositeKey(stub, CK_AUDITOR_AUDIT, []string{newAuditAction.Auditor, newAuditAction.ID})
+	if result.Status != shim.OK {
+		return result
+	}
+
+	result = t.putCompositeKey(stub, CK_AUDIT_OBJ, []string{newAuditAction.ObjectID, newAuditAction.ID})
+	if result.Status != shim.OK {
+		return result
+	}
+
+	result = t.putCompositeKey(stub, CK_AUDIT_STATUS, []string{newAuditAction.Status, newAuditAction.ID})
+	if result.Status != shim.OK {
+		return result
+	}
+
+	return t.updateDocumentKeys(stub, newAuditAction.ID, nil, newAuditAction.Documents)
+}
+
+func (t *FoodChaincode) checkNotExists(stub shim.ChaincodeStubInterface, ID string) pb.Response {
+	existedObjectAsBytes, err := stub.GetState(ID)
+	if err != nil {
+		return ccerror.Internal("Failed to get existed Object with ID: " + ID + ", error: " + err.Error())
+	} else if existedObjectAsBytes != nil {
+		return ccerror.AlreadyExists("Object with ID " + ID + " already existed")
+	}
+	return shim.Success(nil)
+}
+
+func (t *FoodChaincode) updateLogHandler(stub shim.ChaincodeStubInterface, newLog Log) pb.Response {
 	existedObjectAsBytes, err := stub.GetState(newLog.ID)
 	if err != nil {
-		return shim.Error("Failed to get existed Object with ID: " + newLog.ID + ", error: " + err.Error())
+		return ccerror.Internal("Failed to get existed Object with ID: " + newLog.ID + ", error: " + err.Error())
 	} else if existedObjectAsBytes == nil {
-		return shim.Error("Object with ID " + newLog.ID + " does not exist")
+		return ccerror.NotFound("Object with ID " + newLog.ID + " does not exist")
 	}
 
 	oldLog := Log{}
 	err = json.Unmarshal(existedObjectAsBytes, &oldLog)
 	if err != nil {
-		return shim.Error("Failed to get decode Log: " + err.Error())
+		return ccerror.Internal("Failed to get decode Log: " + err.Error())
 	}
 
-	var result pb.Response
+	result, creator := t.getCreator(stub)
+	if result.Status != shim.OK {
+		return result
+	}
+	result = t.checkCreatorMSP(oldLog.ID, oldLog.Creator, creator)
+	if result.Status != shim.OK {
+		logger.Debug(stub, "end updateLog (failed)")
+		return result
+	}
+	newLog.Creator = oldLog.Creator
+
+	result = t.applyLogPrivate(stub, &newLog, &oldLog)
+	if result.Status != shim.OK {
+		logger.Debug(stub, "end updateLog (failed)")
+		return result
+	}
+	bytes, err := json.Marshal(newLog)
+	if err != nil {
+		return ccerror.Internal("Failed to encode json of Log: " + err.Error())
+	}
 
 	if len(newLog.Supplychain) > 0 {
 		result = t.updateCompositeKey(
@@ -738,51 +1085,272 @@ func (t *FoodChaincode) updateLogHandler(stub shim.ChaincodeStubInterface, bytes
 			[]string{oldLog.Supplychain, oldLog.ID},
 			[]string{newLog.Supplychain, newLog.ID})
 		if result.Status != shim.OK {
-			fmt.Println("- end updateLog (failed)")
+			logger.Debug(stub, "end updateLog (failed)")
 			return result
 		}
 	} else if len(oldLog.Supplychain) > 0 {
 		result = t.deleteCompositeKey(stub, CK_SC_LOG, []string{oldLog.Supplychain, oldLog.ID})
 		if result.Status != shim.OK {
-			fmt.Println("- end updateLog (failed)")
+			logger.Debug(stub, "end updateLog (failed)")
 			return result
 		}
 	}
 
-	if len(newLog.Product) > 0 {
+	result = t.updateProductKeys(stub, newLog.ID, logProducts(oldLog), logProducts(newLog))
+	if result.Status != shim.OK {
+		logger.Debug(stub, "end updateLog (failed)")
+		return result
+	}
+
+	result = t.updateLogKeys(stub, &oldLog, &newLog)
+	if result.Status != shim.OK {
+		logger.Debug(stub, "end updateLog (failed)")
+		return result
+	}
+
+	result = t.updateDocumentKeys(stub, newLog.ID, oldLog.Documents, newLog.Documents)
+	if result.Status != shim.OK {
+		logger.Debug(stub, "end updateLog (failed)")
+		return result
+	}
+
+	result = t.updateRefKeys(stub, newLog.ID, oldLog.Ref, newLog.Ref)
+	if result.Status != shim.OK {
+		logger.Debug(stub, "end updateLog (failed)")
+		return result
+	}
+
+	result, bytes = checkVersion(newLog.ID, existedObjectAsBytes, bytes)
+	if result.Status != shim.OK {
+		logger.Debug(stub, "end updateLog (failed)")
+		return result
+	}
+
+	err = stub.PutState(newLog.ID, bytes)
+	if err != nil {
+		return ccerror.Internal("Failed to update the object with ID: " + newLog.ID + ", error: " + err.Error())
+	}
+
+	return t.setChangeEvent(stub, newLogEvent(EVENT_LOG_UPDATED, &oldLog, newLog))
+}
+
+// updateRefKeys maintains the ref~log index of a log, so logs can be found
+// from the objects they reference
+func (t *FoodChaincode) updateRefKeys(stub shim.ChaincodeStubInterface, logID string, oldRefs []string, newRefs []string) pb.Response {
+	return t.updateLogIDKeys(stub, CK_REF_LOG, logID, oldRefs, newRefs)
+}
+
+// updateProductKeys maintains the product~log index of a log, a log is
+// found from each of its products
+func (t *FoodChaincode) updateProductKeys(stub shim.ChaincodeStubInterface, logID string, oldProducts []string, newProducts []string) pb.Response {
+	return t.updateLogIDKeys(stub, CK_PRODUCT_LOG, logID, oldProducts, newProducts)
+}
+
+// updateLogIDKeys replaces the keys of a log in an index of ID~log keys,
+// only the keys of IDs which were added or removed are written
+func (t *FoodChaincode) updateLogIDKeys(stub shim.ChaincodeStubInterface, index string, logID string, oldIDs []string, newIDs []string) pb.Response {
+	oldSet := map[string]bool{}
+	for _, ID := range oldIDs {
+		if len(ID) > 0 {
+			oldSet[ID] = true
+		}
+	}
+	newSet := map[string]bool{}
+	for _, ID := range newIDs {
+		if len(ID) > 0 {
+			newSet[ID] = true
+		}
+	}
+
+	for _, ID := range oldIDs {
+		if oldSet[ID] && !newSet[ID] {
+			result := t.deleteCompositeKey(stub, index, []string{ID, logID})
+			if result.Status != shim.OK {
+				return result
+			}
+			oldSet[ID] = false
+		}
+	}
+	for _, ID := range newIDs {
+		if newSet[ID] && !oldSet[ID] {
+			result := t.putCompositeKey(stub, index, []string{ID, logID})
+			if result.Status != shim.OK {
+				return result
+			}
+			oldSet[ID] = true
+		}
+	}
+	return shim.Success(nil)
+}
+
+func (t *FoodChaincode) updateTraceableHandler(stub shim.ChaincodeStubInterface, bytes []byte, newTraceable Traceable) pb.Response {
+	existedObjectAsBytes, err := stub.GetState(newTraceable.ID)
+	if err != nil {
+		return ccerror.Internal("Failed to get existed Object with ID: " + newTraceable.ID + ", error: " + err.Error())
+	} else if existedObjectAsBytes == nil {
+		return ccerror.NotFound("Object with ID " + newTraceable.ID + " does not exist")
+	}
+
+	oldTraceable := Traceable{}
+	err = json.Unmarshal(existedObjectAsBytes, &oldTraceable)
+	if err != nil {
+		return ccerror.Internal("Failed to get decode Traceable: " + err.Error())
+	}
+
+	result := t.checkParentCycle(stub, newTraceable)
+	if result.Status != shim.OK {
+		logger.Debug(stub, "end updateTraceable (failed)")
+		return result
+	}
+
+	if len(newTraceable.ParentID()) > 0 {
 		result = t.updateCompositeKey(
 			stub,
-			CK_PRODUCT_LOG,
-			[]string{oldLog.Product, oldLog.ID},
-			[]string{newLog.Product, newLog.ID})
+			CK_PARENT_CHILD,
+			[]string{oldTraceable.ParentID(), oldTraceable.ID},
+			[]string{newTraceable.ParentID(), newTraceable.ID})
 		if result.Status != shim.OK {
-			fmt.Println("- end updateLog (failed)")
+			logger.Debug(stub, "end updateTraceable (failed)")
 			return result
 		}
-	} else if len(oldLog.Product) > 0 {
-		result = t.deleteCompositeKey(stub, CK_PRODUCT_LOG, []string{oldLog.Product, oldLog.ID})
+	} else if len(oldTraceable.ParentID()) > 0 {
+		result = t.deleteCompositeKey(stub, CK_PARENT_CHILD, []string{oldTraceable.ParentID(), oldTraceable.ID})
 		if result.Status != shim.OK {
-			fmt.Println("- end updateLog (failed)")
+			logger.Debug(stub, "end updateTraceable (failed)")
 			return result
 		}
 	}
 
-	err = stub.PutState(newLog.ID, bytes)
+	result, bytes = checkVersion(newTraceable.ID, existedObjectAsBytes, bytes)
+	if result.Status != shim.OK {
+		logger.Debug(stub, "end updateTraceable (failed)")
+		return result
+	}
+
+	err = stub.PutState(newTraceable.ID, bytes)
 	if err != nil {
-		return shim.Error("Failed to update the object with ID: " + newLog.ID + ", error: " + err.Error())
+		return ccerror.Internal("Failed to update the object with ID: " + newTraceable.ID + ", error: " + err.Error())
+	}
+
+	return t.setChangeEvent(stub, newTraceableEvent(EVENT_TRACEABLE_UPDATED, &oldTraceable, newTraceable))
+}
+
+// checkParentCycle walks up from the parent of a Traceable and fails when
+// it reaches the Traceable again. The walk stops at a missing parent.
+func (t *FoodChaincode) checkParentCycle(stub shim.ChaincodeStubInterface, traceable Traceable) pb.Response {
+	visited := map[string]bool{}
+	for ID := traceable.ParentID(); len(ID) > 0 && !visited[ID]; {
+		if ID == traceable.ID {
+			return ccerror.WithDetails(ccerror.INVALID_REFERENCE,
+				"Parent "+traceable.ParentID()+" of "+traceable.ID+" is a descendant of it",
+				map[string]interface{}{"id": traceable.ID, "parent": traceable.ParentID()})
+		}
+		visited[ID] = true
+
+		result, parent := t.getTraceable(stub, ID)
+		if result.Status != shim.OK {
+			if ccerror.Parse(result).Code == ccerror.NOT_FOUND {
+				break
+			}
+			return result
+		}
+		ID = parent.ParentID()
 	}
 	return shim.Success(nil)
 }
 
+// putParentKey indexes a Traceable under its parent
+func (t *FoodChaincode) putParentKey(stub shim.ChaincodeStubInterface, traceable Traceable) pb.Response {
+	if len(traceable.ParentID()) == 0 {
+		return shim.Success(nil)
+	}
+	return t.putCompositeKey(stub, CK_PARENT_CHILD, []string{traceable.ParentID(), traceable.ID})
+}
+
+func (t *FoodChaincode) getTraceable(stub shim.ChaincodeStubInterface, ID string) (pb.Response, Traceable) {
+	traceable := Traceable{}
+	existedObjectAsBytes, err := stub.GetState(ID)
+	if err != nil {
+		return ccerror.Internal("Failed to get existed Object with ID: " + ID + ", error: " + err.Error()), traceable
+	} else if existedObjectAsBytes == nil {
+		return ccerror.NotFound("Object with ID " + ID + " does not exist"), traceable
+	}
+
+	err = json.Unmarshal(existedObjectAsBytes, &traceable)
+	if err != nil {
+		return ccerror.Internal("Failed to get decode object: " + err.Error()), traceable
+	}
+	if !isTraceableType(traceable.ObjectType) {
+		return ccerror.TypeMismatch("Object with ID: " + ID + " is not a Traceable"), traceable
+	}
+	return shim.Success(nil), traceable
+}
+
+func (t *FoodChaincode) getChildren(stub shim.ChaincodeStubInterface, ID string) (pb.Response, []Traceable) {
+	resultsIterator, err := stub.GetStateByPartialCompositeKey(CK_PARENT_CHILD, []string{ID})
+	if err != nil {
+		return ccerror.Internal(err.Error()), nil
+	}
+	defer resultsIterator.Close()
+
+	children := []Traceable{}
+	for resultsIterator.HasNext() {
+		responseRange, err := resultsIterator.Next()
+		if err != nil {
+			return ccerror.Internal(err.Error()), nil
+		}
+
+		_, compositeKeyParts, err := stub.SplitCompositeKey(responseRange.Key)
+		if err != nil {
+			return ccerror.Internal(err.Error()), nil
+		}
+		returnedChildID := compositeKeyParts[1]
+		logger.Debug(stub, "found a child", logging.Fields{"ID": returnedChildID})
+
+		result, child := t.getTraceable(stub, returnedChildID)
+		if result.Status != shim.OK {
+			return result, nil
+		}
+		children = append(children, child)
+	}
+
+	return shim.Success(nil), children
+}
+
+func (t *FoodChaincode) buildTraceableTree(stub shim.ChaincodeStubInterface, traceable Traceable, depth int, visited map[string]bool) (pb.Response, TraceableTree) {
+	tree := TraceableTree{Traceable: traceable, Children: []TraceableTree{}}
+	if depth == 0 {
+		return shim.Success(nil), tree
+	}
+
+	result, children := t.getChildren(stub, traceable.ID)
+	if result.Status != shim.OK {
+		return result, tree
+	}
+	for _, child := range children {
+		if visited[child.ID] {
+			continue
+		}
+		visited[child.ID] = true
+
+		result, childTree := t.buildTraceableTree(stub, child, depth-1, visited)
+		if result.Status != shim.OK {
+			return result, tree
+		}
+		tree.Children = append(tree.Children, childTree)
+	}
+	return shim.Success(nil), tree
+}
+
 func (t *FoodChaincode) putCompositeKey(stub shim.ChaincodeStubInterface, objectType string, values []string) pb.Response {
 	cKey, err := stub.CreateCompositeKey(objectType, values)
 	if err != nil {
-		return shim.Error("Failed to create composite key: " + err.Error())
+		return ccerror.Internal("Failed to create composite key: " + err.Error())
 	}
-	fmt.Println("save new composite key:", cKey)
+	logger.Debug(stub, "save new composite key", logging.Fields{"key": cKey})
 	err = stub.PutState(cKey, []byte{0x00})
 	if err != nil {
-		return shim.Error("Failed to save composite key: " + err.Error())
+		return ccerror.Internal("Failed to save composite key: " + err.Error())
 	}
 	return shim.Success(nil)
 }
@@ -798,7 +1366,7 @@ func (t *FoodChaincode) updateCompositeKey(stub shim.ChaincodeStubInterface, obj
 	}
 
 	if !valueChanged {
-		fmt.Println("Values don't change. Don't create new composite key")
+		logger.Debug(stub, "Values don't change. Don't create new composite key")
 		return shim.Success(nil)
 	}
 
@@ -813,51 +1381,103 @@ func (t *FoodChaincode) updateCompositeKey(stub shim.ChaincodeStubInterface, obj
 func (t *FoodChaincode) deleteCompositeKey(stub shim.ChaincodeStubInterface, objectType string, oldValues []string) pb.Response {
 	cKey, err := stub.CreateCompositeKey(objectType, oldValues)
 	if err != nil {
-		return shim.Error("Failed to create composite key: " + err.Error())
+		return ccerror.Internal("Failed to create composite key: " + err.Error())
 	}
 	err = stub.DelState(cKey)
 	if err != nil {
-		return shim.Error("Failed to delete composite key: " + err.Error())
+		return ccerror.Internal("Failed to delete composite key: " + err.Error())
 	}
-	fmt.Println("Deleted old composite key: ", cKey)
+	logger.Debug(stub, "Deleted old composite key", logging.Fields{"key": cKey})
 
 	return shim.Success(nil)
 }
 
+// parsePageSize returns the page size of a paged query, from 1 to
+// MAX_PAGE_SIZE
+func parsePageSize(pageSizeArg string) (pb.Response, int32) {
+	pageSize, err := strconv.ParseInt(pageSizeArg, 10, 32)
+	if err != nil || pageSize < 1 || pageSize > MAX_PAGE_SIZE {
+		return ccerror.ValidationFailed("Page size must be a number from 1 to " + strconv.Itoa(MAX_PAGE_SIZE)), 0
+	}
+	return shim.Success(nil), int32(pageSize)
+}
+
+// getLogsPage returns one page of the logs indexed by a composite key
+// together with the bookmark of the next page
+func (t *FoodChaincode) getLogsPage(stub shim.ChaincodeStubInterface, objectType string, ID string, pageSizeArg string, bookmark string) pb.Response {
+	result, pageSize := parsePageSize(pageSizeArg)
+	if result.Status != shim.OK {
+		return result
+	}
+
+	resultsIterator, metadata, err := stub.GetStateByPartialCompositeKeyWithPagination(objectType, []string{ID}, pageSize, bookmark)
+	if err != nil {
+		return ccerror.Internal(err.Error())
+	} else if resultsIterator == nil {
+		return ccerror.Internal("Paged query of " + objectType + " returned no iterator")
+	}
+	defer resultsIterator.Close()
+
+	page := LogsPage{Logs: []Log{}}
+	result, page.Logs = t.readLogsFromIterator(stub, resultsIterator)
+	if result.Status != shim.OK {
+		return result
+	}
+	if metadata != nil {
+		page.Bookmark = metadata.Bookmark
+		page.FetchedRecordsCount = metadata.FetchedRecordsCount
+	}
+	pageAsBytes, err := json.Marshal(page)
+	if err != nil {
+		return ccerror.Internal("Failed to get encode response: " + err.Error())
+	}
+
+	return shim.Success(pageAsBytes)
+}
+
 func (t *FoodChaincode) getLogsFromIterator(stub shim.ChaincodeStubInterface, resultsIterator shim.StateQueryIteratorInterface) (pb.Response, []byte) {
+	result, response := t.readLogsFromIterator(stub, resultsIterator)
+	if result.Status != shim.OK {
+		return result, nil
+	}
+
+	responseAsBytes, err := json.Marshal(response)
+	if err != nil {
+		return ccerror.Internal("Failed to get encode response: " + err.Error()), nil
+	}
+
+	return shim.Success(nil), responseAsBytes
+}
+
+func (t *FoodChaincode) readLogsFromIterator(stub shim.ChaincodeStubInterface, resultsIterator shim.StateQueryIteratorInterface) (pb.Response, []Log) {
 	response := []Log{}
 	var i int
 	for i = 0; resultsIterator.HasNext(); i++ {
 		responseRange, err := resultsIterator.Next()
 		if err != nil {
-			return shim.Error(err.Error()), nil
+			return ccerror.Internal(err.Error()), nil
 		}
 
 		_, compositeKeyParts, err := stub.SplitCompositeKey(responseRange.Key)
 		if err != nil {
-			return shim.Error(err.Error()), nil
+			return ccerror.Internal(err.Error()), nil
 		}
 		returnedLogID := compositeKeyParts[1]
-		fmt.Printf("- found an log:%s\n", returnedLogID)
+		logger.Debug(stub, "found a log", logging.Fields{"ID": returnedLogID})
 
 		logAsBytes, err := stub.GetState(returnedLogID)
 		if err != nil {
-			return shim.Error("Failed to get existed Log with ID: " + returnedLogID + ", error: " + err.Error()), nil
+			return ccerror.Internal("Failed to get existed Log with ID: " + returnedLogID + ", error: " + err.Error()), nil
 		} else if logAsBytes == nil {
-			return shim.Error("Log with ID " + returnedLogID + " does not exist"), nil
+			return ccerror.NotFound("Log with ID " + returnedLogID + " does not exist"), nil
 		}
 		log := Log{}
 		err = json.Unmarshal(logAsBytes, &log)
 		if err != nil {
-			return shim.Error("Failed to get decode log: " + err.Error()), nil
+			return ccerror.Internal("Failed to get decode log: " + err.Error()), nil
 		}
 		response = append(response, log)
 	}
 
-	responseAsBytes, err := json.Marshal(response)
-	if err != nil {
-		return shim.Error("Failed to get encode response: " + err.Error()), nil
-	}
-
-	return shim.Success(nil), responseAsBytes
+	return shim.Success(nil), response
 }
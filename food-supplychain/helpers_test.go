@@ -0,0 +1,126 @@
+package main
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/fabric/core/chaincode/lib/cid"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+const TEST_MSP = "Org1MSP"
+
+// mockIdentity replaces the client identity, which MockStub can not provide
+type mockIdentity struct {
+	mspID string
+	attrs map[string]string
+}
+
+func (m *mockIdentity) GetID() (string, error) {
+	return "x509::CN=user1::CN=ca." + m.mspID, nil
+}
+
+func (m *mockIdentity) GetMSPID() (string, error) {
+	return m.mspID, nil
+}
+
+func (m *mockIdentity) GetAttributeValue(attrName string) (string, bool, error) {
+	value, found := m.attrs[attrName]
+	return value, found, nil
+}
+
+func (m *mockIdentity) AssertAttributeValue(attrName, attrValue string) error {
+	if m.attrs[attrName] != attrValue {
+		return errors.New("Attribute '" + attrName + "' does not have value '" + attrValue + "'")
+	}
+	return nil
+}
+
+func (m *mockIdentity) GetX509Certificate() (*x509.Certificate, error) {
+	return &x509.Certificate{Subject: pkix.Name{CommonName: "user1", Organization: []string{m.mspID}}}, nil
+}
+
+func setMockIdentity(mspID string, attrs map[string]string) {
+	getClientIdentity = func(stub shim.ChaincodeStubInterface) (cid.ClientIdentity, error) {
+		return &mockIdentity{mspID: mspID, attrs: attrs}, nil
+	}
+}
+
+func checkInit(t *testing.T, stub *shim.MockStub, args [][]byte) {
+	// every test starts as a member of TEST_MSP
+	setMockIdentity(TEST_MSP, nil)
+
+	res := stub.MockInit("1", args)
+	if res.Status != shim.OK {
+		fmt.Println("Init failed", string(res.Message))
+		t.FailNow()
+	}
+}
+
+func checkTraceableData(t *testing.T, stub *shim.MockStub, value Traceable) {
+	// Check org
+	res := stub.MockInvoke("1", [][]byte{[]byte("getObject"), []byte(value.ID), []byte(value.ObjectType)})
+	if res.Status != shim.OK {
+		fmt.Println("failed", string(res.Message))
+		t.FailNow()
+	}
+	if res.Payload == nil {
+		fmt.Println("failed: no payload")
+		t.FailNow()
+	}
+
+	resData := Traceable{}
+	err := json.Unmarshal(res.Payload, &resData)
+	if err != nil {
+		fmt.Println("Failed to decode json:", err.Error())
+		t.FailNow()
+	}
+	// the data was just created, so it has the first version
+	value.Version = 1
+	value.SchemaVersion = SCHEMA_VERSION
+	if resData != value {
+		fmt.Println("Query value was not as expected")
+		t.FailNow()
+	}
+}
+
+func checkCreator(t *testing.T, creator Creator, mspID string) {
+	if creator.MSPID != mspID {
+		fmt.Println("Creator MSP", creator.MSPID, "was not", mspID)
+		t.FailNow()
+	}
+	if len(creator.Subject) == 0 || creator.Time == 0 {
+		fmt.Println("Creator was not recorded")
+		t.FailNow()
+	}
+}
+
+// testAccreditation lets an auditor audit everything until 2100
+var testAccreditation = Accreditation{Body: "Accreditation_Body", ValidTo: 4102444800}
+
+// checkCreateReferences creates the traceables and auditors which the logs
+// and audit actions of a test reference, keyed by ID
+func checkCreateReferences(t *testing.T, stub *shim.MockStub, references map[string]string) {
+	for ID, objectType := range references {
+		function := "createTraceable"
+		var object interface{} = Traceable{ObjectType: objectType, ID: ID, Name: ID}
+		if objectType == TYPE_AUDITOR {
+			function = "createAuditor"
+			object = Auditor{ObjectType: objectType, ID: ID, Name: ID, Accreditations: []Accreditation{testAccreditation}}
+		}
+		objectAsBytes, err := json.Marshal(object)
+		if err != nil {
+			fmt.Println("Failed to encode json")
+			t.FailNow()
+		}
+		res := stub.MockInvoke("1", [][]byte{[]byte(function), objectAsBytes})
+		if res.Status != shim.OK {
+			fmt.Println("failed", string(res.Message))
+			t.FailNow()
+		}
+	}
+}
@@ -68,6 +68,7 @@ func TestFood_GetLogHistory(t *testing.T) {
 		Asset:      "Asset_1",
 		Product:    "Product_2",
 		Location:   "Location_1",
+		Version:    1,
 	}
 	updatedLogAsBytes, err := json.Marshal(updatedLog)
 	if err != nil {
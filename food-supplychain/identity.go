@@ -0,0 +1,42 @@
+package main
+
+import (
+	"github.com/deevotech/sc-chaincode.deevo.io/lib/ccconfig"
+	"github.com/deevotech/sc-chaincode.deevo.io/lib/ccerror"
+	"github.com/hyperledger/fabric/core/chaincode/lib/cid"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+// getClientIdentity returns the identity of the client which submitted the
+// transaction. It is a variable so tests can replace it.
+var getClientIdentity = func(stub shim.ChaincodeStubInterface) (cid.ClientIdentity, error) {
+	return cid.New(stub)
+}
+
+// getCreator returns the MSP ID and certificate subject of the submitting
+// client together with the transaction timestamp
+func (t *FoodChaincode) getCreator(stub shim.ChaincodeStubInterface) (pb.Response, Creator) {
+	creator := Creator{}
+
+	identity, err := getClientIdentity(stub)
+	if err != nil {
+		return ccerror.Internal("Failed to get client identity: " + err.Error()), creator
+	}
+
+	// a Creator has the shape of the Updater of a config
+	updater, err := ccconfig.NewUpdater(stub, identity)
+	if err != nil {
+		return ccerror.Internal(err.Error()), creator
+	}
+	return shim.Success(nil), Creator(updater)
+}
+
+// checkCreatorMSP rejects changes to an object created by another MSP.
+// Objects stored before creators were recorded can be changed by anyone.
+func (t *FoodChaincode) checkCreatorMSP(ID string, original Creator, current Creator) pb.Response {
+	if len(original.MSPID) > 0 && original.MSPID != current.MSPID {
+		return ccerror.Forbidden("Object with ID " + ID + " was created by " + original.MSPID + " and can not be changed by " + current.MSPID)
+	}
+	return shim.Success(nil)
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+// logKeyIndexes are the indexes built from several fields of a log
+var logKeyIndexes = []string{CK_SC_TIME_LOG, CK_PRODUCT_TIME_LOG, CK_LOCATION_LOG, CK_GEOHASH_LOG}
+
+// updateLogKeys maintains the logKeyIndexes of a log. oldLog is nil for
+// created logs, newLog is nil for deleted logs.
+func (t *FoodChaincode) updateLogKeys(stub shim.ChaincodeStubInterface, oldLog *Log, newLog *Log) pb.Response {
+	for _, index := range logKeyIndexes {
+		oldKeys := logKeyValues(index, oldLog)
+		newKeys := logKeyValues(index, newLog)
+
+		for _, oldValues := range oldKeys {
+			if !containsKey(newKeys, oldValues) {
+				result := t.deleteCompositeKey(stub, index, oldValues)
+				if result.Status != shim.OK {
+					return result
+				}
+			}
+		}
+		for _, newValues := range newKeys {
+			if !containsKey(oldKeys, newValues) {
+				result := t.putCompositeKey(stub, index, newValues)
+				if result.Status != shim.OK {
+					return result
+				}
+			}
+		}
+	}
+	return shim.Success(nil)
+}
+
+// logKeyValues returns the attributes of the composite keys of a log in an
+// index, nil when the log is not part of the index. A log has one key per
+// product in CK_PRODUCT_TIME_LOG.
+func logKeyValues(index string, log *Log) [][]string {
+	if log == nil {
+		return nil
+	}
+
+	if index == CK_SC_TIME_LOG {
+		if len(log.Supplychain) == 0 {
+			return nil
+		}
+		return [][]string{{log.Supplychain, timeKeyPart(log.Time), log.ID}}
+	}
+
+	if index == CK_PRODUCT_TIME_LOG {
+		keys := [][]string{}
+		for _, product := range logProducts(*log) {
+			keys = append(keys, []string{product, timeKeyPart(log.Time), log.ID})
+		}
+		return keys
+	}
+
+	if index == CK_LOCATION_LOG {
+		if log.GeoLocation == nil || len(log.GeoLocation.FacilityID) == 0 {
+			return nil
+		}
+		return [][]string{{log.GeoLocation.FacilityID, log.ID}}
+	}
+
+	if index == CK_GEOHASH_LOG {
+		if !log.GeoLocation.HasCoordinates() {
+			return nil
+		}
+		// one attribute per character, so a partial key is a geohash prefix
+		geohash := encodeGeohash(*log.GeoLocation.Latitude, *log.GeoLocation.Longitude, GEOHASH_PRECISION)
+		values := []string{}
+		for _, c := range geohash {
+			values = append(values, string(c))
+		}
+		return [][]string{append(values, log.ID)}
+	}
+
+	return nil
+}
+
+// logProducts returns Product and Products of a log without empty or
+// repeated IDs
+func logProducts(log Log) []string {
+	products := []string{}
+	seen := map[string]bool{}
+	for _, product := range append([]string{log.Product}, log.Products...) {
+		if len(product) > 0 && !seen[product] {
+			seen[product] = true
+			products = append(products, product)
+		}
+	}
+	return products
+}
+
+// containsKey reports whether keys holds the attributes of a composite key
+func containsKey(keys [][]string, values []string) bool {
+	for _, key := range keys {
+		if sameStrings(key, values) {
+			return true
+		}
+	}
+	return false
+}
+
+// timeKeyPart formats a log time so composite keys sort by time. The sign
+// bit is flipped, so times before 1970 sort first as well.
+func timeKeyPart(logTime int64) string {
+	return fmt.Sprintf("%020d", uint64(logTime)^(1<<63))
+}
@@ -105,7 +105,7 @@ func checkInitAuditor(t *testing.T, stub *shim.MockStub, value Auditor) {
 		fmt.Println("Failed to decode json:", err.Error())
 		t.FailNow()
 	}
-	if resData != value {
+	if !resData.Equals(value) {
 		fmt.Println("Query value was not as expected")
 		t.FailNow()
 	}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/deevotech/sc-chaincode.deevo.io/lib/ccerror"
+	"github.com/deevotech/sc-chaincode.deevo.io/lib/logging"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+// checkLogReferences checks that the supply chain, products and Ref entries
+// of a Log exist with the expected types. pending holds the types of objects
+// created earlier in the same transaction.
+func (t *FoodChaincode) checkLogReferences(stub shim.ChaincodeStubInterface, log Log, pending map[string]string) pb.Response {
+	fieldErrors := []string{}
+
+	if len(log.Supplychain) > 0 {
+		result, fieldError := t.checkReference(stub, "supplychain_id", log.Supplychain, pending, TYPE_SUPPLYCHAIN)
+		if result.Status != shim.OK {
+			return result
+		}
+		fieldErrors = appendFieldError(fieldErrors, fieldError)
+	}
+	if len(log.Product) > 0 {
+		result, fieldError := t.checkReference(stub, "product", log.Product, pending, TYPE_PRODUCT)
+		if result.Status != shim.OK {
+			return result
+		}
+		fieldErrors = appendFieldError(fieldErrors, fieldError)
+	}
+	for i, product := range log.Products {
+		if len(product) == 0 {
+			continue
+		}
+		result, fieldError := t.checkReference(stub, "products["+strconv.Itoa(i)+"]", product, pending, TYPE_PRODUCT)
+		if result.Status != shim.OK {
+			return result
+		}
+		fieldErrors = appendFieldError(fieldErrors, fieldError)
+	}
+	for i, ref := range log.Ref {
+		if len(ref) == 0 {
+			continue
+		}
+		result, fieldError := t.checkReference(stub, "ref["+strconv.Itoa(i)+"]", ref, pending, "")
+		if result.Status != shim.OK {
+			return result
+		}
+		fieldErrors = appendFieldError(fieldErrors, fieldError)
+	}
+
+	return t.reportReferences(stub, "Log "+log.ID, fieldErrors)
+}
+
+// checkAuditActionReferences checks that the auditor and the audited object
+// of an AuditAction exist
+func (t *FoodChaincode) checkAuditActionReferences(stub shim.ChaincodeStubInterface, auditAction AuditAction, pending map[string]string) pb.Response {
+	fieldErrors := []string{}
+
+	result, fieldError := t.checkReference(stub, "auditor", auditAction.Auditor, pending, TYPE_AUDITOR)
+	if result.Status != shim.OK {
+		return result
+	}
+	fieldErrors = appendFieldError(fieldErrors, fieldError)
+
+	result, fieldError = t.checkReference(stub, "objectID", auditAction.ObjectID, pending, "*")
+	if result.Status != shim.OK {
+		return result
+	}
+	fieldErrors = appendFieldError(fieldErrors, fieldError)
+
+	return t.reportReferences(stub, "AuditAction "+auditAction.ID, fieldErrors)
+}
+
+// checkReference returns a field error when the referenced object is missing
+// or has another type. An empty expected type accepts any Traceable, "*"
+// accepts every type.
+func (t *FoodChaincode) checkReference(stub shim.ChaincodeStubInterface, field string, ID string, pending map[string]string, expected string) (pb.Response, string) {
+	objectType, ok := pending[ID]
+	if !ok {
+		objectAsBytes, err := stub.GetState(ID)
+		if err != nil {
+			return ccerror.Internal("Failed to get existed Object with ID: " + ID + ", error: " + err.Error()), ""
+		} else if objectAsBytes == nil {
+			return shim.Success(nil), field + ": " + ID + " does not exist"
+		}
+
+		liteModel := LiteModel{}
+		err = json.Unmarshal(objectAsBytes, &liteModel)
+		if err != nil {
+			return ccerror.Internal("Failed to get decode object: " + err.Error()), ""
+		}
+		objectType = liteModel.ObjectType
+	}
+
+	if expected == "*" {
+		return shim.Success(nil), ""
+	} else if expected == "" {
+		if !isTraceableType(objectType) {
+			return shim.Success(nil), field + ": " + ID + " is a " + objectType + ", expected a Traceable"
+		}
+	} else if objectType != expected {
+		return shim.Success(nil), field + ": " + ID + " is a " + objectType + ", expected a " + expected
+	}
+	return shim.Success(nil), ""
+}
+
+// reportReferences fails with all field errors in strict mode. In lenient
+// mode the errors are only printed, for loads of legacy data.
+func (t *FoodChaincode) reportReferences(stub shim.ChaincodeStubInterface, name string, fieldErrors []string) pb.Response {
+	if len(fieldErrors) == 0 {
+		return shim.Success(nil)
+	}
+
+	result, mode := t.readIntegrityMode(stub)
+	if result.Status != shim.OK {
+		return result
+	}
+	if mode == INTEGRITY_LENIENT {
+		logger.Warning(stub, "invalid references accepted", logging.Fields{"object": name, "fields": fieldErrors})
+		return shim.Success(nil)
+	}
+	return ccerror.WithDetails(ccerror.INVALID_REFERENCE, "Invalid references of "+name+": "+strings.Join(fieldErrors, "; "),
+		map[string]interface{}{"fields": fieldErrors})
+}
+
+func appendFieldError(fieldErrors []string, fieldError string) []string {
+	if len(fieldError) > 0 {
+		return append(fieldErrors, fieldError)
+	}
+	return fieldErrors
+}
+
+// readIntegrityMode returns the mode of the config, lenient by default
+func (t *FoodChaincode) readIntegrityMode(stub shim.ChaincodeStubInterface) (pb.Response, string) {
+	result, config := t.readConfig(stub)
+	return result, config.IntegrityMode
+}
+
+func (t *FoodChaincode) setIntegrityMode(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	logger.Debug(stub, "start setIntegrityMode")
+
+	mode := args[0]
+	if mode != INTEGRITY_STRICT && mode != INTEGRITY_LENIENT {
+		return ccerror.ValidationFailed("Integrity mode must be " + INTEGRITY_STRICT + " or " + INTEGRITY_LENIENT)
+	}
+
+	// the mode is changed in the config, so it is part of its history
+	result, config := t.readConfig(stub)
+	if result.Status != shim.OK {
+		return result
+	}
+	config.IntegrityMode = mode
+	result = t.putConfig(stub, config, config.Version+1)
+	if result.Status != shim.OK {
+		return result
+	}
+
+	logger.Debug(stub, "end setIntegrityMode (success)")
+	return shim.Success(nil)
+}
+
+func (t *FoodChaincode) getIntegrityMode(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	logger.Debug(stub, "start getIntegrityMode")
+
+	result, mode := t.readIntegrityMode(stub)
+	if result.Status != shim.OK {
+		return result
+	}
+
+	logger.Debug(stub, "end getIntegrityMode (success)")
+	return shim.Success([]byte(mode))
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+func checkSetIntegrityMode(t *testing.T, stub *shim.MockStub, mode string) {
+	setMockIdentity(TEST_MSP, map[string]string{ADMIN_ATTRIBUTE: "true"})
+	res := stub.MockInvoke("1", [][]byte{[]byte("setIntegrityMode"), []byte(mode)})
+	setMockIdentity(TEST_MSP, nil)
+	if res.Status != shim.OK {
+		fmt.Println("failed", string(res.Message))
+		t.FailNow()
+	}
+
+	res = stub.MockInvoke("1", [][]byte{[]byte("getIntegrityMode")})
+	if string(res.Payload) != mode {
+		fmt.Println("Integrity mode was not", mode)
+		t.FailNow()
+	}
+}
+
+func TestFood_LogReferences(t *testing.T) {
+	scc := new(FoodChaincode)
+	stub := shim.NewMockStub("food", scc)
+
+	checkInit(t, stub, [][]byte{})
+	checkSetIntegrityMode(t, stub, INTEGRITY_STRICT)
+	checkCreateReferences(t, stub, map[string]string{"sc_1": TYPE_SUPPLYCHAIN, "Auditor_1": TYPE_AUDITOR})
+
+	newLog := Log{
+		ObjectType:  TYPE_LOG,
+		ID:          "Log_1",
+		Time:        time.Now().Unix(),
+		Ref:         []string{"Auditor_1"},
+		CTE:         "shipping",
+		Supplychain: "sc_1",
+		Product:     "Product_1",
+	}
+	newLogAsBytes, err := json.Marshal(newLog)
+	if err != nil {
+		fmt.Println("Failed to encode json")
+		t.FailNow()
+	}
+	res := stub.MockInvoke("1", [][]byte{[]byte("createLog"), newLogAsBytes})
+	if res.Status == shim.OK {
+		fmt.Println("createLog should fail with invalid references")
+		t.FailNow()
+	}
+	if !strings.Contains(res.Message, "product: Product_1 does not exist") ||
+		!strings.Contains(res.Message, "ref[0]: Auditor_1 is a auditor, expected a Traceable") ||
+		strings.Contains(res.Message, "supplychain_id") {
+		fmt.Println("Reference errors were not as expected", res.Message)
+		t.FailNow()
+	}
+
+	// legacy data is accepted in lenient mode
+	checkSetIntegrityMode(t, stub, INTEGRITY_LENIENT)
+	res = stub.MockInvoke("1", [][]byte{[]byte("createLog"), newLogAsBytes})
+	if res.Status != shim.OK {
+		fmt.Println("failed", string(res.Message))
+		t.FailNow()
+	}
+}
+
+func TestFood_AuditActionReferences(t *testing.T) {
+	scc := new(FoodChaincode)
+	stub := shim.NewMockStub("food", scc)
+
+	checkInit(t, stub, [][]byte{})
+	checkSetIntegrityMode(t, stub, INTEGRITY_STRICT)
+	checkCreateReferences(t, stub, map[string]string{"Product_1": TYPE_PRODUCT})
+
+	newAuditAction := AuditAction{
+		ObjectType: TYPE_AUDITACTION,
+		ID:         "AuditAction_1",
+		Auditor:    "Product_1",
+		Time:       time.Now().Unix(),
+		ObjectID:   "Product_2",
+	}
+	newAuditActionAsBytes, err := json.Marshal(newAuditAction)
+	if err != nil {
+		fmt.Println("Failed to encode json")
+		t.FailNow()
+	}
+	res := stub.MockInvoke("1", [][]byte{[]byte("createAuditAction"), newAuditActionAsBytes})
+	if res.Status == shim.OK {
+		fmt.Println("createAuditAction should fail with invalid references")
+		t.FailNow()
+	}
+	if !strings.Contains(res.Message, "auditor: Product_1 is a product, expected a auditor") ||
+		!strings.Contains(res.Message, "objectID: Product_2 does not exist") {
+		fmt.Println("Reference errors were not as expected", res.Message)
+		t.FailNow()
+	}
+}
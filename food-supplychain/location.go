@@ -0,0 +1,222 @@
+package main
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+
+	"github.com/deevotech/sc-chaincode.deevo.io/lib/ccerror"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+const geohashBase32 = "0123456789bcdefghjkmnpqrstuvwxyz"
+
+// checkGeoLocation validates the optional structured location of a Log
+func checkGeoLocation(log Log) pb.Response {
+	location := log.GeoLocation
+	if location == nil {
+		return shim.Success(nil)
+	}
+	if (location.Latitude == nil) != (location.Longitude == nil) {
+		return ccerror.ValidationFailed("geoLocation: lat and lon must be set together")
+	}
+	if location.HasCoordinates() {
+		if *location.Latitude < -90 || *location.Latitude > 90 {
+			return ccerror.ValidationFailed("geoLocation: lat must be between -90 and 90")
+		}
+		if *location.Longitude < -180 || *location.Longitude > 180 {
+			return ccerror.ValidationFailed("geoLocation: lon must be between -180 and 180")
+		}
+	} else if len(location.FacilityID) == 0 {
+		return ccerror.ValidationFailed("geoLocation: facilityId or lat and lon are required")
+	}
+	return shim.Success(nil)
+}
+
+// getLogsOfFacility returns the logs with the facility in their GeoLocation
+func (t *FoodChaincode) getLogsOfFacility(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	logger.Debug(stub, "start getLogsOfFacility")
+	result, logs := t.getLogsByCompositeKey(stub, CK_LOCATION_LOG, args[0])
+	if result.Status != shim.OK {
+		return result
+	}
+	sortLogsByTime(logs)
+
+	logsAsBytes, err := json.Marshal(logs)
+	if err != nil {
+		return ccerror.Internal("Failed to get encode response: " + err.Error())
+	}
+
+	logger.Debug(stub, "end getLogsOfFacility (success)")
+	return shim.Success(logsAsBytes)
+}
+
+// getLogsInBoundingBox returns the logs located inside a box, sorted by
+// time. Arguments are the minimum latitude and longitude followed by the
+// maximum ones. The box is covered by geohash prefixes, the logs found
+// under them are filtered by their exact position.
+func (t *FoodChaincode) getLogsInBoundingBox(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	logger.Debug(stub, "start getLogsInBoundingBox")
+
+	bounds := []float64{}
+	for _, arg := range args {
+		bound, err := strconv.ParseFloat(arg, 64)
+		if err != nil {
+			return ccerror.ValidationFailed("Bounds must be numbers, got " + arg)
+		}
+		bounds = append(bounds, bound)
+	}
+	minLat, minLon, maxLat, maxLon := bounds[0], bounds[1], bounds[2], bounds[3]
+	if minLat < -90 || maxLat > 90 || minLat > maxLat {
+		return ccerror.ValidationFailed("Latitudes must be between -90 and 90, the minimum first")
+	}
+	if minLon < -180 || maxLon > 180 || minLon > maxLon {
+		return ccerror.ValidationFailed("Longitudes must be between -180 and 180, the minimum first")
+	}
+
+	logs := []Log{}
+	for _, prefix := range geohashCover(minLat, minLon, maxLat, maxLon) {
+		attributes := []string{}
+		for _, c := range prefix {
+			attributes = append(attributes, string(c))
+		}
+
+		resultsIterator, err := stub.GetStateByPartialCompositeKey(CK_GEOHASH_LOG, attributes)
+		if err != nil {
+			return ccerror.Internal(err.Error())
+		}
+		for resultsIterator.HasNext() {
+			responseRange, err := resultsIterator.Next()
+			if err != nil {
+				resultsIterator.Close()
+				return ccerror.Internal(err.Error())
+			}
+			_, compositeKeyParts, err := stub.SplitCompositeKey(responseRange.Key)
+			if err != nil {
+				resultsIterator.Close()
+				return ccerror.Internal(err.Error())
+			}
+
+			logID := compositeKeyParts[len(compositeKeyParts)-1]
+			logAsBytes, err := stub.GetState(logID)
+			if err != nil {
+				resultsIterator.Close()
+				return ccerror.Internal("Failed to get existed Log with ID: " + logID + ", error: " + err.Error())
+			} else if logAsBytes == nil {
+				resultsIterator.Close()
+				return ccerror.NotFound("Log with ID " + logID + " does not exist")
+			}
+			log := Log{}
+			err = json.Unmarshal(logAsBytes, &log)
+			if err != nil {
+				resultsIterator.Close()
+				return ccerror.Internal("Failed to get decode log: " + err.Error())
+			}
+
+			lat, lon := *log.GeoLocation.Latitude, *log.GeoLocation.Longitude
+			if lat >= minLat && lat <= maxLat && lon >= minLon && lon <= maxLon {
+				logs = append(logs, log)
+			}
+		}
+		resultsIterator.Close()
+	}
+	sortLogsByTime(logs)
+
+	logsAsBytes, err := json.Marshal(logs)
+	if err != nil {
+		return ccerror.Internal("Failed to get encode response: " + err.Error())
+	}
+
+	logger.Debug(stub, "end getLogsInBoundingBox (success)")
+	return shim.Success(logsAsBytes)
+}
+
+func sortLogsByTime(logs []Log) {
+	sort.SliceStable(logs, func(i, j int) bool {
+		if logs[i].Time != logs[j].Time {
+			return logs[i].Time < logs[j].Time
+		}
+		return logs[i].ID < logs[j].ID
+	})
+}
+
+// geohashCover returns the geohashes of the cells which cover a box, at the
+// highest precision which needs at most MAX_GEOHASH_CELLS cells
+func geohashCover(minLat, minLon, maxLat, maxLon float64) []string {
+	for precision := GEOHASH_PRECISION; precision > 1; precision-- {
+		x0, y0 := geohashCell(minLat, minLon, precision)
+		x1, y1 := geohashCell(maxLat, maxLon, precision)
+		if (x1-x0+1)*(y1-y0+1) > MAX_GEOHASH_CELLS {
+			continue
+		}
+
+		cells := []string{}
+		for x := x0; x <= x1; x++ {
+			for y := y0; y <= y1; y++ {
+				cells = append(cells, geohashOfCell(x, y, precision))
+			}
+		}
+		return cells
+	}
+
+	// 32 cells cover the world at precision 1
+	cells := []string{}
+	for _, c := range geohashBase32 {
+		cells = append(cells, string(c))
+	}
+	return cells
+}
+
+func encodeGeohash(lat, lon float64, precision int) string {
+	x, y := geohashCell(lat, lon, precision)
+	return geohashOfCell(x, y, precision)
+}
+
+// geohashBits returns the number of longitude and latitude bits of a
+// geohash, bits alternate starting with the longitude
+func geohashBits(precision int) (uint, uint) {
+	total := uint(5 * precision)
+	return (total + 1) / 2, total / 2
+}
+
+// geohashCell returns the column and row of the cell containing a point
+func geohashCell(lat, lon float64, precision int) (uint64, uint64) {
+	lonBits, latBits := geohashBits(precision)
+	columns := uint64(1) << lonBits
+	rows := uint64(1) << latBits
+
+	x := uint64((lon + 180) / 360 * float64(columns))
+	y := uint64((lat + 90) / 180 * float64(rows))
+	if x >= columns {
+		x = columns - 1
+	}
+	if y >= rows {
+		y = rows - 1
+	}
+	return x, y
+}
+
+func geohashOfCell(x, y uint64, precision int) string {
+	lonBits, latBits := geohashBits(precision)
+	geohash := make([]byte, 0, precision)
+	value, bits := 0, 0
+	for i := 0; i < 5*precision; i++ {
+		var bit uint64
+		if i%2 == 0 {
+			lonBits--
+			bit = (x >> lonBits) & 1
+		} else {
+			latBits--
+			bit = (y >> latBits) & 1
+		}
+		value = value<<1 | int(bit)
+		bits++
+		if bits == 5 {
+			geohash = append(geohash, geohashBase32[value])
+			value, bits = 0, 0
+		}
+	}
+	return string(geohash)
+}
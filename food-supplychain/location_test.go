@@ -0,0 +1,142 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+func newGeoLocation(facilityID string, lat float64, lon float64) *GeoLocation {
+	return &GeoLocation{FacilityID: facilityID, Latitude: &lat, Longitude: &lon}
+}
+
+func initLocationData(t *testing.T, stub *shim.MockStub) {
+	checkInit(t, stub, [][]byte{})
+
+	locations := map[string]*GeoLocation{
+		"Log_1": newGeoLocation("0614141000012", 52.370216, 4.895168), // Amsterdam
+		"Log_2": newGeoLocation("0614141000029", 51.924420, 4.477733), // Rotterdam
+		"Log_3": newGeoLocation("0614141000012", 52.370216, 4.895168),
+		"Log_4": newGeoLocation("0614141000036", 48.856613, 2.352222), // Paris
+		"Log_5": {FacilityID: "0614141000029"},
+	}
+	times := map[string]int64{"Log_1": 100, "Log_2": 200, "Log_3": 300, "Log_4": 400, "Log_5": 500}
+	for ID, location := range locations {
+		newLog := Log{ObjectType: TYPE_LOG, ID: ID, Time: times[ID], Ref: []string{}, CTE: "receiving", GeoLocation: location}
+		checkCreateLogAt(t, stub, newLog)
+	}
+}
+
+func checkCreateLogAt(t *testing.T, stub *shim.MockStub, newLog Log) {
+	newLogAsBytes, err := json.Marshal(newLog)
+	if err != nil {
+		fmt.Println("Failed to encode json")
+		t.FailNow()
+	}
+	res := stub.MockInvoke("1", [][]byte{[]byte("createLog"), newLogAsBytes})
+	if res.Status != shim.OK {
+		fmt.Println("failed", string(res.Message))
+		t.FailNow()
+	}
+}
+
+func TestFood_EncodeGeohash(t *testing.T) {
+	geohash := encodeGeohash(57.64911, 10.40744, GEOHASH_PRECISION)
+	if geohash != "u4pruydqq" {
+		fmt.Println("Geohash", geohash, "was not u4pruydqq")
+		t.FailNow()
+	}
+}
+
+func TestFood_GetLogsOfFacility(t *testing.T) {
+	scc := new(FoodChaincode)
+	stub := shim.NewMockStub("food", scc)
+
+	initLocationData(t, stub)
+
+	res := stub.MockInvoke("1", [][]byte{[]byte("getLogsOfFacility"), []byte("0614141000029")})
+	if res.Status != shim.OK {
+		fmt.Println("failed", string(res.Message))
+		t.FailNow()
+	}
+	logs := []Log{}
+	json.Unmarshal(res.Payload, &logs)
+	checkLogIDs(t, logs, []string{"Log_2", "Log_5"})
+}
+
+func TestFood_GetLogsInBoundingBox(t *testing.T) {
+	scc := new(FoodChaincode)
+	stub := shim.NewMockStub("food", scc)
+
+	initLocationData(t, stub)
+
+	// the Netherlands
+	logs := checkLogsInBoundingBox(t, stub, "50.75", "3.36", "53.55", "7.23")
+	checkLogIDs(t, logs, []string{"Log_1", "Log_2", "Log_3"})
+
+	// Amsterdam only
+	logs = checkLogsInBoundingBox(t, stub, "52.3", "4.8", "52.4", "5.0")
+	checkLogIDs(t, logs, []string{"Log_1", "Log_3"})
+
+	// the whole world
+	logs = checkLogsInBoundingBox(t, stub, "-90", "-180", "90", "180")
+	checkLogIDs(t, logs, []string{"Log_1", "Log_2", "Log_3", "Log_4"})
+
+	// moving a log updates the index
+	movedLog := Log{ObjectType: TYPE_LOG, ID: "Log_3", Time: 300, Ref: []string{}, CTE: "receiving", GeoLocation: newGeoLocation("0614141000036", 48.856613, 2.352222), Version: 1}
+	movedLogAsBytes, err := json.Marshal(movedLog)
+	if err != nil {
+		fmt.Println("Failed to encode json")
+		t.FailNow()
+	}
+	res := stub.MockInvoke("1", [][]byte{[]byte("updateLog"), movedLogAsBytes})
+	if res.Status != shim.OK {
+		fmt.Println("failed", string(res.Message))
+		t.FailNow()
+	}
+	logs = checkLogsInBoundingBox(t, stub, "52.3", "4.8", "52.4", "5.0")
+	checkLogIDs(t, logs, []string{"Log_1"})
+
+	res = stub.MockInvoke("1", [][]byte{[]byte("getLogsInBoundingBox"), []byte("53"), []byte("3"), []byte("50"), []byte("7")})
+	if res.Status == shim.OK {
+		fmt.Println("getLogsInBoundingBox should fail when the minimum is above the maximum")
+		t.FailNow()
+	}
+}
+
+func TestFood_CreateLogWithInvalidGeoLocation(t *testing.T) {
+	scc := new(FoodChaincode)
+	stub := shim.NewMockStub("food", scc)
+
+	checkInit(t, stub, [][]byte{})
+
+	lat := 52.370216
+	newLog := Log{ObjectType: TYPE_LOG, ID: "Log_1", Time: 100, Ref: []string{}, CTE: "receiving", GeoLocation: &GeoLocation{Latitude: &lat}}
+	newLogAsBytes, err := json.Marshal(newLog)
+	if err != nil {
+		fmt.Println("Failed to encode json")
+		t.FailNow()
+	}
+	res := stub.MockInvoke("1", [][]byte{[]byte("createLog"), newLogAsBytes})
+	if res.Status == shim.OK {
+		fmt.Println("createLog should fail without lon")
+		t.FailNow()
+	}
+}
+
+func checkLogsInBoundingBox(t *testing.T, stub *shim.MockStub, minLat string, minLon string, maxLat string, maxLon string) []Log {
+	res := stub.MockInvoke("1", [][]byte{[]byte("getLogsInBoundingBox"), []byte(minLat), []byte(minLon), []byte(maxLat), []byte(maxLon)})
+	if res.Status != shim.OK {
+		fmt.Println("failed", string(res.Message))
+		t.FailNow()
+	}
+	logs := []Log{}
+	err := json.Unmarshal(res.Payload, &logs)
+	if err != nil {
+		fmt.Println("Failed to decode json of Logs:", err.Error())
+		t.FailNow()
+	}
+	return logs
+}
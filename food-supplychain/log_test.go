@@ -3,10 +3,15 @@ package main
 import (
 	"encoding/json"
 	"fmt"
+	"strconv"
 	"testing"
 	"time"
 
+	"github.com/deevotech/sc-chaincode.deevo.io/lib/ccerror"
+
 	"github.com/hyperledger/fabric/core/chaincode/shim"
+	"github.com/hyperledger/fabric/protos/ledger/queryresult"
+	pb "github.com/hyperledger/fabric/protos/peer"
 )
 
 func TestFood_CreateLogs(t *testing.T) {
@@ -105,12 +110,13 @@ func TestFood_UpdateLog(t *testing.T) {
 		ObjectType: TYPE_LOG,
 		ID:         "Log_1",
 		Time:       time.Now().Unix(),
-		Ref:        []string{"Product_1", "product_2"},
+		Ref:        []string{"Product_1", "Product_2"},
 		CTE:        "test_action",
 		Content:    "Log 2",
 		Asset:      "Asset_1",
 		Product:    "Product_2",
 		Location:   "Location_1",
+		Version:    1,
 	}
 	updatedLogAsBytes, err := json.Marshal(updatedLog)
 	if err != nil {
@@ -120,6 +126,202 @@ func TestFood_UpdateLog(t *testing.T) {
 	checkUpdateLog(t, stub, updatedLogAsBytes, updatedLog, newLog)
 }
 
+func TestFood_UpdateLogFromOtherMSP(t *testing.T) {
+	scc := new(FoodChaincode)
+	stub := shim.NewMockStub("food", scc)
+
+	checkInit(t, stub, [][]byte{})
+
+	newSupplychain := Traceable{ObjectType: TYPE_SUPPLYCHAIN, ID: "sc_1", Name: "supplychain 1"}
+	newSupplychainAsBytes, err := json.Marshal(newSupplychain)
+	if err != nil {
+		fmt.Println("Failed to encode json")
+		t.FailNow()
+	}
+	checkCreateTraceable(t, stub, newSupplychainAsBytes, newSupplychain)
+
+	newProduct := Traceable{ObjectType: TYPE_PRODUCT, ID: "Product_1", Name: "Product 1"}
+	newProductAsBytes, err := json.Marshal(newProduct)
+	if err != nil {
+		fmt.Println("Failed to encode json")
+		t.FailNow()
+	}
+	checkCreateTraceable(t, stub, newProductAsBytes, newProduct)
+
+	newLog := Log{
+		ObjectType:  TYPE_LOG,
+		ID:          "Log_1",
+		Time:        time.Now().Unix(),
+		CTE:         "test_action",
+		Supplychain: "sc_1",
+		Product:     "Product_1",
+		// the creator is set by the chaincode, not by the client
+		Creator: Creator{MSPID: "Org2MSP"},
+	}
+	newLogAsBytes, err := json.Marshal(newLog)
+	if err != nil {
+		fmt.Println("Failed to encode json")
+		t.FailNow()
+	}
+	checkCreateLog(t, stub, newLogAsBytes, newLog)
+
+	setMockIdentity("Org2MSP", nil)
+	newLog.Content = "Log 2"
+	newLog.Version = 1
+	newLogAsBytes, err = json.Marshal(newLog)
+	if err != nil {
+		fmt.Println("Failed to encode json")
+		t.FailNow()
+	}
+	res := stub.MockInvoke("1", [][]byte{[]byte("updateLog"), newLogAsBytes})
+	if res.Status == shim.OK {
+		fmt.Println("Update from another MSP should be rejected")
+		t.FailNow()
+	}
+}
+
+func TestFood_GetLogsPageWithInvalidPageSize(t *testing.T) {
+	scc := new(FoodChaincode)
+	stub := shim.NewMockStub("food", scc)
+
+	checkInit(t, stub, [][]byte{})
+
+	newSupplychain := Traceable{ObjectType: TYPE_SUPPLYCHAIN, ID: "sc_1", Name: "supplychain 1"}
+	newSupplychainAsBytes, err := json.Marshal(newSupplychain)
+	if err != nil {
+		fmt.Println("Failed to encode json")
+		t.FailNow()
+	}
+	checkCreateTraceable(t, stub, newSupplychainAsBytes, newSupplychain)
+
+	res := stub.MockInvoke("1", [][]byte{[]byte("getLogsOfSupplychain"), []byte("sc_1"), []byte("0"), []byte("")})
+	if res.Status == shim.OK {
+		fmt.Println("Page size 0 should be rejected")
+		t.FailNow()
+	}
+}
+
+func TestFood_GetLogsPage(t *testing.T) {
+	scc := new(FoodChaincode)
+	stub := shim.NewMockStub("food", scc)
+
+	initRangeData(t, stub)
+
+	res := stub.MockInvoke("1", [][]byte{[]byte("getLogsOfSupplychain"), []byte("sc_1"), []byte(strconv.Itoa(MAX_PAGE_SIZE + 1)), []byte("")})
+	checkErrorCode(t, res, ccerror.VALIDATION_FAILED)
+
+	// the MockStub has no pagination and returns no iterator
+	res = stub.MockInvoke("1", [][]byte{[]byte("getLogsOfSupplychain"), []byte("sc_1"), []byte("2"), []byte("")})
+	checkErrorCode(t, res, ccerror.INTERNAL)
+
+	expected := [][]string{{"Log_1", "Log_2"}, {"Log_3", "Log_4"}, {"Log_5"}}
+	bookmark := ""
+	for i, IDs := range expected {
+		res = invokePaged(scc, stub, [][]byte{[]byte("getLogsOfSupplychain"), []byte("sc_1"), []byte("2"), []byte(bookmark)})
+		page := checkLogsPage(t, res)
+
+		checkLogIDs(t, page.Logs, IDs)
+		if page.FetchedRecordsCount != int32(len(IDs)) || (len(page.Bookmark) == 0) != (i == len(expected)-1) {
+			fmt.Println("Page was not as expected", page)
+			t.FailNow()
+		}
+		bookmark = page.Bookmark
+	}
+}
+
+func checkLogsPage(t *testing.T, res pb.Response) LogsPage {
+	if res.Status != shim.OK {
+		fmt.Println("failed", string(res.Message))
+		t.FailNow()
+	}
+	page := LogsPage{}
+	err := json.Unmarshal(res.Payload, &page)
+	if err != nil {
+		fmt.Println("Failed to decode json of LogsPage:", err.Error())
+		t.FailNow()
+	}
+	return page
+}
+
+// pagingStub pages composite keys, which the MockStub of Fabric 1.4 does
+// not. Like the peer it starts a page at the bookmark.
+type pagingStub struct {
+	*shim.MockStub
+	args [][]byte
+}
+
+// invokePaged is MockInvoke with a pagingStub
+func invokePaged(scc *FoodChaincode, stub *shim.MockStub, args [][]byte) pb.Response {
+	stub.MockTransactionStart("1")
+	defer stub.MockTransactionEnd("1")
+	return scc.Invoke(pagingStub{MockStub: stub, args: args})
+}
+
+func (s pagingStub) GetArgs() [][]byte {
+	return s.args
+}
+
+func (s pagingStub) GetStringArgs() []string {
+	strargs := []string{}
+	for _, arg := range s.args {
+		strargs = append(strargs, string(arg))
+	}
+	return strargs
+}
+
+func (s pagingStub) GetFunctionAndParameters() (string, []string) {
+	strargs := s.GetStringArgs()
+	if len(strargs) == 0 {
+		return "", []string{}
+	}
+	return strargs[0], strargs[1:]
+}
+
+func (s pagingStub) GetStateByPartialCompositeKeyWithPagination(objectType string, keys []string, pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *pb.QueryResponseMetadata, error) {
+	resultsIterator, err := s.GetStateByPartialCompositeKey(objectType, keys)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resultsIterator.Close()
+
+	page := &kvIterator{}
+	metadata := &pb.QueryResponseMetadata{}
+	for resultsIterator.HasNext() {
+		kv, err := resultsIterator.Next()
+		if err != nil {
+			return nil, nil, err
+		}
+		if kv.Key < bookmark {
+			continue
+		}
+		if int32(len(page.kvs)) == pageSize {
+			metadata.Bookmark = kv.Key
+			break
+		}
+		page.kvs = append(page.kvs, kv)
+	}
+	metadata.FetchedRecordsCount = int32(len(page.kvs))
+	return page, metadata, nil
+}
+
+type kvIterator struct {
+	kvs []*queryresult.KV
+}
+
+func (it *kvIterator) HasNext() bool {
+	return len(it.kvs) > 0
+}
+
+func (it *kvIterator) Next() (*queryresult.KV, error) {
+	kv := it.kvs[0]
+	it.kvs = it.kvs[1:]
+	return kv, nil
+}
+
+func (it *kvIterator) Close() error {
+	return nil
+}
+
 func checkCreateLog(t *testing.T, stub *shim.MockStub, logAsJSON []byte, value Log) {
 	res := stub.MockInvoke("1", [][]byte{[]byte("createLog"), logAsJSON})
 	if res.Status != shim.OK {
@@ -142,6 +344,7 @@ func checkCreateLog(t *testing.T, stub *shim.MockStub, logAsJSON []byte, value L
 		fmt.Println("Query value was not as expected")
 		t.FailNow()
 	}
+	checkCreator(t, resLog.Creator, TEST_MSP)
 
 	// check log of supplychain
 	res = stub.MockInvoke("1", [][]byte{[]byte("getLogsOfSupplychain"), []byte(value.Supplychain)})
@@ -214,6 +417,7 @@ func checkUpdateLog(t *testing.T, stub *shim.MockStub, logAsJSON []byte, value L
 		fmt.Println("Query value was not as expected")
 		t.FailNow()
 	}
+	checkCreator(t, resLog.Creator, TEST_MSP)
 
 	// check log of supplychain
 	res = stub.MockInvoke("1", [][]byte{[]byte("getLogsOfSupplychain"), []byte(oldValue.Supplychain)})
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/deevotech/sc-chaincode.deevo.io/lib/ccerror"
+	"github.com/deevotech/sc-chaincode.deevo.io/lib/logging"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+func TestFood_LoggingConfig(t *testing.T) {
+	scc := new(FoodChaincode)
+	stub := shim.NewMockStub("food", scc)
+
+	var out bytes.Buffer
+	defer logging.SetOutput(logging.SetOutput(&out))
+	defer logging.Configure(stub.ChannelID, logging.Settings{})
+
+	initConfigData(t, stub, ChaincodeConfig{ObjectType: TYPE_CONFIG, Logging: logging.Settings{Level: "DEBUG"}})
+	checkCreateLogAt(t, stub, Log{ObjectType: TYPE_LOG, ID: "Log_1", Time: 100, Ref: []string{}, CTE: "shipping", Content: "secret recipe"})
+	if !strings.Contains(out.String(), `"msg":"start createLog"`) {
+		fmt.Println("createLog should be logged at DEBUG", out.String())
+		t.FailNow()
+	}
+	if strings.Contains(out.String(), "secret recipe") {
+		fmt.Println("The arguments of createLog should not be logged", out.String())
+		t.FailNow()
+	}
+
+	// the level of the config applies to the next invocation
+	setMockIdentity(TEST_MSP, map[string]string{ADMIN_ATTRIBUTE: "true"})
+	res := checkUpdateConfig(stub, ChaincodeConfig{ObjectType: TYPE_CONFIG, Version: 1, Logging: logging.Settings{Level: "ERROR"}})
+	if res.Status != shim.OK {
+		fmt.Println("updateConfig failed", res.Message)
+		t.FailNow()
+	}
+	out.Reset()
+	checkGetConfig(t, stub)
+	if out.Len() > 0 {
+		fmt.Println("Nothing should be logged below ERROR", out.String())
+		t.FailNow()
+	}
+
+	res = checkUpdateConfig(stub, ChaincodeConfig{ObjectType: TYPE_CONFIG, Version: 2, Logging: logging.Settings{Level: "LOUD"}})
+	checkErrorCode(t, res, ccerror.VALIDATION_FAILED)
+}
@@ -0,0 +1,319 @@
+package main
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/deevotech/sc-chaincode.deevo.io/lib/ccerror"
+	"github.com/deevotech/sc-chaincode.deevo.io/lib/logging"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+// migration upgrades the json of an object from schema version From to
+// From+1. ObjectTypes limits the step to some types and Traceables to the
+// types of Traceables, it applies to every type when neither is set.
+type migration struct {
+	From        int64
+	ObjectTypes []string
+	Traceables  bool
+	Apply       func(t *FoodChaincode, stub shim.ChaincodeStubInterface, objectAsBytes []byte) (pb.Response, []byte)
+}
+
+// migrations is the registry of the steps between schema versions, in the
+// order they are applied. Steps may only add to the state, a migration can
+// be interrupted and run again.
+var migrations = []migration{
+	// objects from before schema versions miss the keys of the indexes
+	// which were added later
+	{From: 0, ObjectTypes: []string{TYPE_LOG}, Apply: migrateLogKeys},
+	{From: 0, ObjectTypes: []string{TYPE_AUDITACTION}, Apply: migrateAuditStatus},
+	{From: 0, ObjectTypes: []string{TYPE_AUDITOR}, Apply: migrateAuditorKeys},
+	{From: 0, Traceables: true, Apply: migrateTraceableKeys},
+}
+
+func (m migration) appliesTo(objectType string) bool {
+	if m.Traceables {
+		return isTraceableType(objectType)
+	}
+	if len(m.ObjectTypes) == 0 {
+		return true
+	}
+	for _, migrationType := range m.ObjectTypes {
+		if migrationType == objectType {
+			return true
+		}
+	}
+	return false
+}
+
+func migrateLogKeys(t *FoodChaincode, stub shim.ChaincodeStubInterface, logAsBytes []byte) (pb.Response, []byte) {
+	log := Log{}
+	err := json.Unmarshal(logAsBytes, &log)
+	if err != nil {
+		return ccerror.Internal("Failed to get decode log: " + err.Error()), nil
+	}
+	result := t.updateLogKeys(stub, nil, &log)
+	if result.Status != shim.OK {
+		return result, nil
+	}
+	result = t.updateRefKeys(stub, log.ID, nil, log.Ref)
+	if result.Status != shim.OK {
+		return result, nil
+	}
+	result = t.updateDocumentKeys(stub, log.ID, nil, log.Documents)
+	if result.Status != shim.OK {
+		return result, nil
+	}
+	return shim.Success(nil), logAsBytes
+}
+
+func migrateTraceableKeys(t *FoodChaincode, stub shim.ChaincodeStubInterface, traceableAsBytes []byte) (pb.Response, []byte) {
+	traceable := Traceable{}
+	err := json.Unmarshal(traceableAsBytes, &traceable)
+	if err != nil {
+		return ccerror.Internal("Failed to get decode Traceable: " + err.Error()), nil
+	}
+	result := t.putParentKey(stub, traceable)
+	if result.Status != shim.OK {
+		return result, nil
+	}
+	return shim.Success(nil), traceableAsBytes
+}
+
+func migrateAuditorKeys(t *FoodChaincode, stub shim.ChaincodeStubInterface, auditorAsBytes []byte) (pb.Response, []byte) {
+	auditor := Auditor{}
+	err := json.Unmarshal(auditorAsBytes, &auditor)
+	if err != nil {
+		return ccerror.Internal("Failed to get decode Auditor: " + err.Error()), nil
+	}
+	result := t.updateAuditorKeys(stub, nil, &auditor)
+	if result.Status != shim.OK {
+		return result, nil
+	}
+	return shim.Success(nil), auditorAsBytes
+}
+
+func migrateAuditStatus(t *FoodChaincode, stub shim.ChaincodeStubInterface, auditActionAsBytes []byte) (pb.Response, []byte) {
+	auditAction := AuditAction{}
+	err := json.Unmarshal(auditActionAsBytes, &auditAction)
+	if err != nil {
+		return ccerror.Internal("Failed to get decode AuditAction: " + err.Error()), nil
+	}
+	setAuditDefaults(&auditAction)
+	result := t.putCompositeKey(stub, CK_AUDIT_STATUS, []string{auditAction.Status, auditAction.ID})
+	if result.Status != shim.OK {
+		return result, nil
+	}
+	result = t.updateDocumentKeys(stub, auditAction.ID, nil, auditAction.Documents)
+	if result.Status != shim.OK {
+		return result, nil
+	}
+
+	auditActionAsBytes, err = json.Marshal(auditAction)
+	if err != nil {
+		return ccerror.Internal("Failed to encode json of AuditAction: " + err.Error()), nil
+	}
+	return shim.Success(nil), auditActionAsBytes
+}
+
+// migrateObject applies the steps from schemaVersion on and returns the
+// object with SCHEMA_VERSION and its next version
+func (t *FoodChaincode) migrateObject(stub shim.ChaincodeStubInterface, ID string, objectType string, schemaVersion int64, objectAsBytes []byte) (pb.Response, []byte) {
+	for _, step := range migrations {
+		if step.From < schemaVersion || !step.appliesTo(objectType) {
+			continue
+		}
+		result, migratedAsBytes := step.Apply(t, stub, objectAsBytes)
+		if result.Status != shim.OK {
+			return ccerror.Prefix("Failed to migrate Object with ID "+ID+" from schema version "+strconv.FormatInt(step.From, 10)+": ", result), nil
+		}
+		objectAsBytes = migratedAsBytes
+	}
+
+	version, err := versionOf(objectAsBytes)
+	if err != nil {
+		return ccerror.Internal("Failed to get version of Object with ID: " + ID + ", error: " + err.Error()), nil
+	}
+	objectAsBytes, err = withVersion(objectAsBytes, version+1)
+	if err != nil {
+		return ccerror.Internal("Failed to set version of Object with ID: " + ID + ", error: " + err.Error()), nil
+	}
+	return shim.Success(nil), objectAsBytes
+}
+
+// migrate upgrades the objects of a type to SCHEMA_VERSION, at most
+// batchSize of them per transaction. The bookmark of the returned progress
+// is passed to the next call until the migration is done.
+//
+// Objects from before schema versions are in no index of their type, so
+// migrate walks the world state from the bookmark and skips the objects of
+// other types. batchSize limits the objects which are written, a call reads
+// at most MAX_MIGRATION_READS objects and returns a bookmark when it stops
+// there, even when fewer than batchSize objects were found.
+func (t *FoodChaincode) migrate(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	logger.Debug(stub, "start migrate")
+
+	objectType := args[0]
+	batchSize := DEFAULT_MIGRATION_BATCH
+	if len(args) > 1 {
+		size, err := strconv.Atoi(args[1])
+		if err != nil || size < 1 || size > MAX_MIGRATION_BATCH {
+			return ccerror.ValidationFailed("Batch size must be a number from 1 to " + strconv.Itoa(MAX_MIGRATION_BATCH))
+		}
+		batchSize = size
+	}
+	bookmark := ""
+	if len(args) == 3 {
+		bookmark = args[2]
+	}
+
+	result, progress := t.readMigrationProgress(stub, objectType)
+	if result.Status != shim.OK {
+		return result
+	}
+	// a migration without bookmark starts over
+	if len(bookmark) == 0 || progress.SchemaVersion != SCHEMA_VERSION {
+		progress = MigrationProgress{Type: objectType, SchemaVersion: SCHEMA_VERSION}
+	}
+	progress.Bookmark = ""
+
+	resultsIterator, err := stub.GetStateByRange(bookmark, "")
+	if err != nil {
+		return ccerror.Internal(err.Error())
+	}
+	defer resultsIterator.Close()
+
+	scanned := 0
+	reads := 0
+	lastKey := ""
+	for resultsIterator.HasNext() {
+		responseRange, err := resultsIterator.Next()
+		if err != nil {
+			return ccerror.Internal(err.Error())
+		}
+		// composite keys are not part of the range on a peer, the MockStub
+		// returns them as well
+		if strings.HasPrefix(responseRange.Key, "\x00") || responseRange.Key == bookmark {
+			continue
+		}
+		if reads == MAX_MIGRATION_READS {
+			progress.Bookmark = lastKey
+			break
+		}
+		reads++
+
+		object := struct {
+			ObjectType    string `json:"objectType"`
+			SchemaVersion int64  `json:"schemaVersion"`
+		}{}
+		err = json.Unmarshal(responseRange.Value, &object)
+		if err != nil {
+			return ccerror.Internal("Failed to get decode Object with ID " + responseRange.Key + ": " + err.Error())
+		}
+		if object.ObjectType != objectType {
+			lastKey = responseRange.Key
+			continue
+		}
+		if scanned == batchSize {
+			progress.Bookmark = lastKey
+			break
+		}
+		scanned++
+		lastKey = responseRange.Key
+		progress.Scanned++
+
+		if object.SchemaVersion >= SCHEMA_VERSION {
+			continue
+		}
+		result, objectAsBytes := t.migrateObject(stub, responseRange.Key, objectType, object.SchemaVersion, responseRange.Value)
+		if result.Status != shim.OK {
+			return result
+		}
+		err = stub.PutState(responseRange.Key, objectAsBytes)
+		if err != nil {
+			return ccerror.Internal("Failed to update the object with ID: " + responseRange.Key + ", error: " + err.Error())
+		}
+		progress.Migrated++
+	}
+	progress.Done = len(progress.Bookmark) == 0
+
+	progressAsBytes, err := json.Marshal(progress)
+	if err != nil {
+		return ccerror.Internal("Failed to encode json of MigrationProgress: " + err.Error())
+	}
+	progressKey, err := stub.CreateCompositeKey(CK_MIGRATION, []string{objectType})
+	if err != nil {
+		return ccerror.Internal("Failed to create composite key: " + err.Error())
+	}
+	err = stub.PutState(progressKey, progressAsBytes)
+	if err != nil {
+		return ccerror.Internal("Failed to save progress of migration, error: " + err.Error())
+	}
+
+	logger.Debug(stub, "end migrate (success)")
+	return shim.Success(progressAsBytes)
+}
+
+func (t *FoodChaincode) getMigrationProgress(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	logger.Debug(stub, "start getMigrationProgress")
+
+	result, progress := t.readMigrationProgress(stub, args[0])
+	if result.Status != shim.OK {
+		return result
+	}
+	progressAsBytes, err := json.Marshal(progress)
+	if err != nil {
+		return ccerror.Internal("Failed to get encode response: " + err.Error())
+	}
+
+	logger.Debug(stub, "end getMigrationProgress (success)")
+	return shim.Success(progressAsBytes)
+}
+
+// readMigrationProgress returns the stored progress, an empty one when no
+// migration of the type ran
+func (t *FoodChaincode) readMigrationProgress(stub shim.ChaincodeStubInterface, objectType string) (pb.Response, MigrationProgress) {
+	progress := MigrationProgress{Type: objectType}
+	progressKey, err := stub.CreateCompositeKey(CK_MIGRATION, []string{objectType})
+	if err != nil {
+		return ccerror.Internal("Failed to create composite key: " + err.Error()), progress
+	}
+	progressAsBytes, err := stub.GetState(progressKey)
+	if err != nil {
+		return ccerror.Internal("Failed to get progress of migration, error: " + err.Error()), progress
+	} else if progressAsBytes == nil {
+		return shim.Success(nil), progress
+	}
+	err = json.Unmarshal(progressAsBytes, &progress)
+	if err != nil {
+		return ccerror.Internal("Failed to decode progress of migration: " + err.Error()), progress
+	}
+	return shim.Success(nil), progress
+}
+
+// initSchemaVersion records the schema version of the chaincode. Init runs
+// on every upgrade, objects of an older schema keep their shape until they
+// are migrated.
+func (t *FoodChaincode) initSchemaVersion(stub shim.ChaincodeStubInterface) pb.Response {
+	settingKey, err := stub.CreateCompositeKey(CK_SETTING, []string{SETTING_SCHEMA_VERSION})
+	if err != nil {
+		return ccerror.Internal("Failed to create composite key: " + err.Error())
+	}
+	versionAsBytes, err := stub.GetState(settingKey)
+	if err != nil {
+		return ccerror.Internal("Failed to get schema version, error: " + err.Error())
+	}
+
+	current := strconv.Itoa(SCHEMA_VERSION)
+	if versionAsBytes != nil && string(versionAsBytes) != current {
+		logger.Info(stub, "schema version changed, objects are upgraded with migrate", logging.Fields{"from": string(versionAsBytes), "to": current})
+	}
+	err = stub.PutState(settingKey, []byte(current))
+	if err != nil {
+		return ccerror.Internal("Failed to save schema version, error: " + err.Error())
+	}
+	return shim.Success(nil)
+}
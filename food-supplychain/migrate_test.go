@@ -0,0 +1,168 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+// initLegacyData stores objects the way chaincode without schema versions
+// wrote them, without the keys of the later indexes
+func initLegacyData(t *testing.T, stub *shim.MockStub) {
+	checkInit(t, stub, [][]byte{})
+	checkCreateReferences(t, stub, map[string]string{"Product_1": TYPE_PRODUCT, "Auditor_1": TYPE_AUDITOR})
+
+	stub.State["Log_1"] = []byte(`{"objectType": "log", "id": "Log_1", "time": 100, "ref": [], "cte": "shipping", "product": "Product_1"}`)
+	stub.State["Log_2"] = []byte(`{"objectType": "log", "id": "Log_2", "time": 200, "ref": [], "cte": "receiving", "product": "Product_1"}`)
+	stub.State["AuditAction_1"] = []byte(`{"objectType": "auditAction", "id": "AuditAction_1", "auditor": "Auditor_1", "objectID": "Product_1"}`)
+	checkCreateLogAt(t, stub, Log{ObjectType: TYPE_LOG, ID: "Log_3", Time: 300, Ref: []string{}, CTE: "shipping", Product: "Product_1"})
+}
+
+func TestFood_MigrateInBatches(t *testing.T) {
+	scc := new(FoodChaincode)
+	stub := shim.NewMockStub("food", scc)
+
+	initLegacyData(t, stub)
+	page := checkLogsInRange(t, scc, stub, "getLogsOfProductInRange", "Product_1", "0", "1000")
+	if len(page.Logs) != 1 {
+		fmt.Println("Legacy logs should not be in the time index yet", len(page.Logs))
+		t.FailNow()
+	}
+
+	setMockIdentity(TEST_MSP, map[string]string{ADMIN_ATTRIBUTE: "true"})
+	progress := checkMigrate(t, stub, TYPE_LOG, "2")
+	if progress.Scanned != 2 || progress.Migrated != 2 || progress.Bookmark != "Log_2" || progress.Done {
+		fmt.Println("First batch was not as expected", progress)
+		t.FailNow()
+	}
+	progress = checkMigrate(t, stub, TYPE_LOG, "2", progress.Bookmark)
+	if progress.Scanned != 3 || progress.Migrated != 2 || len(progress.Bookmark) != 0 || !progress.Done {
+		fmt.Println("Second batch was not as expected", progress)
+		t.FailNow()
+	}
+
+	log := Log{}
+	json.Unmarshal(stub.State["Log_1"], &log)
+	if log.SchemaVersion != SCHEMA_VERSION || log.Version != 1 || log.CTE != "shipping" {
+		fmt.Println("Log was not migrated", log)
+		t.FailNow()
+	}
+	page = checkLogsInRange(t, scc, stub, "getLogsOfProductInRange", "Product_1", "0", "1000")
+	if len(page.Logs) != 3 {
+		fmt.Println("Migrated logs should be in the time index", len(page.Logs))
+		t.FailNow()
+	}
+
+	res := stub.MockInvoke("1", [][]byte{[]byte("getMigrationProgress"), []byte(TYPE_LOG)})
+	if res.Status != shim.OK {
+		fmt.Println("failed", string(res.Message))
+		t.FailNow()
+	}
+	stored := MigrationProgress{}
+	json.Unmarshal(res.Payload, &stored)
+	if stored != progress {
+		fmt.Println("Stored progress was not as expected", stored)
+		t.FailNow()
+	}
+}
+
+func TestFood_MigrateAuditActions(t *testing.T) {
+	scc := new(FoodChaincode)
+	stub := shim.NewMockStub("food", scc)
+
+	initLegacyData(t, stub)
+
+	setMockIdentity(TEST_MSP, map[string]string{ADMIN_ATTRIBUTE: "true"})
+	progress := checkMigrate(t, stub, TYPE_AUDITACTION)
+	if progress.Migrated != 1 || !progress.Done {
+		fmt.Println("Migration was not as expected", progress)
+		t.FailNow()
+	}
+	checkAuditsByStatus(t, stub, AUDIT_SCHEDULED, 1)
+
+	// migrated objects are skipped
+	progress = checkMigrate(t, stub, TYPE_AUDITACTION)
+	if progress.Scanned != 1 || progress.Migrated != 0 {
+		fmt.Println("Second migration was not as expected", progress)
+		t.FailNow()
+	}
+}
+
+func TestFood_MigrateIndexes(t *testing.T) {
+	scc := new(FoodChaincode)
+	stub := shim.NewMockStub("food", scc)
+
+	initLegacyData(t, stub)
+	expires := time.Now().Unix() + 10*secondsPerDay
+	stub.State["Log_4"] = []byte(`{"objectType": "log", "id": "Log_4", "time": 400, "ref": ["Product_1"], "cte": "shipping", "product": "Product_1"}`)
+	stub.State["Product_2"] = []byte(`{"objectType": "product", "id": "Product_2", "name": "Product 2", "parent": "Product_1"}`)
+	stub.State["Auditor_2"] = []byte(`{"objectType": "auditor", "id": "Auditor_2", "accreditations": [{"accreditingBody": "Body_1", "validTo": ` + strconv.FormatInt(expires, 10) + `}]}`)
+	checkChildren(t, stub, "Product_1", 0)
+
+	setMockIdentity(TEST_MSP, map[string]string{ADMIN_ATTRIBUTE: "true"})
+	checkMigrate(t, stub, TYPE_LOG)
+	refKey, _ := stub.CreateCompositeKey(CK_REF_LOG, []string{"Product_1", "Log_4"})
+	if stub.State[refKey] == nil {
+		fmt.Println("Migrated logs should be in the ref~log index")
+		t.FailNow()
+	}
+
+	checkMigrate(t, stub, TYPE_PRODUCT)
+	checkChildren(t, stub, "Product_1", 1)
+
+	checkMigrate(t, stub, TYPE_AUDITOR)
+	auditors := checkExpiringAuditors(t, stub, "30")
+	if len(auditors) != 1 || auditors[0].ID != "Auditor_2" {
+		fmt.Println("Migrated auditors should be in the expiry index", auditors)
+		t.FailNow()
+	}
+}
+
+func TestFood_MigrateWithoutAdmin(t *testing.T) {
+	scc := new(FoodChaincode)
+	stub := shim.NewMockStub("food", scc)
+
+	initLegacyData(t, stub)
+
+	res := stub.MockInvoke("1", [][]byte{[]byte("migrate"), []byte(TYPE_LOG)})
+	if res.Status == shim.OK {
+		fmt.Println("migrate should fail without " + ADMIN_ATTRIBUTE)
+		t.FailNow()
+	}
+}
+
+func TestFood_InitRecordsSchemaVersion(t *testing.T) {
+	scc := new(FoodChaincode)
+	stub := shim.NewMockStub("food", scc)
+
+	checkInit(t, stub, [][]byte{})
+
+	settingKey, _ := stub.CreateCompositeKey(CK_SETTING, []string{SETTING_SCHEMA_VERSION})
+	if string(stub.State[settingKey]) != "1" {
+		fmt.Println("Schema version was not recorded", string(stub.State[settingKey]))
+		t.FailNow()
+	}
+}
+
+func checkMigrate(t *testing.T, stub *shim.MockStub, args ...string) MigrationProgress {
+	invokeArgs := [][]byte{[]byte("migrate")}
+	for _, arg := range args {
+		invokeArgs = append(invokeArgs, []byte(arg))
+	}
+	res := stub.MockInvoke("1", invokeArgs)
+	if res.Status != shim.OK {
+		fmt.Println("failed", string(res.Message))
+		t.FailNow()
+	}
+	progress := MigrationProgress{}
+	err := json.Unmarshal(res.Payload, &progress)
+	if err != nil {
+		fmt.Println("Failed to decode json of MigrationProgress:", err.Error())
+		t.FailNow()
+	}
+	return progress
+}
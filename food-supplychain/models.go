@@ -1,18 +1,138 @@
 package main
 
-import "sort"
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/deevotech/sc-chaincode.deevo.io/lib/ccconfig"
+	"github.com/deevotech/sc-chaincode.deevo.io/lib/ccerror"
+	"github.com/deevotech/sc-chaincode.deevo.io/lib/logging"
+)
 
 const (
-	CK_AUDIT_OBJ     = "auditedObject~audit"
-	CK_AUDITOR_AUDIT = "auditor~audit"
-	CK_SC_LOG        = "sc~log"
-	CK_PRODUCT_LOG   = "product~log"
+	CK_AUDIT_OBJ        = "auditedObject~audit"
+	CK_AUDITOR_AUDIT    = "auditor~audit"
+	CK_SC_LOG           = "sc~log"
+	CK_PRODUCT_LOG      = "product~log"
+	CK_REF_LOG          = "ref~log"
+	CK_SC_TIME_LOG      = "sc~time~log"
+	CK_PRODUCT_TIME_LOG = "product~time~log"
+	CK_LOCATION_LOG     = "location~log"
+	CK_GEOHASH_LOG      = "geohash~log"
+	CK_RECALL_STATUS    = "status~recall"
+	CK_AFFECTED_RECALL  = "affected~recall"
+	CK_AUDIT_STATUS     = "status~audit"
+	CK_AUDITOR_EXPIRY   = "expiry~auditor"
+	CK_DOCUMENT_OBJECT  = "document~object"
+	CK_PARENT_CHILD     = "parent~child"
+	CK_POLICY           = "policy~function"
+	CK_TOMBSTONE        = "tombstone~object"
+	CK_CTE_SCHEMA       = "schema~cte"
+	CK_SETTING          = "setting~name"
+	CK_MIGRATION        = "migration~type"
 
 	TYPE_LOG         = "log"
 	TYPE_SUPPLYCHAIN = "supplychain"
 	TYPE_PRODUCT     = "product"
 	TYPE_AUDITACTION = "auditAction"
 	TYPE_AUDITOR     = "auditor"
+	TYPE_POLICY      = "policy"
+	TYPE_TOMBSTONE   = "tombstone"
+	TYPE_CTE_SCHEMA  = "cteSchema"
+	TYPE_RECALL      = "recall"
+	TYPE_LOG_PRIVATE = "logPrivate"
+	TYPE_CONFIG      = "config"
+
+	ADMIN_ATTRIBUTE = "food_supplychain.admin"
+
+	EVENT_VERSION             = 1
+	EVENT_LOG_CREATED         = "LogCreated"
+	EVENT_LOG_UPDATED         = "LogUpdated"
+	EVENT_TRACEABLE_CREATED   = "TraceableCreated"
+	EVENT_TRACEABLE_UPDATED   = "TraceableUpdated"
+	EVENT_AUDITACTION_CREATED = "AuditActionCreated"
+	EVENT_AUDITACTION_UPDATED = "AuditActionUpdated"
+	EVENT_OBJECT_DELETED      = "ObjectDeleted"
+	EVENT_OBJECT_ARCHIVED     = "ObjectArchived"
+	EVENT_BULK_CREATED        = "BulkCreated"
+	EVENT_RECALL_INITIATED    = "RecallInitiated"
+	EVENT_RECALL_UPDATED      = "RecallUpdated"
+
+	SETTING_INTEGRITY_MODE = "integrityMode"
+	SETTING_SCHEMA_VERSION = "schemaVersion"
+	SETTING_CONFIG         = "config"
+	INTEGRITY_STRICT       = "strict"
+	INTEGRITY_LENIENT      = "lenient"
+
+	BULK_VALID   = "valid"
+	BULK_CREATED = "created"
+	BULK_FAILED  = "failed"
+
+	TRACE_BACKWARD = "backward"
+	TRACE_FORWARD  = "forward"
+
+	EDGE_LOG    = "log"
+	EDGE_REF    = "ref"
+	EDGE_PARENT = "parent"
+
+	DEFAULT_TRACE_DEPTH = 10
+	MAX_TRACE_DEPTH     = 50
+
+	DEFAULT_TREE_DEPTH = 10
+	MAX_TREE_DEPTH     = 50
+
+	GEOHASH_PRECISION = 9
+	MAX_GEOHASH_CELLS = 64
+
+	RECALL_OPEN     = "open"
+	RECALL_NOTIFIED = "notified"
+	RECALL_CLOSED   = "closed"
+
+	SEVERITY_HIGH   = "high"
+	SEVERITY_MEDIUM = "medium"
+	SEVERITY_LOW    = "low"
+
+	AUDIT_SCHEDULED   = "scheduled"
+	AUDIT_IN_PROGRESS = "in_progress"
+	AUDIT_COMPLETED   = "completed"
+	AUDIT_CLOSED      = "closed"
+
+	FINDING_CRITICAL = "critical"
+	FINDING_MAJOR    = "major"
+	FINDING_MINOR    = "minor"
+
+	ACTION_OPEN     = "open"
+	ACTION_RESOLVED = "resolved"
+
+	OUTCOME_PASSED  = "passed"
+	OUTCOME_FAILED  = "failed"
+	OUTCOME_PENDING = "pending"
+
+	SORT_ASC  = "asc"
+	SORT_DESC = "desc"
+
+	// MAX_PAGE_SIZE keeps a page of logs below the message size of gRPC
+	MAX_PAGE_SIZE = 1000
+
+	// SCHEMA_VERSION is the shape of the objects this chaincode writes, raise
+	// it together with a step in migrations when a model changes
+	SCHEMA_VERSION          = 1
+	DEFAULT_MIGRATION_BATCH = 100
+	MAX_MIGRATION_BATCH     = 1000
+	MAX_MIGRATION_READS     = 10000
+
+	COLLECTION_LOG_PRIVATE = "collectionLogPrivate"
+	TRANSIENT_LOG_PRIVATE  = "logPrivate"
+	MIN_SALT_LENGTH        = 16
+
+	EPCIS_CONTEXT              = "https://ref.gs1.org/standards/epcis/epcis-context.jsonld"
+	EPCIS_DOCUMENT             = "EPCISDocument"
+	EPCIS_SCHEMA_VERSION       = "2.0"
+	EPCIS_OBJECT_EVENT         = "ObjectEvent"
+	EPCIS_AGGREGATION_EVENT    = "AggregationEvent"
+	EPCIS_TRANSFORMATION_EVENT = "TransformationEvent"
+	EPCIS_EXPORT_SUPPLYCHAIN   = "supplychain"
+	EPCIS_EXPORT_PRODUCT       = "product"
 )
 
 // InitData model
@@ -29,25 +149,291 @@ type LiteModel struct {
 
 // Traceable model
 type Traceable struct {
-	ObjectType string `json:"objectType"`
-	ID         string `json:"id"`
-	Name       string `json:"name"`
-	Content    string `json:"content"`
-	Parent     string `json:"parent"`
+	ObjectType    string `json:"objectType"`
+	ID            string `json:"id"`
+	Name          string `json:"name"`
+	Content       string `json:"content"`
+	Parent        string `json:"parent"`
+	Version       int64  `json:"version"`
+	SchemaVersion int64  `json:"schemaVersion"`
+}
+
+// ParentID returns the parent of a Traceable, a Traceable which is its own
+// parent has none
+func (tr *Traceable) ParentID() string {
+	if tr.Parent == tr.ID {
+		return ""
+	}
+	return tr.Parent
+}
+
+// TraceableTree model
+type TraceableTree struct {
+	Traceable
+	Children []TraceableTree `json:"children"`
+}
+
+// ChaincodeConfig model, the configuration of the chaincode on a channel.
+// It is passed to Init and changed with updateConfig, every version is kept
+// in its history. Empty AllowedCTEs allow every CTE, empty AdminMSPs let
+// admins of every MSP through.
+type ChaincodeConfig struct {
+	ObjectType    string           `json:"objectType"`
+	AllowedCTEs   []string         `json:"allowedCTEs"`
+	AdminMSPs     []string         `json:"adminMSPs"`
+	IntegrityMode string           `json:"integrityMode"`
+	Updater       ccconfig.Updater `json:"updater"`
+	Version       int64            `json:"version"`
+	SchemaVersion int64            `json:"schemaVersion"`
+	// Logging settings of the channel, see lib/logging
+	Logging logging.Settings `json:"logging"`
+}
+
+// FunctionPolicy model. A client may call Function if its MSP is one of
+// MSPIDs, when set, and its certificate has all of the Attributes.
+type FunctionPolicy struct {
+	ObjectType    string            `json:"objectType"`
+	Function      string            `json:"function"`
+	MSPIDs        []string          `json:"mspIds"`
+	Attributes    map[string]string `json:"attributes"`
+	Version       int64             `json:"version"`
+	SchemaVersion int64             `json:"schemaVersion"`
+}
+
+// CTESchema model, the key data elements a Log of the CTE must have.
+// LogFields are json names of Log fields, KDEs are keys of the json object
+// in the Content of the Log.
+type CTESchema struct {
+	ObjectType    string   `json:"objectType"`
+	CTE           string   `json:"cte"`
+	LogFields     []string `json:"logFields"`
+	KDEs          []string `json:"kdes"`
+	Version       int64    `json:"version"`
+	SchemaVersion int64    `json:"schemaVersion"`
+}
+
+// ChangeEvent model, the payload of the chaincode events. OldIndexes and
+// NewIndexes hold the indexed value per composite key, OldIndexes is empty
+// for created objects.
+type ChangeEvent struct {
+	Version     int               `json:"version"`
+	EventType   string            `json:"eventType"`
+	TxID        string            `json:"txId"`
+	ObjectID    string            `json:"objectId"`
+	ObjectType  string            `json:"objectType"`
+	Supplychain string            `json:"supplychain_id"`
+	Product     string            `json:"product"`
+	OldIndexes  map[string]string `json:"oldIndexes"`
+	NewIndexes  map[string]string `json:"newIndexes"`
+	ObjectIDs   []string          `json:"objectIds,omitempty"`
+}
+
+// Recall model. Affected holds the root lot and every Traceable derived
+// from it, Organisations the MSPs which logged them and have to
+// acknowledge the recall. Truncated is set when the trace hit its depth
+// limit.
+type Recall struct {
+	ObjectType       string                  `json:"objectType"`
+	ID               string                  `json:"id"`
+	Root             string                  `json:"root"`
+	Reason           string                  `json:"reason"`
+	Severity         string                  `json:"severity"`
+	Status           string                  `json:"status"`
+	Affected         []string                `json:"affected"`
+	Truncated        bool                    `json:"truncated"`
+	Organisations    []string                `json:"organisations"`
+	Acknowledgements []RecallAcknowledgement `json:"acknowledgements"`
+	Initiator        Creator                 `json:"initiator"`
+	Updated          int64                   `json:"updated"`
+	Version          int64                   `json:"version"`
+	SchemaVersion    int64                   `json:"schemaVersion"`
+}
+
+// RecallAcknowledgement model
+type RecallAcknowledgement struct {
+	MSPID   string `json:"mspId"`
+	Subject string `json:"subject"`
+	Time    int64  `json:"time"`
+	Note    string `json:"note"`
+}
+
+// BulkData model
+type BulkData struct {
+	Logs         []Log         `json:"logs"`
+	AuditActions []AuditAction `json:"auditActions"`
 }
 
-// Log model
+// BulkOptions model. With AllOrNothing nothing is created when one item
+// fails, with DryRun items are only validated.
+type BulkOptions struct {
+	AllOrNothing bool `json:"allOrNothing"`
+	DryRun       bool `json:"dryRun"`
+}
+
+// BulkItemResult model, Index is the position of the item in its list
+type BulkItemResult struct {
+	Index      int          `json:"index"`
+	ObjectType string       `json:"objectType"`
+	ID         string       `json:"id"`
+	Status     string       `json:"status"`
+	Code       ccerror.Code `json:"code,omitempty"`
+	Message    string       `json:"message,omitempty"`
+}
+
+// BulkResult model
+type BulkResult struct {
+	AllOrNothing bool             `json:"allOrNothing"`
+	DryRun       bool             `json:"dryRun"`
+	Created      int              `json:"created"`
+	Failed       int              `json:"failed"`
+	Items        []BulkItemResult `json:"items"`
+}
+
+// EPCISDocument model, the part of a GS1 EPCIS 2.0 JSON-LD document used
+// by the chaincode. Events are kept raw so they can be stored unchanged.
+type EPCISDocument struct {
+	Context       interface{} `json:"@context"`
+	Type          string      `json:"type"`
+	SchemaVersion string      `json:"schemaVersion"`
+	CreationDate  string      `json:"creationDate"`
+	EPCISBody     EPCISBody   `json:"epcisBody"`
+}
+
+// EPCISBody model
+type EPCISBody struct {
+	EventList []json.RawMessage `json:"eventList"`
+}
+
+// EPCISEvent model, the fields of ObjectEvent, AggregationEvent and
+// TransformationEvent which are mapped to a Log
+type EPCISEvent struct {
+	Type               string          `json:"type"`
+	EventID            string          `json:"eventID"`
+	EventTime          string          `json:"eventTime"`
+	BizStep            string          `json:"bizStep"`
+	ReadPoint          *EPCISLocation  `json:"readPoint"`
+	BizLocation        *EPCISLocation  `json:"bizLocation"`
+	EPCList            []string        `json:"epcList"`
+	QuantityList       []EPCISQuantity `json:"quantityList"`
+	ParentID           string          `json:"parentID"`
+	ChildEPCs          []string        `json:"childEPCs"`
+	ChildQuantityList  []EPCISQuantity `json:"childQuantityList"`
+	InputEPCList       []string        `json:"inputEPCList"`
+	InputQuantityList  []EPCISQuantity `json:"inputQuantityList"`
+	OutputEPCList      []string        `json:"outputEPCList"`
+	OutputQuantityList []EPCISQuantity `json:"outputQuantityList"`
+}
+
+// EPCISLocation model
+type EPCISLocation struct {
+	ID string `json:"id"`
+}
+
+// EPCISQuantity model
+type EPCISQuantity struct {
+	EPCClass string  `json:"epcClass"`
+	Quantity float64 `json:"quantity,omitempty"`
+	UOM      string  `json:"uom,omitempty"`
+}
+
+// Tombstone model, left behind by a deleted or archived object. Data holds
+// the archived object.
+type Tombstone struct {
+	ObjectType  string          `json:"objectType"`
+	ID          string          `json:"id"`
+	DeletedType string          `json:"deletedType"`
+	Reason      string          `json:"reason"`
+	Deleter     Creator         `json:"deleter"`
+	Archived    bool            `json:"archived"`
+	Data        json.RawMessage `json:"data,omitempty"`
+}
+
+// Creator model, set by the chaincode from the identity of the client
+// which submitted the transaction
+type Creator struct {
+	MSPID   string `json:"mspId"`
+	Subject string `json:"subject"`
+	Time    int64  `json:"time"`
+}
+
+// Log model. Products are the products of the Log besides Product, such as
+// the further EPCs of an EPCIS event, the Log is indexed under each of them.
 type Log struct {
-	ObjectType  string   `json:"objectType"`
-	ID          string   `json:"id"`
-	Time        int64    `json:"time"`
-	Ref         []string `json:"ref"`
-	CTE         string   `json:"cte"`
-	Supplychain string   `json:"supplychain_id"`
-	Content     string   `json:"content"`
-	Asset       string   `json:"asset"`
-	Product     string   `json:"product"`
-	Location    string   `json:"location"`
+	ObjectType    string            `json:"objectType"`
+	ID            string            `json:"id"`
+	Time          int64             `json:"time"`
+	Ref           []string          `json:"ref"`
+	CTE           string            `json:"cte"`
+	Supplychain   string            `json:"supplychain_id"`
+	Content       string            `json:"content"`
+	Asset         string            `json:"asset"`
+	Product       string            `json:"product"`
+	Products      []string          `json:"products,omitempty"`
+	Location      string            `json:"location"`
+	GeoLocation   *GeoLocation      `json:"geoLocation,omitempty"`
+	Documents     []DocumentRef     `json:"documents,omitempty"`
+	PrivateHashes map[string]string `json:"privateHashes,omitempty"`
+	Creator       Creator           `json:"creator"`
+	Version       int64             `json:"version"`
+	SchemaVersion int64             `json:"schemaVersion"`
+}
+
+// LogPrivate model, the commercially sensitive fields of a Log. It is
+// stored in COLLECTION_LOG_PRIVATE under the ID of the Log, the Log holds
+// the salted hash of every field in PrivateHashes.
+type LogPrivate struct {
+	ObjectType string            `json:"objectType"`
+	ID         string            `json:"id"`
+	Fields     map[string]string `json:"fields"`
+	Salts      map[string]string `json:"salts"`
+}
+
+// PrivateFieldVerification model, the result of verifyPrivateField
+type PrivateFieldVerification struct {
+	LogID    string `json:"logID"`
+	Field    string `json:"field"`
+	Verified bool   `json:"verified"`
+}
+
+// DocumentRef model, an off-chain document anchored by its SHA-256 digest
+type DocumentRef struct {
+	URI       string `json:"uri"`
+	MediaType string `json:"mediaType"`
+	Digest    string `json:"sha256"`
+	Size      int64  `json:"size"`
+}
+
+// DocumentVerification model, the result of verifyDocument
+type DocumentVerification struct {
+	ObjectID string       `json:"objectID"`
+	Digest   string       `json:"sha256"`
+	Verified bool         `json:"verified"`
+	Document *DocumentRef `json:"document"`
+}
+
+// GeoLocation model, the structured location of a Log. FacilityID is the
+// GLN or another ID of the facility. Latitude and Longitude are optional
+// but set together.
+type GeoLocation struct {
+	FacilityID string   `json:"facilityId"`
+	Latitude   *float64 `json:"lat,omitempty"`
+	Longitude  *float64 `json:"lon,omitempty"`
+}
+
+// HasCoordinates reports whether latitude and longitude are set
+func (g *GeoLocation) HasCoordinates() bool {
+	return g != nil && g.Latitude != nil && g.Longitude != nil
+}
+
+// Equals compare 2 locations
+func (g *GeoLocation) Equals(other *GeoLocation) bool {
+	if g == nil || other == nil {
+		return g == other
+	}
+	if g.FacilityID != other.FacilityID || g.HasCoordinates() != other.HasCoordinates() {
+		return false
+	}
+	return !g.HasCoordinates() || (*g.Latitude == *other.Latitude && *g.Longitude == *other.Longitude)
 }
 
 // Equals compare 2 logs
@@ -76,9 +462,21 @@ func (l *Log) Equals(other Log) bool {
 	if l.Product != other.Product {
 		return false
 	}
+	if !sameStrings(l.Products, other.Products) {
+		return false
+	}
 	if l.Location != other.Location {
 		return false
 	}
+	if !l.GeoLocation.Equals(other.GeoLocation) {
+		return false
+	}
+	if !sameDocuments(l.Documents, other.Documents) {
+		return false
+	}
+	if !sameStringMaps(l.PrivateHashes, other.PrivateHashes) {
+		return false
+	}
 	if len(l.Ref) != len(other.Ref) {
 		return false
 	}
@@ -96,21 +494,224 @@ func (l *Log) Equals(other Log) bool {
 	return true
 }
 
+// LogsPage model
+type LogsPage struct {
+	Logs                []Log  `json:"logs"`
+	Bookmark            string `json:"bookmark"`
+	FetchedRecordsCount int32  `json:"fetchedRecordsCount"`
+}
+
+// TraceNode model
+type TraceNode struct {
+	ID         string          `json:"id"`
+	ObjectType string          `json:"objectType"`
+	Depth      int             `json:"depth"`
+	Data       json.RawMessage `json:"data"`
+}
+
+// TraceEdge model
+type TraceEdge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	Type string `json:"type"`
+}
+
+// TraceGraph model. When Truncated is set some edges point to objects
+// beyond the depth limit which are not part of Nodes.
+type TraceGraph struct {
+	Root      string      `json:"root"`
+	Direction string      `json:"direction"`
+	MaxDepth  int         `json:"maxDepth"`
+	Truncated bool        `json:"truncated"`
+	Nodes     []TraceNode `json:"nodes"`
+	Edges     []TraceEdge `json:"edges"`
+	Missing   []string    `json:"missing"`
+}
+
 // Auditor model
 type Auditor struct {
-	ObjectType string `json:"objectType"`
-	ID         string `json:"id"`
-	Name       string `json:"name"`
-	Content    string `json:"content"`
+	ObjectType     string          `json:"objectType"`
+	ID             string          `json:"id"`
+	Name           string          `json:"name"`
+	Content        string          `json:"content"`
+	Accreditations []Accreditation `json:"accreditations,omitempty"`
+	Version        int64           `json:"version"`
+	SchemaVersion  int64           `json:"schemaVersion"`
+}
+
+// Equals compare 2 auditors
+func (a *Auditor) Equals(other Auditor) bool {
+	if a.ObjectType != other.ObjectType || a.ID != other.ID || a.Name != other.Name || a.Content != other.Content {
+		return false
+	}
+	if len(a.Accreditations) != len(other.Accreditations) {
+		return false
+	}
+	for i, accreditation := range a.Accreditations {
+		if !accreditation.Equals(other.Accreditations[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// Accreditation model. An empty scope covers all object types or CTEs.
+type Accreditation struct {
+	Body        string   `json:"accreditingBody"`
+	ObjectTypes []string `json:"objectTypes,omitempty"`
+	CTEs        []string `json:"ctes,omitempty"`
+	ValidFrom   int64    `json:"validFrom"`
+	ValidTo     int64    `json:"validTo"`
+	Suspended   bool     `json:"suspended"`
+}
+
+// Equals compare 2 accreditations
+func (a *Accreditation) Equals(other Accreditation) bool {
+	if a.Body != other.Body || a.ValidFrom != other.ValidFrom || a.ValidTo != other.ValidTo || a.Suspended != other.Suspended {
+		return false
+	}
+	return sameStrings(a.ObjectTypes, other.ObjectTypes) && sameStrings(a.CTEs, other.CTEs)
+}
+
+func sameDocuments(documents []DocumentRef, others []DocumentRef) bool {
+	if len(documents) != len(others) {
+		return false
+	}
+	for i, document := range documents {
+		if document != others[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func sameStringMaps(values map[string]string, others map[string]string) bool {
+	if len(values) != len(others) {
+		return false
+	}
+	for key, value := range values {
+		if other, ok := others[key]; !ok || other != value {
+			return false
+		}
+	}
+	return true
+}
+
+func sameStrings(values []string, others []string) bool {
+	if len(values) != len(others) {
+		return false
+	}
+	for i, value := range values {
+		if value != others[i] {
+			return false
+		}
+	}
+	return true
 }
 
 // AuditAction model
 type AuditAction struct {
-	ObjectType string `json:"objectType"`
-	ID         string `json:"id"`
-	Time       int64  `json:"time"`
-	Auditor    string `json:"auditor"`
-	Location   string `json:"location"`
-	ObjectID   string `json:"objectID"`
-	Content    string `json:"content"`
+	ObjectType        string             `json:"objectType"`
+	ID                string             `json:"id"`
+	Time              int64              `json:"time"`
+	Auditor           string             `json:"auditor"`
+	Location          string             `json:"location"`
+	ObjectID          string             `json:"objectID"`
+	Content           string             `json:"content"`
+	Status            string             `json:"status"`
+	Findings          []AuditFinding     `json:"findings,omitempty"`
+	CorrectiveActions []CorrectiveAction `json:"correctiveActions,omitempty"`
+	Documents         []DocumentRef      `json:"documents,omitempty"`
+	Creator           Creator            `json:"creator"`
+	Version           int64              `json:"version"`
+	SchemaVersion     int64              `json:"schemaVersion"`
+}
+
+// Equals compare 2 audit actions
+func (a *AuditAction) Equals(other AuditAction) bool {
+	if a.ObjectType != other.ObjectType || a.ID != other.ID || a.Time != other.Time {
+		return false
+	}
+	if a.Auditor != other.Auditor || a.Location != other.Location || a.ObjectID != other.ObjectID {
+		return false
+	}
+	if a.Content != other.Content || a.Status != other.Status {
+		return false
+	}
+	if !sameDocuments(a.Documents, other.Documents) {
+		return false
+	}
+	if len(a.Findings) != len(other.Findings) || len(a.CorrectiveActions) != len(other.CorrectiveActions) {
+		return false
+	}
+	for i, finding := range a.Findings {
+		if finding != other.Findings[i] {
+			return false
+		}
+	}
+	for i, action := range a.CorrectiveActions {
+		if action != other.CorrectiveActions[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// AuditFilter model, the options of getAuditOfObject. Empty fields and a
+// zero time do not filter.
+type AuditFilter struct {
+	Auditor string `json:"auditor"`
+	From    int64  `json:"from"`
+	To      int64  `json:"to"`
+	Outcome string `json:"outcome"`
+	Sort    string `json:"sort"`
+}
+
+// AuditSummary model
+type AuditSummary struct {
+	Count         int          `json:"count"`
+	Latest        *AuditAction `json:"latest"`
+	LatestOutcome string       `json:"latestOutcome"`
+}
+
+// AuditsOfObject model
+type AuditsOfObject struct {
+	ObjectID string        `json:"objectID"`
+	Audits   []AuditAction `json:"audits"`
+	Summary  AuditSummary  `json:"summary"`
+}
+
+// AuditFinding model, the result of checking one clause
+type AuditFinding struct {
+	ID       string  `json:"id"`
+	Clause   string  `json:"clause"`
+	Severity string  `json:"severity"`
+	Passed   bool    `json:"passed"`
+	Score    float64 `json:"score"`
+	Note     string  `json:"note"`
+}
+
+// CorrectiveAction model, raised for a failed finding. All corrective
+// actions are resolved before an audit closes.
+type CorrectiveAction struct {
+	ID          string `json:"id"`
+	FindingID   string `json:"findingId"`
+	Description string `json:"description"`
+	Owner       string `json:"owner"`
+	DueDate     int64  `json:"dueDate"`
+	Status      string `json:"status"`
+	Resolved    int64  `json:"resolved"`
+}
+
+// MigrationProgress model, the progress of migrating the objects of Type to
+// SchemaVersion. Scanned and Migrated count all batches since the first
+// one, Bookmark is the key of the last object of the batch and empty when
+// the migration is done.
+type MigrationProgress struct {
+	Type          string `json:"type"`
+	SchemaVersion int64  `json:"schemaVersion"`
+	Scanned       int    `json:"scanned"`
+	Migrated      int    `json:"migrated"`
+	Bookmark      string `json:"bookmark"`
+	Done          bool   `json:"done"`
 }
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+
+	"github.com/deevotech/sc-chaincode.deevo.io/lib/ccerror"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+// getTransient returns the transient map of the proposal. It is a variable
+// so tests can replace it.
+var getTransient = func(stub shim.ChaincodeStubInterface) (map[string][]byte, error) {
+	return stub.GetTransient()
+}
+
+// readLogPrivate returns the private fields passed for a Log. The transient
+// map holds TRANSIENT_LOG_PRIVATE, a JSON object of LogPrivate by Log ID, so
+// one map serves createLog, updateLog and bulkCreateLogs.
+func readLogPrivate(stub shim.ChaincodeStubInterface, logID string) (pb.Response, *LogPrivate) {
+	transient, err := getTransient(stub)
+	if err != nil {
+		return ccerror.Internal("Failed to get transient map: " + err.Error()), nil
+	}
+	privateAsBytes, ok := transient[TRANSIENT_LOG_PRIVATE]
+	if !ok {
+		return shim.Success(nil), nil
+	}
+
+	privates := map[string]LogPrivate{}
+	err = json.Unmarshal(privateAsBytes, &privates)
+	if err != nil {
+		return ccerror.ValidationFailed("Failed to decode json of " + TRANSIENT_LOG_PRIVATE + ": " + err.Error()), nil
+	}
+	private, ok := privates[logID]
+	if !ok {
+		return shim.Success(nil), nil
+	}
+	private.ObjectType = TYPE_LOG_PRIVATE
+	private.ID = logID
+
+	if len(private.Fields) == 0 {
+		return ccerror.ValidationFailed("Private fields of Log " + logID + " can not by empty"), nil
+	}
+	for field := range private.Fields {
+		if len(private.Salts[field]) < MIN_SALT_LENGTH {
+			return ccerror.ValidationFailed("Private field " + field + " of Log " + logID + " needs a salt of at least " + strconv.Itoa(MIN_SALT_LENGTH) + " characters"), nil
+		}
+	}
+	for field := range private.Salts {
+		if _, ok := private.Fields[field]; !ok {
+			return ccerror.ValidationFailed("Salt of Log " + logID + " has no private field " + field), nil
+		}
+	}
+	return shim.Success(nil), &private
+}
+
+// applyLogPrivate stores the private fields passed for a Log and sets their
+// hashes on it. Without private fields a new Log has no hashes and an
+// updated Log keeps the hashes of oldLog.
+func (t *FoodChaincode) applyLogPrivate(stub shim.ChaincodeStubInterface, newLog *Log, oldLog *Log) pb.Response {
+	result, private := readLogPrivate(stub, newLog.ID)
+	if result.Status != shim.OK {
+		return result
+	}
+
+	if private == nil {
+		if oldLog == nil {
+			newLog.PrivateHashes = nil
+		} else {
+			if len(newLog.PrivateHashes) > 0 && !sameStringMaps(newLog.PrivateHashes, oldLog.PrivateHashes) {
+				return ccerror.ValidationFailed("privateHashes of Log " + newLog.ID + " are set from the transient map")
+			}
+			newLog.PrivateHashes = oldLog.PrivateHashes
+		}
+		return shim.Success(nil)
+	}
+
+	newLog.PrivateHashes = map[string]string{}
+	for field, value := range private.Fields {
+		newLog.PrivateHashes[field] = privateHash(private.Salts[field], value)
+	}
+
+	privateAsBytes, err := json.Marshal(private)
+	if err != nil {
+		return ccerror.Internal("Failed to encode json of LogPrivate: " + err.Error())
+	}
+	err = stub.PutPrivateData(COLLECTION_LOG_PRIVATE, newLog.ID, privateAsBytes)
+	if err != nil {
+		return ccerror.Internal("Failed to put private data of Log " + newLog.ID + ": " + err.Error())
+	}
+	return shim.Success(nil)
+}
+
+func privateHash(salt string, value string) string {
+	hash := sha256.Sum256([]byte(salt + value))
+	return hex.EncodeToString(hash[:])
+}
+
+// verifyPrivateField lets anyone check a disclosed value and salt against
+// the hash on a Log, without access to the collection. The arguments are
+// not printed, they are the secret.
+func (t *FoodChaincode) verifyPrivateField(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	logger.Debug(stub, "start verifyPrivateField")
+
+	logID, field, value, salt := args[0], args[1], args[2], args[3]
+	logAsBytes, err := stub.GetState(logID)
+	if err != nil {
+		return ccerror.Internal("Failed to get existed Log with ID: " + logID + ", error: " + err.Error())
+	} else if logAsBytes == nil {
+		return ccerror.NotFound("Log with ID " + logID + " does not exist")
+	}
+	log := Log{}
+	err = json.Unmarshal(logAsBytes, &log)
+	if err != nil {
+		return ccerror.Internal("Failed to get decode Log: " + err.Error())
+	}
+	if log.ObjectType != TYPE_LOG {
+		return ccerror.TypeMismatch("Object with ID: " + logID + " is not a Log")
+	}
+	hash, ok := log.PrivateHashes[field]
+	if !ok {
+		return ccerror.ValidationFailed("Log " + logID + " has no private field " + field)
+	}
+
+	verification := PrivateFieldVerification{LogID: logID, Field: field, Verified: privateHash(salt, value) == hash}
+	verificationAsBytes, err := json.Marshal(verification)
+	if err != nil {
+		return ccerror.Internal("Failed to get encode response: " + err.Error())
+	}
+
+	logger.Debug(stub, "end verifyPrivateField (success)")
+	return shim.Success(verificationAsBytes)
+}
+
+// getLogPrivate returns the private fields of a Log, on peers of members of
+// the collection
+func (t *FoodChaincode) getLogPrivate(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	logger.Debug(stub, "start getLogPrivate")
+
+	privateAsBytes, err := stub.GetPrivateData(COLLECTION_LOG_PRIVATE, args[0])
+	if err != nil {
+		return ccerror.Internal("Failed to get private data of Log " + args[0] + ": " + err.Error())
+	} else if privateAsBytes == nil {
+		return ccerror.NotFound("Private data of Log " + args[0] + " does not exist")
+	}
+
+	logger.Debug(stub, "end getLogPrivate (success)")
+	return shim.Success(privateAsBytes)
+}
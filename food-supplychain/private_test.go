@@ -0,0 +1,136 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+const testSalt = "0123456789abcdef"
+
+// setMockTransient passes the private fields of Logs to the next invokes,
+// the MockStub has no transient map of its own
+func setMockTransient(privates map[string]LogPrivate) {
+	if privates == nil {
+		getTransient = func(stub shim.ChaincodeStubInterface) (map[string][]byte, error) {
+			return stub.GetTransient()
+		}
+		return
+	}
+	privatesAsBytes, _ := json.Marshal(privates)
+	getTransient = func(stub shim.ChaincodeStubInterface) (map[string][]byte, error) {
+		return map[string][]byte{TRANSIENT_LOG_PRIVATE: privatesAsBytes}, nil
+	}
+}
+
+func TestFood_CreateLogWithPrivateFields(t *testing.T) {
+	scc := new(FoodChaincode)
+	stub := shim.NewMockStub("food", scc)
+
+	checkInit(t, stub, [][]byte{})
+
+	setMockTransient(map[string]LogPrivate{"Log_1": {
+		Fields: map[string]string{"quantity": "1200", "price": "2.35", "supplier": "Acme Farms"},
+		Salts:  map[string]string{"quantity": testSalt, "price": testSalt + "1", "supplier": testSalt + "2"},
+	}})
+	defer setMockTransient(nil)
+	checkCreateLogAt(t, stub, Log{ObjectType: TYPE_LOG, ID: "Log_1", Time: 100, Ref: []string{}, CTE: "shipping"})
+
+	log := Log{}
+	json.Unmarshal(stub.State["Log_1"], &log)
+	if len(log.PrivateHashes) != 3 || log.PrivateHashes["quantity"] != privateHash(testSalt, "1200") {
+		fmt.Println("Log should hold the hashes of the private fields", log.PrivateHashes)
+		t.FailNow()
+	}
+	if stub.PvtState[COLLECTION_LOG_PRIVATE]["Log_1"] == nil {
+		fmt.Println("Private fields should be in the collection")
+		t.FailNow()
+	}
+
+	res := stub.MockInvoke("1", [][]byte{[]byte("getLogPrivate"), []byte("Log_1")})
+	if res.Status != shim.OK {
+		fmt.Println("failed", string(res.Message))
+		t.FailNow()
+	}
+	private := LogPrivate{}
+	json.Unmarshal(res.Payload, &private)
+	if private.Fields["supplier"] != "Acme Farms" {
+		fmt.Println("Private fields were not as expected", private)
+		t.FailNow()
+	}
+
+	// updates without private fields keep the hashes
+	setMockTransient(nil)
+	updatedLog := Log{ObjectType: TYPE_LOG, ID: "Log_1", Time: 100, Ref: []string{}, CTE: "shipping", Location: "Location_1", Version: 1}
+	updatedLogAsBytes, err := json.Marshal(updatedLog)
+	if err != nil {
+		fmt.Println("Failed to encode json")
+		t.FailNow()
+	}
+	res = stub.MockInvoke("1", [][]byte{[]byte("updateLog"), updatedLogAsBytes})
+	if res.Status != shim.OK {
+		fmt.Println("failed", string(res.Message))
+		t.FailNow()
+	}
+	log = Log{}
+	json.Unmarshal(stub.State["Log_1"], &log)
+	if len(log.PrivateHashes) != 3 {
+		fmt.Println("Update should keep the hashes", log.PrivateHashes)
+		t.FailNow()
+	}
+}
+
+func TestFood_VerifyPrivateField(t *testing.T) {
+	scc := new(FoodChaincode)
+	stub := shim.NewMockStub("food", scc)
+
+	checkInit(t, stub, [][]byte{})
+
+	setMockTransient(map[string]LogPrivate{"Log_1": {
+		Fields: map[string]string{"price": "2.35"},
+		Salts:  map[string]string{"price": testSalt},
+	}})
+	defer setMockTransient(nil)
+	checkCreateLogAt(t, stub, Log{ObjectType: TYPE_LOG, ID: "Log_1", Time: 100, Ref: []string{}, CTE: "shipping"})
+
+	for value, expected := range map[string]bool{"2.35": true, "2.30": false} {
+		res := stub.MockInvoke("1", [][]byte{[]byte("verifyPrivateField"), []byte("Log_1"), []byte("price"), []byte(value), []byte(testSalt)})
+		if res.Status != shim.OK {
+			fmt.Println("failed", string(res.Message))
+			t.FailNow()
+		}
+		verification := PrivateFieldVerification{}
+		json.Unmarshal(res.Payload, &verification)
+		if verification.Verified != expected {
+			fmt.Println("Verification of price", value, "was not", expected)
+			t.FailNow()
+		}
+	}
+}
+
+func TestFood_CreateLogWithInvalidPrivateFields(t *testing.T) {
+	scc := new(FoodChaincode)
+	stub := shim.NewMockStub("food", scc)
+
+	checkInit(t, stub, [][]byte{})
+
+	setMockTransient(map[string]LogPrivate{"Log_1": {
+		Fields: map[string]string{"price": "2.35"},
+		Salts:  map[string]string{"price": "short"},
+	}})
+	defer setMockTransient(nil)
+	res := stub.MockInvoke("1", [][]byte{[]byte("createLog"), []byte(`{"objectType": "log", "id": "Log_1", "time": 100, "ref": [], "cte": "shipping"}`)})
+	if res.Status == shim.OK {
+		fmt.Println("createLog should fail for a short salt")
+		t.FailNow()
+	}
+
+	setMockTransient(nil)
+	res = stub.MockInvoke("1", [][]byte{[]byte("createLog"), []byte(`{"objectType": "log", "id": "Log_1", "time": 100, "ref": [], "cte": "shipping", "privateHashes": {"price": "abc"}}`)})
+	if res.Status == shim.OK {
+		fmt.Println("createLog should fail for hashes which are not from the transient map")
+		t.FailNow()
+	}
+}
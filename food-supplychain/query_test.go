@@ -87,6 +87,7 @@ func TestFood_QueryAudit(t *testing.T) {
 		Time:       time.Now().Unix(),
 		Location:   "Location_1",
 		ObjectID:   "Log_1",
+		Status:     AUDIT_SCHEDULED,
 	}
 	newAuditActionAsBytes, err := json.Marshal(newAuditAction)
 	if err != nil {
@@ -107,13 +108,19 @@ func TestFood_QueryAudit(t *testing.T) {
 		t.FailNow()
 	}
 
-	resAudit := AuditAction{}
-	err = json.Unmarshal(res.Payload, &resAudit)
+	resAudits := AuditsOfObject{}
+	err = json.Unmarshal(res.Payload, &resAudits)
 	if err != nil {
 		fmt.Println("Failed to decode json of Log:", err.Error())
 		t.FailNow()
 	}
-	if resAudit != newAuditAction {
+	if len(resAudits.Audits) != 1 || resAudits.Summary.Count != 1 {
+		fmt.Println("Query value was not as expected")
+		t.FailNow()
+	}
+	resAudit := resAudits.Audits[0]
+	checkCreator(t, resAudit.Creator, TEST_MSP)
+	if !resAudit.Equals(newAuditAction) {
 		fmt.Println("Query value was not as expected")
 		t.FailNow()
 	}
@@ -136,7 +143,11 @@ func TestFood_QueryAudit(t *testing.T) {
 		fmt.Println("Failed to decode json of Log:", err.Error())
 		t.FailNow()
 	}
-	if len(resAudit2) != 1 || resAudit2[0] != newAuditAction {
+	if len(resAudit2) != 1 {
+		fmt.Println("Query value was not as expected")
+		t.FailNow()
+	}
+	if !resAudit2[0].Equals(newAuditAction) {
 		fmt.Println("Query value was not as expected")
 		t.FailNow()
 	}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"github.com/deevotech/sc-chaincode.deevo.io/lib/ccerror"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+// getLogsOfSupplychainInRange returns the logs of a supply chain between two
+// times, sorted by time. Arguments are the supply chain, the first and the
+// last time in seconds and optionally the page size and bookmark.
+func (t *FoodChaincode) getLogsOfSupplychainInRange(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	logger.Debug(stub, "start getLogsOfSupplychainInRange")
+
+	result := t.getLogsInRange(stub, args, CK_SC_TIME_LOG, TYPE_SUPPLYCHAIN)
+
+	if result.Status == shim.OK {
+		logger.Debug(stub, "end getLogsOfSupplychainInRange (success)")
+	}
+	return result
+}
+
+// getLogsOfProductInRange is getLogsOfSupplychainInRange for a product
+func (t *FoodChaincode) getLogsOfProductInRange(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	logger.Debug(stub, "start getLogsOfProductInRange")
+
+	result := t.getLogsInRange(stub, args, CK_PRODUCT_TIME_LOG, TYPE_PRODUCT)
+
+	if result.Status == shim.OK {
+		logger.Debug(stub, "end getLogsOfProductInRange (success)")
+	}
+	return result
+}
+
+// getLogsInRange seeks to From in the time ordered index and reads its
+// pages up to To, composite keys can not be used with GetStateByRange. The
+// bookmark is the key the next page starts at.
+func (t *FoodChaincode) getLogsInRange(stub shim.ChaincodeStubInterface, args []string, index string, objectType string) pb.Response {
+
+	ID := args[0]
+	from, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		return ccerror.ValidationFailed("From must be a time in seconds")
+	}
+	to, err := strconv.ParseInt(args[2], 10, 64)
+	if err != nil {
+		return ccerror.ValidationFailed("To must be a time in seconds")
+	}
+	if from > to {
+		return ccerror.ValidationFailed("From can not be after To")
+	}
+
+	pageSize := int32(0)
+	bookmark := ""
+	if len(args) == 5 {
+		var result pb.Response
+		result, pageSize = parsePageSize(args[3])
+		if result.Status != shim.OK {
+			return result
+		}
+		bookmark = args[4]
+	}
+
+	existedObjectAsBytes, err := stub.GetState(ID)
+	if err != nil {
+		return ccerror.Internal("Failed to get existed Object with ID: " + ID + ", error: " + err.Error())
+	} else if existedObjectAsBytes == nil {
+		return ccerror.NotFound("Object with ID " + ID + " does not exist")
+	}
+	liteModel := LiteModel{}
+	err = json.Unmarshal(existedObjectAsBytes, &liteModel)
+	if err != nil {
+		return ccerror.Internal("Failed to get decode object: " + err.Error())
+	}
+	if liteModel.ObjectType != objectType {
+		return ccerror.TypeMismatch("Object with ID: " + ID + " is not a " + objectType)
+	}
+
+	fromKey, err := stub.CreateCompositeKey(index, []string{ID, timeKeyPart(from)})
+	if err != nil {
+		return ccerror.Internal("Failed to create composite key: " + err.Error())
+	}
+	if len(bookmark) > 0 {
+		bookmarkIndex, bookmarkParts, err := stub.SplitCompositeKey(bookmark)
+		if err != nil || bookmarkIndex != index || len(bookmarkParts) != 3 || bookmarkParts[0] != ID {
+			return ccerror.ValidationFailed("Bookmark is not a key of the logs of " + ID)
+		}
+	}
+	if bookmark < fromKey {
+		bookmark = fromKey
+	}
+
+	toKey := timeKeyPart(to)
+	page := LogsPage{Logs: []Log{}}
+	for {
+		limit := pageSize
+		if limit == 0 {
+			limit = MAX_PAGE_SIZE
+		}
+		result, logs, next := t.readTimeIndexPage(stub, index, ID, toKey, limit, bookmark)
+		if result.Status != shim.OK {
+			return result
+		}
+		page.Logs = append(page.Logs, logs...)
+		bookmark = next
+
+		// without a page size every page up to To is read
+		if pageSize > 0 || len(bookmark) == 0 {
+			break
+		}
+	}
+	page.Bookmark = bookmark
+	page.FetchedRecordsCount = int32(len(page.Logs))
+
+	pageAsBytes, err := json.Marshal(page)
+	if err != nil {
+		return ccerror.Internal("Failed to get encode response: " + err.Error())
+	}
+	return shim.Success(pageAsBytes)
+}
+
+// readTimeIndexPage reads a page of a time ordered index which starts at the
+// bookmark. It returns the bookmark of the next page, which is empty when
+// the next page would start after toKey.
+func (t *FoodChaincode) readTimeIndexPage(stub shim.ChaincodeStubInterface, index string, ID string, toKey string, pageSize int32, bookmark string) (pb.Response, []Log, string) {
+	resultsIterator, metadata, err := stub.GetStateByPartialCompositeKeyWithPagination(index, []string{ID}, pageSize, bookmark)
+	if err != nil {
+		return ccerror.Internal(err.Error()), nil, ""
+	} else if resultsIterator == nil {
+		return ccerror.Internal("Paged query of " + index + " returned no iterator"), nil, ""
+	}
+	defer resultsIterator.Close()
+
+	logs := []Log{}
+	for resultsIterator.HasNext() {
+		responseRange, err := resultsIterator.Next()
+		if err != nil {
+			return ccerror.Internal(err.Error()), nil, ""
+		}
+
+		_, compositeKeyParts, err := stub.SplitCompositeKey(responseRange.Key)
+		if err != nil {
+			return ccerror.Internal(err.Error()), nil, ""
+		}
+		if compositeKeyParts[1] > toKey {
+			return shim.Success(nil), logs, ""
+		}
+
+		logID := compositeKeyParts[2]
+		logAsBytes, err := stub.GetState(logID)
+		if err != nil {
+			return ccerror.Internal("Failed to get existed Log with ID: " + logID + ", error: " + err.Error()), nil, ""
+		} else if logAsBytes == nil {
+			return ccerror.NotFound("Log with ID " + logID + " does not exist"), nil, ""
+		}
+		log := Log{}
+		err = json.Unmarshal(logAsBytes, &log)
+		if err != nil {
+			return ccerror.Internal("Failed to get decode log: " + err.Error()), nil, ""
+		}
+		logs = append(logs, log)
+	}
+
+	if metadata == nil || len(metadata.Bookmark) == 0 {
+		return shim.Success(nil), logs, ""
+	}
+	_, compositeKeyParts, err := stub.SplitCompositeKey(metadata.Bookmark)
+	if err != nil {
+		return ccerror.Internal(err.Error()), nil, ""
+	}
+	if compositeKeyParts[1] > toKey {
+		return shim.Success(nil), logs, ""
+	}
+	return shim.Success(nil), logs, metadata.Bookmark
+}
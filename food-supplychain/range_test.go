@@ -0,0 +1,141 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"testing"
+
+	"github.com/deevotech/sc-chaincode.deevo.io/lib/ccerror"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+func initRangeData(t *testing.T, stub *shim.MockStub) {
+	checkInit(t, stub, [][]byte{})
+	checkCreateReferences(t, stub, map[string]string{"sc_1": TYPE_SUPPLYCHAIN, "Product_1": TYPE_PRODUCT, "Product_2": TYPE_PRODUCT})
+
+	// created out of order, the index sorts them by time
+	times := []int64{300, 100, 500, 200, 400}
+	for i, logTime := range times {
+		newLog := Log{
+			ObjectType:  TYPE_LOG,
+			ID:          "Log_" + strconv.Itoa(i+1),
+			Time:        logTime,
+			Ref:         []string{},
+			CTE:         "shipping",
+			Supplychain: "sc_1",
+			Product:     "Product_1",
+		}
+		newLogAsBytes, err := json.Marshal(newLog)
+		if err != nil {
+			fmt.Println("Failed to encode json")
+			t.FailNow()
+		}
+		res := stub.MockInvoke("1", [][]byte{[]byte("createLog"), newLogAsBytes})
+		if res.Status != shim.OK {
+			fmt.Println("failed", string(res.Message))
+			t.FailNow()
+		}
+	}
+}
+
+func TestFood_GetLogsOfSupplychainInRange(t *testing.T) {
+	scc := new(FoodChaincode)
+	stub := shim.NewMockStub("food", scc)
+
+	initRangeData(t, stub)
+
+	page := checkLogsInRange(t, scc, stub, "getLogsOfSupplychainInRange", "sc_1", "150", "450")
+	checkLogIDs(t, page.Logs, []string{"Log_4", "Log_1", "Log_5"})
+	if len(page.Bookmark) != 0 {
+		fmt.Println("Bookmark should be empty on the last page")
+		t.FailNow()
+	}
+
+	page = checkLogsInRange(t, scc, stub, "getLogsOfSupplychainInRange", "sc_1", "0", "1000", "2", "")
+	checkLogIDs(t, page.Logs, []string{"Log_2", "Log_4"})
+	page = checkLogsInRange(t, scc, stub, "getLogsOfSupplychainInRange", "sc_1", "0", "1000", "2", page.Bookmark)
+	checkLogIDs(t, page.Logs, []string{"Log_1", "Log_5"})
+	page = checkLogsInRange(t, scc, stub, "getLogsOfSupplychainInRange", "sc_1", "0", "1000", "2", page.Bookmark)
+	checkLogIDs(t, page.Logs, []string{"Log_3"})
+
+	// pages seek to From and end at To
+	page = checkLogsInRange(t, scc, stub, "getLogsOfSupplychainInRange", "sc_1", "150", "450", "2", "")
+	checkLogIDs(t, page.Logs, []string{"Log_4", "Log_1"})
+	page = checkLogsInRange(t, scc, stub, "getLogsOfSupplychainInRange", "sc_1", "150", "450", "2", page.Bookmark)
+	checkLogIDs(t, page.Logs, []string{"Log_5"})
+	if len(page.Bookmark) != 0 {
+		fmt.Println("Bookmark should be empty when the next page starts after To")
+		t.FailNow()
+	}
+
+	bookmark, _ := stub.CreateCompositeKey(CK_PRODUCT_TIME_LOG, []string{"Product_1", timeKeyPart(300), "Log_1"})
+	res := invokePaged(scc, stub, [][]byte{[]byte("getLogsOfSupplychainInRange"), []byte("sc_1"), []byte("0"), []byte("1000"), []byte("2"), []byte(bookmark)})
+	checkErrorCode(t, res, ccerror.VALIDATION_FAILED)
+}
+
+func TestFood_GetLogsOfProductInRangeAfterUpdate(t *testing.T) {
+	scc := new(FoodChaincode)
+	stub := shim.NewMockStub("food", scc)
+
+	initRangeData(t, stub)
+
+	updatedLog := Log{ObjectType: TYPE_LOG, ID: "Log_2", Time: 600, Ref: []string{}, CTE: "shipping", Supplychain: "sc_1", Product: "Product_2", Version: 1}
+	updatedLogAsBytes, err := json.Marshal(updatedLog)
+	if err != nil {
+		fmt.Println("Failed to encode json")
+		t.FailNow()
+	}
+	res := stub.MockInvoke("1", [][]byte{[]byte("updateLog"), updatedLogAsBytes})
+	if res.Status != shim.OK {
+		fmt.Println("failed", string(res.Message))
+		t.FailNow()
+	}
+
+	page := checkLogsInRange(t, scc, stub, "getLogsOfProductInRange", "Product_1", "0", "1000")
+	checkLogIDs(t, page.Logs, []string{"Log_4", "Log_1", "Log_5", "Log_3"})
+	page = checkLogsInRange(t, scc, stub, "getLogsOfProductInRange", "Product_2", "0", "1000")
+	checkLogIDs(t, page.Logs, []string{"Log_2"})
+	page = checkLogsInRange(t, scc, stub, "getLogsOfSupplychainInRange", "sc_1", "450", "1000")
+	checkLogIDs(t, page.Logs, []string{"Log_3", "Log_2"})
+
+	res = stub.MockInvoke("1", [][]byte{[]byte("getLogsOfProductInRange"), []byte("sc_1"), []byte("0"), []byte("1000")})
+	if res.Status == shim.OK {
+		fmt.Println("getLogsOfProductInRange should fail for a supply chain")
+		t.FailNow()
+	}
+}
+
+func checkLogsInRange(t *testing.T, scc *FoodChaincode, stub *shim.MockStub, function string, args ...string) LogsPage {
+	invokeArgs := [][]byte{[]byte(function)}
+	for _, arg := range args {
+		invokeArgs = append(invokeArgs, []byte(arg))
+	}
+	res := invokePaged(scc, stub, invokeArgs)
+	if res.Status != shim.OK {
+		fmt.Println("failed", string(res.Message))
+		t.FailNow()
+	}
+
+	page := LogsPage{}
+	err := json.Unmarshal(res.Payload, &page)
+	if err != nil {
+		fmt.Println("Failed to decode json of LogsPage:", err.Error())
+		t.FailNow()
+	}
+	return page
+}
+
+func checkLogIDs(t *testing.T, logs []Log, IDs []string) {
+	if len(logs) != len(IDs) {
+		fmt.Println("Expected", len(IDs), "logs, got", len(logs))
+		t.FailNow()
+	}
+	for i, log := range logs {
+		if log.ID != IDs[i] {
+			fmt.Println("Expected log", IDs[i], "at", i, "got", log.ID)
+			t.FailNow()
+		}
+	}
+}
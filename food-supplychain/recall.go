@@ -0,0 +1,357 @@
+package main
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/deevotech/sc-chaincode.deevo.io/lib/ccerror"
+	"github.com/deevotech/sc-chaincode.deevo.io/lib/logging"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+// recallTransitions lists the statuses a recall can move to
+var recallTransitions = map[string][]string{
+	RECALL_OPEN:     {RECALL_NOTIFIED, RECALL_CLOSED},
+	RECALL_NOTIFIED: {RECALL_CLOSED},
+}
+
+// Methods on Recall
+// ========================================
+
+// initiateRecall opens a recall of a lot. Everything derived from the lot
+// is found by tracing forward over Log Refs and Parent links, the
+// organisations which logged them and the ones listed by the initiator have
+// to acknowledge the recall. A recall which affects no organisation is
+// rejected.
+func (t *FoodChaincode) initiateRecall(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	logger.Debug(stub, "start initiateRecall")
+
+	newRecall := Recall{}
+	err := json.Unmarshal([]byte(args[0]), &newRecall)
+	if err != nil {
+		return ccerror.ValidationFailed("Failed to decode json of Recall: " + err.Error())
+	}
+	if newRecall.ObjectType != TYPE_RECALL {
+		return ccerror.TypeMismatch("Expexted objectType " + TYPE_RECALL + " for Recall")
+	}
+	if len(newRecall.ID) < 1 {
+		return ccerror.ValidationFailed("ID can not by empty")
+	}
+	if len(newRecall.Reason) < 1 {
+		return ccerror.ValidationFailed("Reason can not by empty")
+	}
+	if newRecall.Severity != SEVERITY_HIGH && newRecall.Severity != SEVERITY_MEDIUM && newRecall.Severity != SEVERITY_LOW {
+		return ccerror.ValidationFailed("Severity must be " + SEVERITY_HIGH + ", " + SEVERITY_MEDIUM + " or " + SEVERITY_LOW)
+	}
+	result := t.checkNotExists(stub, newRecall.ID)
+	if result.Status != shim.OK {
+		return result
+	}
+
+	result, _ = t.getTraceable(stub, newRecall.Root)
+	if result.Status != shim.OK {
+		return result
+	}
+
+	result, initiator := t.getCreator(stub)
+	if result.Status != shim.OK {
+		return result
+	}
+
+	result, graph := t.buildTraceGraph(stub, newRecall.Root, TRACE_FORWARD, MAX_TRACE_DEPTH)
+	if result.Status != shim.OK {
+		return result
+	}
+
+	newRecall.Status = RECALL_OPEN
+	newRecall.Affected = []string{}
+	newRecall.Truncated = graph.Truncated
+	newRecall.Acknowledgements = []RecallAcknowledgement{}
+	newRecall.Initiator = initiator
+	newRecall.Updated = initiator.Time
+
+	// the initiator can list organisations which logged nothing on the trace
+	listed := newRecall.Organisations
+	newRecall.Organisations = []string{}
+	organisations := map[string]bool{}
+	for _, mspID := range listed {
+		if len(mspID) > 0 && !organisations[mspID] {
+			organisations[mspID] = true
+			newRecall.Organisations = append(newRecall.Organisations, mspID)
+		}
+	}
+	for _, node := range graph.Nodes {
+		if isTraceableType(node.ObjectType) {
+			newRecall.Affected = append(newRecall.Affected, node.ID)
+		} else if node.ObjectType == TYPE_LOG {
+			log := Log{}
+			err = json.Unmarshal(node.Data, &log)
+			if err != nil {
+				return ccerror.Internal("Failed to get decode Log: " + err.Error())
+			}
+			if len(log.Creator.MSPID) > 0 && !organisations[log.Creator.MSPID] {
+				organisations[log.Creator.MSPID] = true
+				newRecall.Organisations = append(newRecall.Organisations, log.Creator.MSPID)
+			}
+		}
+	}
+	if len(newRecall.Organisations) == 0 {
+		return ccerror.ValidationFailed("Recall " + newRecall.ID + " affects no organisation, list the organisations which have to acknowledge it")
+	}
+	sort.Strings(newRecall.Organisations)
+	newRecall.Version = 1
+	newRecall.SchemaVersion = SCHEMA_VERSION
+
+	recallAsBytes, err := json.Marshal(newRecall)
+	if err != nil {
+		return ccerror.Internal("Failed to encode json of Recall: " + err.Error())
+	}
+	result = t.createObject(stub, recallAsBytes, newRecall.ID)
+	if result.Status != shim.OK {
+		return result
+	}
+
+	result = t.putCompositeKey(stub, CK_RECALL_STATUS, []string{newRecall.Status, newRecall.ID})
+	if result.Status != shim.OK {
+		return result
+	}
+	for _, affected := range newRecall.Affected {
+		result = t.putCompositeKey(stub, CK_AFFECTED_RECALL, []string{affected, newRecall.ID})
+		if result.Status != shim.OK {
+			return result
+		}
+	}
+
+	result = t.setChangeEvent(stub, ChangeEvent{
+		EventType:  EVENT_RECALL_INITIATED,
+		ObjectID:   newRecall.ID,
+		ObjectType: TYPE_RECALL,
+		Product:    newRecall.Root,
+		NewIndexes: map[string]string{CK_RECALL_STATUS: newRecall.Status},
+		ObjectIDs:  newRecall.Affected,
+	})
+	if result.Status != shim.OK {
+		return result
+	}
+
+	logger.Debug(stub, "end initiateRecall (success)", logging.Fields{"affected": len(newRecall.Affected)})
+	return shim.Success(recallAsBytes)
+}
+
+// updateRecallStatus moves a recall to notified or closed. Only the
+// organisation which initiated the recall can change its status.
+func (t *FoodChaincode) updateRecallStatus(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	logger.Debug(stub, "start updateRecallStatus")
+
+	result, recall := t.getRecall(stub, args[0])
+	if result.Status != shim.OK {
+		return result
+	}
+	status := args[1]
+
+	allowed := false
+	for _, next := range recallTransitions[recall.Status] {
+		if next == status {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return ccerror.ValidationFailed("Recall with ID " + recall.ID + " can not move from " + recall.Status + " to " + status)
+	}
+
+	result, creator := t.getCreator(stub)
+	if result.Status != shim.OK {
+		return result
+	}
+	result = t.checkCreatorMSP(recall.ID, recall.Initiator, creator)
+	if result.Status != shim.OK {
+		return result
+	}
+
+	result = t.updateCompositeKey(stub, CK_RECALL_STATUS, []string{recall.Status, recall.ID}, []string{status, recall.ID})
+	if result.Status != shim.OK {
+		return result
+	}
+
+	event := ChangeEvent{
+		EventType:  EVENT_RECALL_UPDATED,
+		ObjectID:   recall.ID,
+		ObjectType: TYPE_RECALL,
+		Product:    recall.Root,
+		OldIndexes: map[string]string{CK_RECALL_STATUS: recall.Status},
+		NewIndexes: map[string]string{CK_RECALL_STATUS: status},
+	}
+	recall.Status = status
+	recall.Updated = creator.Time
+
+	result = t.putRecall(stub, recall, event)
+
+	if result.Status == shim.OK {
+		logger.Debug(stub, "end updateRecallStatus (success)")
+	}
+	return result
+}
+
+// acknowledgeRecall records that the organisation of the client has seen a
+// recall. Each affected organisation acknowledges once.
+func (t *FoodChaincode) acknowledgeRecall(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	logger.Debug(stub, "start acknowledgeRecall")
+
+	result, recall := t.getRecall(stub, args[0])
+	if result.Status != shim.OK {
+		return result
+	}
+	if recall.Status == RECALL_CLOSED {
+		return ccerror.ValidationFailed("Recall with ID " + recall.ID + " is closed")
+	}
+
+	result, creator := t.getCreator(stub)
+	if result.Status != shim.OK {
+		return result
+	}
+
+	affected := false
+	for _, organisation := range recall.Organisations {
+		if organisation == creator.MSPID {
+			affected = true
+			break
+		}
+	}
+	if !affected {
+		return ccerror.Forbidden(creator.MSPID + " is not affected by Recall with ID " + recall.ID)
+	}
+	for _, acknowledgement := range recall.Acknowledgements {
+		if acknowledgement.MSPID == creator.MSPID {
+			return ccerror.ValidationFailed("Recall with ID " + recall.ID + " was already acknowledged by " + creator.MSPID)
+		}
+	}
+
+	acknowledgement := RecallAcknowledgement{MSPID: creator.MSPID, Subject: creator.Subject, Time: creator.Time}
+	if len(args) == 2 {
+		acknowledgement.Note = args[1]
+	}
+	recall.Acknowledgements = append(recall.Acknowledgements, acknowledgement)
+	recall.Updated = creator.Time
+
+	result = t.putRecall(stub, recall, ChangeEvent{
+		EventType:  EVENT_RECALL_UPDATED,
+		ObjectID:   recall.ID,
+		ObjectType: TYPE_RECALL,
+		Product:    recall.Root,
+	})
+
+	if result.Status == shim.OK {
+		logger.Debug(stub, "end acknowledgeRecall (success)")
+	}
+	return result
+}
+
+// getActiveRecalls returns the recalls which are not closed
+func (t *FoodChaincode) getActiveRecalls(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	logger.Debug(stub, "start getActiveRecalls")
+
+	recalls := []Recall{}
+	for _, status := range []string{RECALL_OPEN, RECALL_NOTIFIED} {
+		result, statusRecalls := t.getRecallsByCompositeKey(stub, CK_RECALL_STATUS, status)
+		if result.Status != shim.OK {
+			return result
+		}
+		recalls = append(recalls, statusRecalls...)
+	}
+
+	recallsAsBytes, err := json.Marshal(recalls)
+	if err != nil {
+		return ccerror.Internal("Failed to get encode response: " + err.Error())
+	}
+
+	logger.Debug(stub, "end getActiveRecalls (success)")
+	return shim.Success(recallsAsBytes)
+}
+
+// getRecallsAffectingProduct returns all recalls, closed ones included,
+// which affect a Traceable
+func (t *FoodChaincode) getRecallsAffectingProduct(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	logger.Debug(stub, "start getRecallsAffectingProduct")
+
+	result, recalls := t.getRecallsByCompositeKey(stub, CK_AFFECTED_RECALL, args[0])
+	if result.Status != shim.OK {
+		return result
+	}
+
+	recallsAsBytes, err := json.Marshal(recalls)
+	if err != nil {
+		return ccerror.Internal("Failed to get encode response: " + err.Error())
+	}
+
+	logger.Debug(stub, "end getRecallsAffectingProduct (success)")
+	return shim.Success(recallsAsBytes)
+}
+
+func (t *FoodChaincode) getRecall(stub shim.ChaincodeStubInterface, ID string) (pb.Response, Recall) {
+	recall := Recall{}
+	recallAsBytes, err := stub.GetState(ID)
+	if err != nil {
+		return ccerror.Internal("Failed to get existed Recall with ID: " + ID + ", error: " + err.Error()), recall
+	} else if recallAsBytes == nil {
+		return ccerror.NotFound("Recall with ID " + ID + " does not exist"), recall
+	}
+
+	err = json.Unmarshal(recallAsBytes, &recall)
+	if err != nil {
+		return ccerror.Internal("Failed to get decode Recall: " + err.Error()), recall
+	}
+	if recall.ObjectType != TYPE_RECALL {
+		return ccerror.TypeMismatch("Object with ID: " + ID + " is not a Recall"), recall
+	}
+	return shim.Success(nil), recall
+}
+
+// putRecall stores a changed recall with its next version and returns it
+func (t *FoodChaincode) putRecall(stub shim.ChaincodeStubInterface, recall Recall, event ChangeEvent) pb.Response {
+	recall.Version++
+	recall.SchemaVersion = SCHEMA_VERSION
+	recallAsBytes, err := json.Marshal(recall)
+	if err != nil {
+		return ccerror.Internal("Failed to encode json of Recall: " + err.Error())
+	}
+	err = stub.PutState(recall.ID, recallAsBytes)
+	if err != nil {
+		return ccerror.Internal("Failed to update the object with ID: " + recall.ID + ", error: " + err.Error())
+	}
+
+	result := t.setChangeEvent(stub, event)
+	if result.Status != shim.OK {
+		return result
+	}
+	return shim.Success(recallAsBytes)
+}
+
+func (t *FoodChaincode) getRecallsByCompositeKey(stub shim.ChaincodeStubInterface, index string, value string) (pb.Response, []Recall) {
+	resultsIterator, err := stub.GetStateByPartialCompositeKey(index, []string{value})
+	if err != nil {
+		return ccerror.Internal(err.Error()), nil
+	}
+	defer resultsIterator.Close()
+
+	recalls := []Recall{}
+	for resultsIterator.HasNext() {
+		responseRange, err := resultsIterator.Next()
+		if err != nil {
+			return ccerror.Internal(err.Error()), nil
+		}
+		_, compositeKeyParts, err := stub.SplitCompositeKey(responseRange.Key)
+		if err != nil {
+			return ccerror.Internal(err.Error()), nil
+		}
+
+		result, recall := t.getRecall(stub, compositeKeyParts[1])
+		if result.Status != shim.OK {
+			return result, nil
+		}
+		recalls = append(recalls, recall)
+	}
+	return shim.Success(nil), recalls
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/deevotech/sc-chaincode.deevo.io/lib/ccerror"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+func initRecallData(t *testing.T, stub *shim.MockStub) {
+	checkInit(t, stub, [][]byte{})
+	initTraceData(t, stub)
+
+	// Product_D is made from Product_C by another organisation
+	setMockIdentity("Org2MSP", nil)
+	checkCreateReferences(t, stub, map[string]string{"Product_D": TYPE_PRODUCT})
+	log := Log{ObjectType: TYPE_LOG, ID: "Log_D", Time: time.Now().Unix(), Ref: []string{"Product_C"}, CTE: "transformation", Product: "Product_D"}
+	logAsBytes, err := json.Marshal(log)
+	if err != nil {
+		fmt.Println("Failed to encode json")
+		t.FailNow()
+	}
+	res := stub.MockInvoke("1", [][]byte{[]byte("createLog"), logAsBytes})
+	if res.Status != shim.OK {
+		fmt.Println("failed", string(res.Message))
+		t.FailNow()
+	}
+	setMockIdentity(TEST_MSP, nil)
+}
+
+func TestFood_InitiateRecall(t *testing.T) {
+	scc := new(FoodChaincode)
+	stub := shim.NewMockStub("food", scc)
+
+	initRecallData(t, stub)
+
+	recall := checkInitiateRecall(t, stub, Recall{ObjectType: TYPE_RECALL, ID: "Recall_1", Root: "Product_B", Reason: "Listeria", Severity: SEVERITY_HIGH})
+	affected := append([]string{}, recall.Affected...)
+	sort.Strings(affected)
+	if fmt.Sprint(affected) != "[Product_B Product_C Product_D]" {
+		fmt.Println("Affected was not as expected", recall.Affected)
+		t.FailNow()
+	}
+	if fmt.Sprint(recall.Organisations) != "[Org1MSP Org2MSP]" || recall.Status != RECALL_OPEN {
+		fmt.Println("Recall was not as expected", recall)
+		t.FailNow()
+	}
+	checkCreator(t, recall.Initiator, TEST_MSP)
+
+	recalls := checkRecalls(t, stub, "getRecallsAffectingProduct", "Product_D")
+	if len(recalls) != 1 || recalls[0].ID != "Recall_1" {
+		fmt.Println("Recall of Product_D was not found")
+		t.FailNow()
+	}
+	recalls = checkRecalls(t, stub, "getRecallsAffectingProduct", "Product_A")
+	if len(recalls) != 0 {
+		fmt.Println("Product_A is upstream and not affected")
+		t.FailNow()
+	}
+
+	res := stub.MockInvoke("1", [][]byte{[]byte("deleteObject"), []byte("Recall_1"), []byte(TYPE_RECALL)})
+	if res.Status == shim.OK {
+		fmt.Println("Recall should not be deleted")
+		t.FailNow()
+	}
+
+	res = stub.MockInvoke("1", [][]byte{[]byte("initiateRecall"), []byte(`{"objectType": "recall", "id": "Recall_2", "root": "Log_B", "reason": "Listeria", "severity": "high"}`)})
+	if res.Status == shim.OK {
+		fmt.Println("Recall of a Log should fail")
+		t.FailNow()
+	}
+}
+
+func TestFood_InitiateRecallWithoutOrganisations(t *testing.T) {
+	scc := new(FoodChaincode)
+	stub := shim.NewMockStub("food", scc)
+
+	checkInit(t, stub, [][]byte{})
+	checkCreateReferences(t, stub, map[string]string{"Product_1": TYPE_PRODUCT})
+
+	// nobody logged Product_1, so nobody would acknowledge the recall
+	res := stub.MockInvoke("1", [][]byte{[]byte("initiateRecall"), []byte(`{"objectType": "recall", "id": "Recall_1", "root": "Product_1", "reason": "Listeria", "severity": "high"}`)})
+	checkErrorCode(t, res, ccerror.VALIDATION_FAILED)
+
+	recall := checkInitiateRecall(t, stub, Recall{ObjectType: TYPE_RECALL, ID: "Recall_1", Root: "Product_1", Reason: "Listeria", Severity: SEVERITY_HIGH,
+		Organisations: []string{"Org3MSP", "Org2MSP", "Org3MSP"}})
+	if fmt.Sprint(recall.Organisations) != "[Org2MSP Org3MSP]" {
+		fmt.Println("Listed organisations were not kept", recall.Organisations)
+		t.FailNow()
+	}
+}
+
+func TestFood_RecallLifecycle(t *testing.T) {
+	scc := new(FoodChaincode)
+	stub := shim.NewMockStub("food", scc)
+
+	initRecallData(t, stub)
+	checkInitiateRecall(t, stub, Recall{ObjectType: TYPE_RECALL, ID: "Recall_1", Root: "Product_B", Reason: "Listeria", Severity: SEVERITY_HIGH})
+
+	// only affected organisations acknowledge, once
+	setMockIdentity("Org3MSP", nil)
+	res := stub.MockInvoke("1", [][]byte{[]byte("acknowledgeRecall"), []byte("Recall_1")})
+	if res.Status == shim.OK {
+		fmt.Println("Org3MSP is not affected")
+		t.FailNow()
+	}
+	checkErrorCode(t, res, ccerror.FORBIDDEN)
+	setMockIdentity("Org2MSP", nil)
+	res = stub.MockInvoke("1", [][]byte{[]byte("acknowledgeRecall"), []byte("Recall_1"), []byte("Lot withdrawn")})
+	if res.Status != shim.OK {
+		fmt.Println("failed", string(res.Message))
+		t.FailNow()
+	}
+	res = stub.MockInvoke("1", [][]byte{[]byte("acknowledgeRecall"), []byte("Recall_1")})
+	if res.Status == shim.OK {
+		fmt.Println("Org2MSP should acknowledge only once")
+		t.FailNow()
+	}
+
+	// only the initiator changes the status
+	res = stub.MockInvoke("1", [][]byte{[]byte("updateRecallStatus"), []byte("Recall_1"), []byte(RECALL_NOTIFIED)})
+	if res.Status == shim.OK {
+		fmt.Println("Org2MSP did not initiate the recall")
+		t.FailNow()
+	}
+	setMockIdentity(TEST_MSP, nil)
+	res = stub.MockInvoke("1", [][]byte{[]byte("updateRecallStatus"), []byte("Recall_1"), []byte(RECALL_NOTIFIED)})
+	if res.Status != shim.OK {
+		fmt.Println("failed", string(res.Message))
+		t.FailNow()
+	}
+	recall := Recall{}
+	json.Unmarshal(res.Payload, &recall)
+	if recall.Status != RECALL_NOTIFIED || len(recall.Acknowledgements) != 1 || recall.Acknowledgements[0].Note != "Lot withdrawn" {
+		fmt.Println("Recall was not as expected", recall)
+		t.FailNow()
+	}
+	res = stub.MockInvoke("1", [][]byte{[]byte("updateRecallStatus"), []byte("Recall_1"), []byte(RECALL_OPEN)})
+	if res.Status == shim.OK {
+		fmt.Println("Recall should not move back to open")
+		t.FailNow()
+	}
+
+	recalls := checkRecalls(t, stub, "getActiveRecalls")
+	if len(recalls) != 1 {
+		fmt.Println("Recall should be active")
+		t.FailNow()
+	}
+
+	res = stub.MockInvoke("1", [][]byte{[]byte("updateRecallStatus"), []byte("Recall_1"), []byte(RECALL_CLOSED)})
+	if res.Status != shim.OK {
+		fmt.Println("failed", string(res.Message))
+		t.FailNow()
+	}
+	recalls = checkRecalls(t, stub, "getActiveRecalls")
+	if len(recalls) != 0 {
+		fmt.Println("Closed recall should not be active")
+		t.FailNow()
+	}
+	recalls = checkRecalls(t, stub, "getRecallsAffectingProduct", "Product_C")
+	if len(recalls) != 1 || recalls[0].Status != RECALL_CLOSED {
+		fmt.Println("Closed recall should still be found by product")
+		t.FailNow()
+	}
+}
+
+func checkInitiateRecall(t *testing.T, stub *shim.MockStub, recall Recall) Recall {
+	recallAsBytes, err := json.Marshal(recall)
+	if err != nil {
+		fmt.Println("Failed to encode json")
+		t.FailNow()
+	}
+	res := stub.MockInvoke("1", [][]byte{[]byte("initiateRecall"), recallAsBytes})
+	if res.Status != shim.OK {
+		fmt.Println("failed", string(res.Message))
+		t.FailNow()
+	}
+
+	created := Recall{}
+	err = json.Unmarshal(res.Payload, &created)
+	if err != nil {
+		fmt.Println("Failed to decode json of Recall:", err.Error())
+		t.FailNow()
+	}
+	return created
+}
+
+func checkRecalls(t *testing.T, stub *shim.MockStub, function string, args ...string) []Recall {
+	invokeArgs := [][]byte{[]byte(function)}
+	for _, arg := range args {
+		invokeArgs = append(invokeArgs, []byte(arg))
+	}
+	res := stub.MockInvoke("1", invokeArgs)
+	if res.Status != shim.OK {
+		fmt.Println("failed", string(res.Message))
+		t.FailNow()
+	}
+
+	recalls := []Recall{}
+	err := json.Unmarshal(res.Payload, &recalls)
+	if err != nil {
+		fmt.Println("Failed to decode json of Recalls:", err.Error())
+		t.FailNow()
+	}
+	return recalls
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"github.com/deevotech/sc-chaincode.deevo.io/lib/router"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+// newRouter returns the router of the chaincode. The logging settings of the
+// config apply to the whole invocation, the FunctionPolicy of a function is
+// checked before the permissions of its route.
+func (t *FoodChaincode) newRouter() *router.Router {
+	r := router.New(router.Recover, router.ConfigureLogging(t.loggingSettings), router.Log, t.enforcePolicy, router.Authorize, router.Validate, router.GuardReadOnly)
+	r.Register(t.routes()...)
+	return r
+}
+
+// enforcePolicy is the middleware of checkPolicy
+func (t *FoodChaincode) enforcePolicy(route router.Route, next router.Handler) router.Handler {
+	return func(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+		result := t.checkPolicy(stub, route.Name)
+		if result.Status != shim.OK {
+			return result
+		}
+		return next(stub, args)
+	}
+}
+
+// isRoute reports whether a function is registered with the router
+func (t *FoodChaincode) isRoute(function string) bool {
+	for _, route := range t.routes() {
+		if route.Name == function {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *FoodChaincode) routes() []router.Route {
+	admin := []router.Permission{t.checkAdmin}
+	paged := []int{1, 3}
+	depth := []int{1, 2}
+
+	return []router.Route{
+		{Name: "initOrgData", Handler: t.initOrgData, Args: []router.Arg{router.JSONArg("data")}},
+
+		// logs
+		{Name: "createLog", Handler: t.createLog, Args: []router.Arg{router.JSONArg("log")}},
+		{Name: "updateLog", Handler: t.updateLog, Args: []router.Arg{router.JSONArg("log")}},
+		{Name: "bulkCreateLogs", Handler: t.bulkCreateLogs, Args: []router.Arg{router.JSONArg("data"), router.JSONArg("options")}, Counts: []int{1, 2}},
+		{Name: "importEPCIS", Handler: t.importEPCIS, Args: []router.Arg{router.JSONArg("document"), router.String("supplychainID"), router.JSONArg("options")}, Counts: []int{1, 2, 3}},
+		{Name: "exportEPCIS", Handler: t.exportEPCIS, Args: []router.Arg{router.NonEmpty("index"), router.NonEmpty("ID")}, ReadOnly: true},
+		{Name: "getLogsOfSupplychain", Handler: t.getLogsOfSupplychain, Args: []router.Arg{router.NonEmpty("ID"), router.Int("pageSize"), router.String("bookmark")}, Counts: paged, ReadOnly: true},
+		{Name: "getLogsOfProduct", Handler: t.getLogsOfProduct, Args: []router.Arg{router.NonEmpty("ID"), router.Int("pageSize"), router.String("bookmark")}, Counts: paged, ReadOnly: true},
+		{Name: "getLogsOfSupplychainInRange", Handler: t.getLogsOfSupplychainInRange, Args: rangeArgs(), Counts: []int{3, 5}, ReadOnly: true},
+		{Name: "getLogsOfProductInRange", Handler: t.getLogsOfProductInRange, Args: rangeArgs(), Counts: []int{3, 5}, ReadOnly: true},
+		{Name: "getLogsOfFacility", Handler: t.getLogsOfFacility, Args: []router.Arg{router.NonEmpty("facilityID")}, ReadOnly: true},
+		{Name: "getLogsInBoundingBox", Handler: t.getLogsInBoundingBox, Args: []router.Arg{router.NonEmpty("minLatitude"), router.NonEmpty("minLongitude"), router.NonEmpty("maxLatitude"), router.NonEmpty("maxLongitude")}, ReadOnly: true},
+		{Name: "verifyPrivateField", Handler: t.verifyPrivateField, Args: []router.Arg{router.NonEmpty("logID"), router.NonEmpty("field"), router.String("value"), router.NonEmpty("salt")}, ReadOnly: true},
+		{Name: "getLogPrivate", Handler: t.getLogPrivate, Args: []router.Arg{router.NonEmpty("logID")}, ReadOnly: true},
+
+		// auditors and audits
+		{Name: "createAuditor", Handler: t.createAuditor, Args: []router.Arg{router.JSONArg("auditor")}},
+		{Name: "updateAuditor", Handler: t.updateAuditor, Args: []router.Arg{router.JSONArg("auditor")}},
+		{Name: "getExpiringAuditors", Handler: t.getExpiringAuditors, Args: []router.Arg{router.Int("days")}, ReadOnly: true},
+		{Name: "createAuditAction", Handler: t.createAuditAction, Args: []router.Arg{router.JSONArg("auditAction")}},
+		{Name: "updateAuditAction", Handler: t.updateAuditAction, Args: []router.Arg{router.JSONArg("auditAction")}},
+		{Name: "getAuditOfObject", Handler: t.getAuditOfObject, Args: []router.Arg{router.NonEmpty("objectID"), router.JSONArg("filter")}, Counts: []int{1, 2}, ReadOnly: true},
+		{Name: "getAuditsOfAuditor", Handler: t.getAuditsOfAuditor, Args: []router.Arg{router.NonEmpty("auditorID")}, ReadOnly: true},
+		{Name: "getAuditsByStatus", Handler: t.getAuditsByStatus, Args: []router.Arg{router.NonEmpty("status")}, ReadOnly: true},
+
+		// traceables
+		{Name: "createTraceable", Handler: t.createTraceable, Args: []router.Arg{router.JSONArg("traceable")}},
+		{Name: "updateTraceable", Handler: t.updateTraceable, Args: []router.Arg{router.JSONArg("traceable")}},
+		{Name: "getChildrenOfTraceable", Handler: t.getChildrenOfTraceable, Args: []router.Arg{router.NonEmpty("ID")}, ReadOnly: true},
+		{Name: "getTraceableTree", Handler: t.getTraceableTree, Args: []router.Arg{router.NonEmpty("ID"), router.Int("depth")}, Counts: depth, ReadOnly: true},
+		{Name: "traceProduct", Handler: t.traceProduct, Args: []router.Arg{router.NonEmpty("ID"), router.Int("depth")}, Counts: depth, ReadOnly: true},
+		{Name: "traceForward", Handler: t.traceForward, Args: []router.Arg{router.NonEmpty("ID"), router.Int("depth")}, Counts: depth, ReadOnly: true},
+
+		// objects
+		{Name: "getObject", Handler: t.getObject, Args: []router.Arg{router.NonEmpty("ID"), router.NonEmpty("objectType")}, ReadOnly: true},
+		{Name: "getHistoryOfObject", Handler: t.getHistoryOfObject, Args: []router.Arg{router.NonEmpty("ID")}, ReadOnly: true},
+		{Name: "getQueryResultForQueryString", Handler: t.getQueryResultForQueryString, Args: []router.Arg{router.JSONArg("query")}, ReadOnly: true},
+		{Name: "verifyDocument", Handler: t.verifyDocument, Args: []router.Arg{router.NonEmpty("objectID"), router.NonEmpty("digest"), router.String("uri")}, Counts: []int{2, 3}, ReadOnly: true},
+		{Name: "getObjectsByDocument", Handler: t.getObjectsByDocument, Args: []router.Arg{router.NonEmpty("digest")}, ReadOnly: true},
+		{Name: "deleteObject", Handler: t.deleteObject, Args: []router.Arg{router.NonEmpty("ID"), router.NonEmpty("objectType"), router.String("reason")}, Counts: []int{2, 3}},
+		{Name: "archiveObject", Handler: t.archiveObject, Args: []router.Arg{router.NonEmpty("ID"), router.NonEmpty("objectType"), router.NonEmpty("reason")}},
+		{Name: "getTombstone", Handler: t.getTombstone, Args: []router.Arg{router.NonEmpty("ID")}, ReadOnly: true},
+
+		// recalls
+		{Name: "initiateRecall", Handler: t.initiateRecall, Args: []router.Arg{router.JSONArg("recall")}},
+		{Name: "updateRecallStatus", Handler: t.updateRecallStatus, Args: []router.Arg{router.NonEmpty("ID"), router.NonEmpty("status")}},
+		{Name: "acknowledgeRecall", Handler: t.acknowledgeRecall, Args: []router.Arg{router.NonEmpty("ID"), router.String("note")}, Counts: []int{1, 2}},
+		{Name: "getActiveRecalls", Handler: t.getActiveRecalls, ReadOnly: true},
+		{Name: "getRecallsAffectingProduct", Handler: t.getRecallsAffectingProduct, Args: []router.Arg{router.NonEmpty("productID")}, ReadOnly: true},
+
+		// administration
+		{Name: "setFunctionPolicy", Handler: t.setFunctionPolicy, Args: []router.Arg{router.JSONArg("policy")}, Permissions: admin},
+		{Name: "deleteFunctionPolicy", Handler: t.deleteFunctionPolicy, Args: []router.Arg{router.NonEmpty("function")}, Permissions: admin},
+		{Name: "getFunctionPolicies", Handler: t.getFunctionPolicies, ReadOnly: true},
+		{Name: "setCTESchema", Handler: t.setCTESchema, Args: []router.Arg{router.JSONArg("schema")}, Permissions: admin},
+		{Name: "deleteCTESchema", Handler: t.deleteCTESchema, Args: []router.Arg{router.NonEmpty("CTE")}, Permissions: admin},
+		{Name: "getCTESchemas", Handler: t.getCTESchemas, ReadOnly: true},
+		{Name: "setIntegrityMode", Handler: t.setIntegrityMode, Args: []router.Arg{router.NonEmpty("mode")}, Permissions: admin},
+		{Name: "getIntegrityMode", Handler: t.getIntegrityMode, ReadOnly: true},
+		{Name: "getConfig", Handler: t.getConfig, ReadOnly: true},
+		{Name: "updateConfig", Handler: t.updateConfig, Args: []router.Arg{router.JSONArg("config")}, Permissions: admin},
+		{Name: "getConfigHistory", Handler: t.getConfigHistory, ReadOnly: true},
+		{Name: "migrate", Handler: t.migrate, Args: []router.Arg{router.NonEmpty("objectType"), router.Int("batchSize"), router.String("bookmark")}, Counts: []int{1, 2, 3}, Permissions: admin},
+		{Name: "getMigrationProgress", Handler: t.getMigrationProgress, Args: []router.Arg{router.NonEmpty("objectType")}, ReadOnly: true},
+	}
+}
+
+func rangeArgs() []router.Arg {
+	return []router.Arg{router.NonEmpty("ID"), router.Int("from"), router.Int("to"), router.Int("pageSize"), router.String("bookmark")}
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/deevotech/sc-chaincode.deevo.io/lib/ccerror"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+func TestFood_Routes(t *testing.T) {
+	scc := new(FoodChaincode)
+	for _, route := range scc.newRouter().Routes() {
+		if route.Handler == nil {
+			fmt.Println("Function", route.Name, "has no handler")
+			t.FailNow()
+		}
+	}
+
+	stub := shim.NewMockStub("food", scc)
+	checkInit(t, stub, [][]byte{})
+
+	res := stub.MockInvoke("1", [][]byte{[]byte("unknownFunction")})
+	body := checkErrorCode(t, res, ccerror.VALIDATION_FAILED)
+	if body.Details["function"] != "unknownFunction" {
+		fmt.Println("Unexpected details", body.Details)
+		t.FailNow()
+	}
+
+	res = stub.MockInvoke("1", [][]byte{[]byte("traceProduct"), []byte("Product_1"), []byte("2"), []byte("3")})
+	body = checkErrorCode(t, res, ccerror.VALIDATION_FAILED)
+	if body.Message != "Incorrect number of arguments. Expecting 1 or 2" {
+		fmt.Println("Unexpected message", body.Message)
+		t.FailNow()
+	}
+
+	res = stub.MockInvoke("1", [][]byte{[]byte("traceProduct"), []byte("Product_1"), []byte("deep")})
+	body = checkErrorCode(t, res, ccerror.VALIDATION_FAILED)
+	if body.Details["arg"] != "depth" {
+		fmt.Println("Unexpected details", body.Details)
+		t.FailNow()
+	}
+
+	res = stub.MockInvoke("1", [][]byte{[]byte("createLog"), []byte("{not json")})
+	checkErrorCode(t, res, ccerror.VALIDATION_FAILED)
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/deevotech/sc-chaincode.deevo.io/lib/ccerror"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+// checkLogSchema checks that the config allows the CTE of a Log and the Log
+// against the schema of its CTE. Logs of a CTE without schema are not
+// checked further. The error lists every missing element.
+func (t *FoodChaincode) checkLogSchema(stub shim.ChaincodeStubInterface, log Log) pb.Response {
+	result, config := t.readConfig(stub)
+	if result.Status != shim.OK {
+		return result
+	}
+	if !isAllowedCTE(config, log.CTE) {
+		return ccerror.ValidationFailed("CTE " + log.CTE + " of Log " + log.ID + " is not allowed by the config")
+	}
+
+	result, schema := t.getCTESchema(stub, log.CTE)
+	if result.Status != shim.OK || schema == nil {
+		return result
+	}
+
+	fieldErrors := []string{}
+
+	if len(schema.LogFields) > 0 {
+		logFields, err := toJSONObject(log)
+		if err != nil {
+			return ccerror.Internal("Failed to encode json of Log: " + err.Error())
+		}
+		for _, field := range schema.LogFields {
+			if isEmptyJSONValue(logFields[field]) {
+				fieldErrors = append(fieldErrors, field+" is required")
+			}
+		}
+	}
+
+	if len(schema.KDEs) > 0 {
+		content := map[string]interface{}{}
+		err := json.Unmarshal([]byte(log.Content), &content)
+		if err != nil {
+			fieldErrors = append(fieldErrors, "content must be a json object")
+		} else {
+			for _, kde := range schema.KDEs {
+				if isEmptyJSONValue(content[kde]) {
+					fieldErrors = append(fieldErrors, "content."+kde+" is required")
+				}
+			}
+		}
+	}
+
+	if len(fieldErrors) > 0 {
+		return ccerror.WithDetails(ccerror.VALIDATION_FAILED, "Log "+log.ID+" does not match the schema of CTE "+log.CTE+": "+strings.Join(fieldErrors, "; "),
+			map[string]interface{}{"fields": fieldErrors})
+	}
+	return shim.Success(nil)
+}
+
+// getCTESchema returns nil when the CTE has no schema. Init sets no
+// schemas, an admin sets the ones of the FSMA 204 CTEs with setCTESchema.
+func (t *FoodChaincode) getCTESchema(stub shim.ChaincodeStubInterface, CTE string) (pb.Response, *CTESchema) {
+	if len(CTE) < 1 {
+		return shim.Success(nil), nil
+	}
+	schemaKey, err := stub.CreateCompositeKey(CK_CTE_SCHEMA, []string{CTE})
+	if err != nil {
+		return ccerror.Internal("Failed to create composite key: " + err.Error()), nil
+	}
+	schemaAsBytes, err := stub.GetState(schemaKey)
+	if err != nil {
+		return ccerror.Internal("Failed to get schema of CTE " + CTE + ", error: " + err.Error()), nil
+	} else if schemaAsBytes == nil {
+		return shim.Success(nil), nil
+	}
+
+	schema := CTESchema{}
+	err = json.Unmarshal(schemaAsBytes, &schema)
+	if err != nil {
+		return ccerror.Internal("Failed to decode schema of CTE " + CTE + ": " + err.Error()), nil
+	}
+	return shim.Success(nil), &schema
+}
+
+// Methods on CTESchema
+// ========================================
+func (t *FoodChaincode) setCTESchema(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	logger.Debug(stub, "start setCTESchema")
+
+	newSchema := CTESchema{}
+	err := json.Unmarshal([]byte(args[0]), &newSchema)
+	if err != nil {
+		return ccerror.ValidationFailed("Failed to decode json of CTESchema: " + err.Error())
+	}
+	if newSchema.ObjectType != TYPE_CTE_SCHEMA {
+		return ccerror.TypeMismatch("Expexted objectType " + TYPE_CTE_SCHEMA + " for CTESchema")
+	}
+	if len(newSchema.CTE) < 1 {
+		return ccerror.ValidationFailed("CTE can not by empty")
+	}
+	if len(newSchema.LogFields) == 0 && len(newSchema.KDEs) == 0 {
+		return ccerror.ValidationFailed("CTESchema needs at least one Log field or KDE")
+	}
+
+	logFields, err := toJSONObject(Log{})
+	if err != nil {
+		return ccerror.Internal("Failed to encode json of Log: " + err.Error())
+	}
+	for _, field := range newSchema.LogFields {
+		if _, ok := logFields[field]; !ok {
+			return ccerror.ValidationFailed("Log has no field " + field)
+		}
+	}
+	for _, kde := range newSchema.KDEs {
+		if len(kde) < 1 {
+			return ccerror.ValidationFailed("KDE can not by empty")
+		}
+	}
+
+	schemaKey, err := stub.CreateCompositeKey(CK_CTE_SCHEMA, []string{newSchema.CTE})
+	if err != nil {
+		return ccerror.Internal("Failed to create composite key: " + err.Error())
+	}
+	schemaAsBytes, err := json.Marshal(newSchema)
+	if err != nil {
+		return ccerror.Internal("Failed to encode json of CTESchema: " + err.Error())
+	}
+	result := putVersioned(stub, schemaKey, newSchema.CTE, schemaAsBytes)
+	if result.Status != shim.OK {
+		logger.Debug(stub, "end setCTESchema (failed)")
+		return result
+	}
+
+	logger.Debug(stub, "end setCTESchema (success)")
+	return shim.Success(nil)
+}
+
+func (t *FoodChaincode) deleteCTESchema(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	logger.Debug(stub, "start deleteCTESchema")
+
+	result, schema := t.getCTESchema(stub, args[0])
+	if result.Status != shim.OK {
+		return result
+	}
+	if schema == nil {
+		return ccerror.NotFound("CTE " + args[0] + " has no schema")
+	}
+
+	result = t.deleteCompositeKey(stub, CK_CTE_SCHEMA, []string{args[0]})
+
+	if result.Status == shim.OK {
+		logger.Debug(stub, "end deleteCTESchema (success)")
+	}
+	return result
+}
+
+func (t *FoodChaincode) getCTESchemas(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	logger.Debug(stub, "start getCTESchemas")
+
+	resultsIterator, err := stub.GetStateByPartialCompositeKey(CK_CTE_SCHEMA, []string{})
+	if err != nil {
+		return ccerror.Internal(err.Error())
+	}
+	defer resultsIterator.Close()
+
+	response := []CTESchema{}
+	for resultsIterator.HasNext() {
+		responseRange, err := resultsIterator.Next()
+		if err != nil {
+			return ccerror.Internal(err.Error())
+		}
+
+		schema := CTESchema{}
+		err = json.Unmarshal(responseRange.Value, &schema)
+		if err != nil {
+			return ccerror.Internal("Failed to get decode schema: " + err.Error())
+		}
+		response = append(response, schema)
+	}
+
+	responseAsBytes, err := json.Marshal(response)
+	if err != nil {
+		return ccerror.Internal("Failed to get encode response: " + err.Error())
+	}
+
+	logger.Debug(stub, "end getCTESchemas (success)")
+	return shim.Success(responseAsBytes)
+}
+
+// toJSONObject returns the fields of a value by their json names
+func toJSONObject(value interface{}) (map[string]interface{}, error) {
+	valueAsBytes, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	object := map[string]interface{}{}
+	err = json.Unmarshal(valueAsBytes, &object)
+	return object, err
+}
+
+func isEmptyJSONValue(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case string:
+		return len(strings.TrimSpace(v)) == 0
+	case float64:
+		return v == 0
+	case []interface{}:
+		return len(v) == 0
+	case map[string]interface{}:
+		return len(v) == 0
+	}
+	return false
+}
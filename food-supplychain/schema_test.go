@@ -0,0 +1,156 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/deevotech/sc-chaincode.deevo.io/lib/ccerror"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+func checkSetCTESchema(t *testing.T, stub *shim.MockStub, schema CTESchema) {
+	schemaAsBytes, err := json.Marshal(schema)
+	if err != nil {
+		fmt.Println("Failed to encode json")
+		t.FailNow()
+	}
+
+	res := stub.MockInvoke("1", [][]byte{[]byte("setCTESchema"), schemaAsBytes})
+	if res.Status == shim.OK {
+		fmt.Println("CTESchema should not be set by a client without " + ADMIN_ATTRIBUTE)
+		t.FailNow()
+	}
+
+	setMockIdentity(TEST_MSP, map[string]string{ADMIN_ATTRIBUTE: "true"})
+	res = stub.MockInvoke("1", [][]byte{[]byte("setCTESchema"), schemaAsBytes})
+	setMockIdentity(TEST_MSP, nil)
+	if res.Status != shim.OK {
+		fmt.Println("failed", string(res.Message))
+		t.FailNow()
+	}
+}
+
+func TestFood_CTESchema(t *testing.T) {
+	scc := new(FoodChaincode)
+	stub := shim.NewMockStub("food", scc)
+
+	checkInit(t, stub, [][]byte{})
+	checkCreateReferences(t, stub, map[string]string{"Product_1": TYPE_PRODUCT})
+
+	checkSetCTESchema(t, stub, CTESchema{
+		ObjectType: TYPE_CTE_SCHEMA,
+		CTE:        "shipping",
+		LogFields:  []string{"product", "location"},
+		KDEs:       []string{"traceabilityLotCode", "destination"},
+	})
+
+	newLog := Log{ObjectType: TYPE_LOG, ID: "Log_1", Time: time.Now().Unix(), CTE: "shipping", Product: "Product_1",
+		Content: `{"traceabilityLotCode": "TLC_1"}`}
+	newLogAsBytes, err := json.Marshal(newLog)
+	if err != nil {
+		fmt.Println("Failed to encode json")
+		t.FailNow()
+	}
+	res := stub.MockInvoke("1", [][]byte{[]byte("createLog"), newLogAsBytes})
+	if res.Status == shim.OK {
+		fmt.Println("createLog should fail without location and destination")
+		t.FailNow()
+	}
+	if !strings.Contains(res.Message, "location is required") || !strings.Contains(res.Message, "content.destination is required") {
+		fmt.Println("Validation error was not as expected", res.Message)
+		t.FailNow()
+	}
+
+	newLog.Location = "Location_1"
+	newLog.Content = `{"traceabilityLotCode": "TLC_1", "destination": "Location_2"}`
+	newLogAsBytes, err = json.Marshal(newLog)
+	if err != nil {
+		fmt.Println("Failed to encode json")
+		t.FailNow()
+	}
+	res = stub.MockInvoke("1", [][]byte{[]byte("createLog"), newLogAsBytes})
+	if res.Status != shim.OK {
+		fmt.Println("failed", string(res.Message))
+		t.FailNow()
+	}
+
+	newLog.Content = "Log 1"
+	newLog.Version = 1
+	newLogAsBytes, err = json.Marshal(newLog)
+	if err != nil {
+		fmt.Println("Failed to encode json")
+		t.FailNow()
+	}
+	res = stub.MockInvoke("1", [][]byte{[]byte("updateLog"), newLogAsBytes})
+	if res.Status == shim.OK || !strings.Contains(res.Message, "content must be a json object") {
+		fmt.Println("updateLog should fail with a content which is not json", res.Message)
+		t.FailNow()
+	}
+
+	// logs of other CTEs are not checked
+	otherLog := Log{ObjectType: TYPE_LOG, ID: "Log_2", Time: time.Now().Unix(), CTE: "receiving", Content: "Log 2"}
+	otherLogAsBytes, err := json.Marshal(otherLog)
+	if err != nil {
+		fmt.Println("Failed to encode json")
+		t.FailNow()
+	}
+	res = stub.MockInvoke("1", [][]byte{[]byte("createLog"), otherLogAsBytes})
+	if res.Status != shim.OK {
+		fmt.Println("failed", string(res.Message))
+		t.FailNow()
+	}
+
+	res = stub.MockInvoke("1", [][]byte{[]byte("getCTESchemas")})
+	schemas := []CTESchema{}
+	json.Unmarshal(res.Payload, &schemas)
+	if len(schemas) != 1 || schemas[0].CTE != "shipping" {
+		fmt.Println("CTESchemas were not as expected", string(res.Payload))
+		t.FailNow()
+	}
+}
+
+func TestFood_SetCTESchemaWithUnknownField(t *testing.T) {
+	scc := new(FoodChaincode)
+	stub := shim.NewMockStub("food", scc)
+
+	checkInit(t, stub, [][]byte{})
+
+	schema := CTESchema{ObjectType: TYPE_CTE_SCHEMA, CTE: "shipping", LogFields: []string{"destination"}}
+	schemaAsBytes, err := json.Marshal(schema)
+	if err != nil {
+		fmt.Println("Failed to encode json")
+		t.FailNow()
+	}
+
+	setMockIdentity(TEST_MSP, map[string]string{ADMIN_ATTRIBUTE: "true"})
+	res := stub.MockInvoke("1", [][]byte{[]byte("setCTESchema"), schemaAsBytes})
+	if res.Status == shim.OK {
+		fmt.Println("setCTESchema should fail for an unknown Log field")
+		t.FailNow()
+	}
+}
+
+func TestFood_DeleteCTESchema(t *testing.T) {
+	scc := new(FoodChaincode)
+	stub := shim.NewMockStub("food", scc)
+
+	checkInit(t, stub, [][]byte{})
+	checkSetCTESchema(t, stub, CTESchema{ObjectType: TYPE_CTE_SCHEMA, CTE: "shipping", KDEs: []string{"traceabilityLotCode"}})
+
+	setMockIdentity(TEST_MSP, map[string]string{ADMIN_ATTRIBUTE: "true"})
+	res := stub.MockInvoke("1", [][]byte{[]byte("deleteCTESchema"), []byte("shipping")})
+	if res.Status != shim.OK {
+		fmt.Println("failed", string(res.Message))
+		t.FailNow()
+	}
+
+	// there is nothing left to delete
+	res = stub.MockInvoke("1", [][]byte{[]byte("deleteCTESchema"), []byte("shipping")})
+	checkErrorCode(t, res, ccerror.NOT_FOUND)
+	res = stub.MockInvoke("1", [][]byte{[]byte("deleteCTESchema"), []byte("receiving")})
+	checkErrorCode(t, res, ccerror.NOT_FOUND)
+}
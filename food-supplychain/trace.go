@@ -0,0 +1,288 @@
+package main
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"github.com/deevotech/sc-chaincode.deevo.io/lib/ccerror"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+// traceItem is a traceable waiting to be expanded
+type traceItem struct {
+	ID    string
+	Depth int
+}
+
+// traceBuilder collects the nodes and edges of a provenance graph
+type traceBuilder struct {
+	graph   TraceGraph
+	nodes   map[string]bool
+	edges   map[string]bool
+	visited map[string]bool
+}
+
+func newTraceBuilder(rootID string, direction string, maxDepth int) *traceBuilder {
+	return &traceBuilder{
+		graph: TraceGraph{
+			Root:      rootID,
+			Direction: direction,
+			MaxDepth:  maxDepth,
+			Nodes:     []TraceNode{},
+			Edges:     []TraceEdge{},
+			Missing:   []string{},
+		},
+		nodes:   map[string]bool{},
+		edges:   map[string]bool{},
+		visited: map[string]bool{},
+	}
+}
+
+func (b *traceBuilder) addNode(ID string, objectType string, depth int, data []byte) {
+	if b.nodes[ID] {
+		return
+	}
+	b.nodes[ID] = true
+	b.graph.Nodes = append(b.graph.Nodes, TraceNode{ID: ID, ObjectType: objectType, Depth: depth, Data: data})
+}
+
+func (b *traceBuilder) addLogNode(log Log, depth int) pb.Response {
+	if b.nodes[log.ID] {
+		return shim.Success(nil)
+	}
+	logAsBytes, err := json.Marshal(log)
+	if err != nil {
+		return ccerror.Internal("Failed to encode json of Log " + log.ID)
+	}
+	b.addNode(log.ID, log.ObjectType, depth, logAsBytes)
+	return shim.Success(nil)
+}
+
+func (b *traceBuilder) addEdge(from string, to string, edgeType string) {
+	key := from + "\x00" + to + "\x00" + edgeType
+	if b.edges[key] {
+		return
+	}
+	b.edges[key] = true
+	b.graph.Edges = append(b.graph.Edges, TraceEdge{From: from, To: to, Type: edgeType})
+}
+
+// traceProduct returns the graph of everything a traceable was made from,
+// following the Ref of its logs and its Parent
+func (t *FoodChaincode) traceProduct(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	logger.Debug(stub, "start traceProduct")
+
+	result := t.trace(stub, args, TRACE_BACKWARD)
+
+	if result.Status == shim.OK {
+		logger.Debug(stub, "end traceProduct (success)")
+	}
+	return result
+}
+
+// traceForward returns the graph of everything made from a traceable,
+// following the logs which reference it and its children
+func (t *FoodChaincode) traceForward(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	logger.Debug(stub, "start traceForward")
+
+	result := t.trace(stub, args, TRACE_FORWARD)
+
+	if result.Status == shim.OK {
+		logger.Debug(stub, "end traceForward (success)")
+	}
+	return result
+}
+
+func (t *FoodChaincode) trace(stub shim.ChaincodeStubInterface, args []string, direction string) pb.Response {
+
+	ID := args[0]
+	maxDepth := DEFAULT_TRACE_DEPTH
+	if len(args) == 2 {
+		depth, err := strconv.Atoi(args[1])
+		if err != nil || depth < 0 || depth > MAX_TRACE_DEPTH {
+			return ccerror.ValidationFailed("Depth must be a number between 0 and " + strconv.Itoa(MAX_TRACE_DEPTH))
+		}
+		maxDepth = depth
+	}
+
+	existedObjectAsBytes, err := stub.GetState(ID)
+	if err != nil {
+		return ccerror.Internal("Failed to get existed Object with ID: " + ID + ", error: " + err.Error())
+	} else if existedObjectAsBytes == nil {
+		return ccerror.NotFound("Object with ID " + ID + " does not exist")
+	}
+
+	liteModel := LiteModel{}
+	err = json.Unmarshal(existedObjectAsBytes, &liteModel)
+	if err != nil {
+		return ccerror.Internal("Failed to get decode object: " + err.Error())
+	}
+	if !isTraceableType(liteModel.ObjectType) {
+		return ccerror.TypeMismatch("Object with ID: " + ID + " is not a Traceable")
+	}
+
+	result, graph := t.buildTraceGraph(stub, ID, direction, maxDepth)
+	if result.Status != shim.OK {
+		return result
+	}
+
+	graphAsBytes, err := json.Marshal(graph)
+	if err != nil {
+		return ccerror.Internal("Failed to get encode response: " + err.Error())
+	}
+	return shim.Success(graphAsBytes)
+}
+
+// buildTraceGraph walks the graph breadth first from the root. Every
+// traceable is expanded once, so cycles in Ref or Parent links terminate.
+func (t *FoodChaincode) buildTraceGraph(stub shim.ChaincodeStubInterface, rootID string, direction string, maxDepth int) (pb.Response, *TraceGraph) {
+	b := newTraceBuilder(rootID, direction, maxDepth)
+	b.visited[rootID] = true
+	queue := []traceItem{{ID: rootID, Depth: 0}}
+
+	for len(queue) > 0 {
+		item := queue[0]
+		queue = queue[1:]
+
+		objectAsBytes, err := stub.GetState(item.ID)
+		if err != nil {
+			return ccerror.Internal("Failed to get existed Object with ID: " + item.ID + ", error: " + err.Error()), nil
+		} else if objectAsBytes == nil {
+			b.graph.Missing = append(b.graph.Missing, item.ID)
+			continue
+		}
+
+		traceable := Traceable{}
+		err = json.Unmarshal(objectAsBytes, &traceable)
+		if err != nil {
+			return ccerror.Internal("Failed to get decode object: " + err.Error()), nil
+		}
+		b.addNode(item.ID, traceable.ObjectType, item.Depth, objectAsBytes)
+		if !isTraceableType(traceable.ObjectType) {
+			continue
+		}
+
+		var result pb.Response
+		var next []string
+		if direction == TRACE_BACKWARD {
+			result, next = t.traceBackwardStep(stub, b, traceable, item.Depth)
+		} else {
+			result, next = t.traceForwardStep(stub, b, traceable, item.Depth)
+		}
+		if result.Status != shim.OK {
+			return result, nil
+		}
+
+		for _, nextID := range next {
+			if b.visited[nextID] {
+				continue
+			}
+			if item.Depth >= maxDepth {
+				b.graph.Truncated = true
+				continue
+			}
+			b.visited[nextID] = true
+			queue = append(queue, traceItem{ID: nextID, Depth: item.Depth + 1})
+		}
+	}
+
+	return shim.Success(nil), &b.graph
+}
+
+func (t *FoodChaincode) traceBackwardStep(stub shim.ChaincodeStubInterface, b *traceBuilder, traceable Traceable, depth int) (pb.Response, []string) {
+	next := []string{}
+	if len(traceable.ParentID()) > 0 {
+		b.addEdge(traceable.ID, traceable.ParentID(), EDGE_PARENT)
+		next = append(next, traceable.ParentID())
+	}
+
+	result, logs := t.getLogsByCompositeKey(stub, CK_PRODUCT_LOG, traceable.ID)
+	if result.Status != shim.OK {
+		return result, nil
+	}
+	for _, log := range logs {
+		result = b.addLogNode(log, depth)
+		if result.Status != shim.OK {
+			return result, nil
+		}
+		b.addEdge(traceable.ID, log.ID, EDGE_LOG)
+		for _, ref := range log.Ref {
+			if len(ref) == 0 || ref == traceable.ID {
+				continue
+			}
+			b.addEdge(log.ID, ref, EDGE_REF)
+			next = append(next, ref)
+		}
+	}
+
+	return shim.Success(nil), next
+}
+
+func (t *FoodChaincode) traceForwardStep(stub shim.ChaincodeStubInterface, b *traceBuilder, traceable Traceable, depth int) (pb.Response, []string) {
+	next := []string{}
+
+	result, logs := t.getLogsByCompositeKey(stub, CK_PRODUCT_LOG, traceable.ID)
+	if result.Status != shim.OK {
+		return result, nil
+	}
+	for _, log := range logs {
+		result = b.addLogNode(log, depth)
+		if result.Status != shim.OK {
+			return result, nil
+		}
+		b.addEdge(traceable.ID, log.ID, EDGE_LOG)
+	}
+
+	result, children := t.getChildren(stub, traceable.ID)
+	if result.Status != shim.OK {
+		return result, nil
+	}
+	for _, child := range children {
+		b.addEdge(child.ID, traceable.ID, EDGE_PARENT)
+		next = append(next, child.ID)
+	}
+
+	result, logs = t.getLogsByCompositeKey(stub, CK_REF_LOG, traceable.ID)
+	if result.Status != shim.OK {
+		return result, nil
+	}
+	for _, log := range logs {
+		result = b.addLogNode(log, depth)
+		if result.Status != shim.OK {
+			return result, nil
+		}
+		b.addEdge(log.ID, traceable.ID, EDGE_REF)
+		for _, product := range logProducts(log) {
+			if product != traceable.ID {
+				b.addEdge(product, log.ID, EDGE_LOG)
+				next = append(next, product)
+			}
+		}
+	}
+
+	return shim.Success(nil), next
+}
+
+func (t *FoodChaincode) getLogsByCompositeKey(stub shim.ChaincodeStubInterface, objectType string, ID string) (pb.Response, []Log) {
+	resultsIterator, err := stub.GetStateByPartialCompositeKey(objectType, []string{ID})
+	if err != nil {
+		return ccerror.Internal(err.Error()), nil
+	}
+	defer resultsIterator.Close()
+
+	return t.readLogsFromIterator(stub, resultsIterator)
+}
+
+// isTraceableType reports whether objects of the type are stored as
+// Traceable. The types of Traceables are open, so every other type of the
+// chaincode is excluded.
+func isTraceableType(objectType string) bool {
+	switch objectType {
+	case TYPE_LOG, TYPE_AUDITOR, TYPE_AUDITACTION, TYPE_RECALL, TYPE_POLICY,
+		TYPE_TOMBSTONE, TYPE_CTE_SCHEMA, TYPE_LOG_PRIVATE, TYPE_CONFIG:
+		return false
+	}
+	return true
+}
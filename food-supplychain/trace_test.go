@@ -0,0 +1,123 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+func initTraceData(t *testing.T, stub *shim.MockStub) {
+	for _, ID := range []string{"Product_A", "Product_B", "Product_C"} {
+		product := Traceable{ObjectType: TYPE_PRODUCT, ID: ID, Name: ID}
+		productAsBytes, err := json.Marshal(product)
+		if err != nil {
+			fmt.Println("Failed to encode json")
+			t.FailNow()
+		}
+		checkCreateTraceable(t, stub, productAsBytes, product)
+	}
+
+	// Product_B is made from Product_A, Product_C is made from Product_B
+	logs := []Log{
+		{ObjectType: TYPE_LOG, ID: "Log_B", Time: time.Now().Unix(), Ref: []string{"Product_A"}, CTE: "transformation", Product: "Product_B"},
+		{ObjectType: TYPE_LOG, ID: "Log_C", Time: time.Now().Unix(), Ref: []string{"Product_B"}, CTE: "transformation", Product: "Product_C"},
+	}
+	for _, log := range logs {
+		logAsBytes, err := json.Marshal(log)
+		if err != nil {
+			fmt.Println("Failed to encode json")
+			t.FailNow()
+		}
+		res := stub.MockInvoke("1", [][]byte{[]byte("createLog"), logAsBytes})
+		if res.Status != shim.OK {
+			fmt.Println("failed", string(res.Message))
+			t.FailNow()
+		}
+	}
+}
+
+func TestFood_TraceProduct(t *testing.T) {
+	scc := new(FoodChaincode)
+	stub := shim.NewMockStub("food", scc)
+
+	checkInit(t, stub, [][]byte{})
+	initTraceData(t, stub)
+
+	graph := checkTrace(t, stub, "traceProduct", "Product_C", "")
+	checkTraceNodes(t, graph, []string{"Product_A", "Product_B", "Product_C", "Log_B", "Log_C"})
+	if graph.Truncated {
+		fmt.Println("Graph should not be truncated")
+		t.FailNow()
+	}
+
+	graph = checkTrace(t, stub, "traceProduct", "Product_C", "1")
+	checkTraceNodes(t, graph, []string{"Product_B", "Product_C", "Log_B", "Log_C"})
+	if !graph.Truncated {
+		fmt.Println("Graph should be truncated")
+		t.FailNow()
+	}
+}
+
+func TestFood_TraceForward(t *testing.T) {
+	scc := new(FoodChaincode)
+	stub := shim.NewMockStub("food", scc)
+
+	checkInit(t, stub, [][]byte{})
+	initTraceData(t, stub)
+
+	// a log closing the loop must not make the walk run forever
+	cycleLog := Log{ObjectType: TYPE_LOG, ID: "Log_A", Time: time.Now().Unix(), Ref: []string{"Product_C"}, CTE: "transformation", Product: "Product_A"}
+	cycleLogAsBytes, err := json.Marshal(cycleLog)
+	if err != nil {
+		fmt.Println("Failed to encode json")
+		t.FailNow()
+	}
+	res := stub.MockInvoke("1", [][]byte{[]byte("createLog"), cycleLogAsBytes})
+	if res.Status != shim.OK {
+		fmt.Println("failed", string(res.Message))
+		t.FailNow()
+	}
+
+	graph := checkTrace(t, stub, "traceForward", "Product_A", "")
+	checkTraceNodes(t, graph, []string{"Product_A", "Product_B", "Product_C", "Log_A", "Log_B", "Log_C"})
+}
+
+func checkTrace(t *testing.T, stub *shim.MockStub, function string, ID string, depth string) TraceGraph {
+	args := [][]byte{[]byte(function), []byte(ID)}
+	if len(depth) > 0 {
+		args = append(args, []byte(depth))
+	}
+	res := stub.MockInvoke("1", args)
+	if res.Status != shim.OK {
+		fmt.Println("failed", string(res.Message))
+		t.FailNow()
+	}
+
+	graph := TraceGraph{}
+	err := json.Unmarshal(res.Payload, &graph)
+	if err != nil {
+		fmt.Println("Failed to decode json of TraceGraph:", err.Error())
+		t.FailNow()
+	}
+	return graph
+}
+
+func checkTraceNodes(t *testing.T, graph TraceGraph, expected []string) {
+	if len(graph.Nodes) != len(expected) {
+		fmt.Println("Size of nodes does not match", graph.Nodes)
+		t.FailNow()
+	}
+	found := map[string]bool{}
+	for _, node := range graph.Nodes {
+		found[node.ID] = true
+	}
+	for _, ID := range expected {
+		if !found[ID] {
+			fmt.Println("Node", ID, "was not found")
+			t.FailNow()
+		}
+	}
+}
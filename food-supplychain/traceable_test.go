@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/deevotech/sc-chaincode.deevo.io/lib/ccerror"
+
 	"github.com/hyperledger/fabric/core/chaincode/shim"
 )
 
@@ -37,7 +39,7 @@ func TestFood_UpdateTraceable(t *testing.T) {
 	}
 	checkCreateTraceable(t, stub, newOrgAsBytes, newOrg)
 
-	updatedOrg := Traceable{ObjectType: "org", ID: "org_1", Name: "org 1", Content: "address 2"}
+	updatedOrg := Traceable{ObjectType: "org", ID: "org_1", Name: "org 1", Content: "address 2", Version: 1}
 	updatedOrgAsBytes, err := json.Marshal(updatedOrg)
 	if err != nil {
 		fmt.Println("Failed to encode json")
@@ -66,6 +68,8 @@ func checkCreateTraceable(t *testing.T, stub *shim.MockStub, orgAsJSON []byte, v
 		fmt.Println("Failed to decode json of ORG:", err.Error())
 		t.FailNow()
 	}
+	value.Version = 1
+	value.SchemaVersion = SCHEMA_VERSION
 	if resOrg != value {
 		fmt.Println("Query value was not as expected")
 		t.FailNow()
@@ -92,8 +96,155 @@ func checkUpdateTraceable(t *testing.T, stub *shim.MockStub, orgAsJSON []byte, v
 		fmt.Println("Failed to decode json of ORG:", err.Error())
 		t.FailNow()
 	}
+	// value holds the version the update expected
+	value.Version++
+	value.SchemaVersion = SCHEMA_VERSION
 	if resOrg != value {
 		fmt.Println("Query value was not as expected")
 		t.FailNow()
 	}
 }
+
+func TestFood_ReparentTraceable(t *testing.T) {
+	scc := new(FoodChaincode)
+	stub := shim.NewMockStub("food", scc)
+
+	checkInit(t, stub, [][]byte{})
+
+	for _, traceable := range []Traceable{
+		{ObjectType: "org", ID: "org_1", Name: "org 1"},
+		{ObjectType: "org", ID: "org_2", Name: "org 2"},
+		{ObjectType: "party", ID: "party_1", Name: "party 1", Parent: "org_1"},
+	} {
+		traceableAsBytes, err := json.Marshal(traceable)
+		if err != nil {
+			fmt.Println("Failed to encode json")
+			t.FailNow()
+		}
+		checkCreateTraceable(t, stub, traceableAsBytes, traceable)
+	}
+	checkChildren(t, stub, "org_1", 1)
+
+	updatedParty := Traceable{ObjectType: "party", ID: "party_1", Name: "party 1", Parent: "org_2", Version: 1}
+	updatedPartyAsBytes, err := json.Marshal(updatedParty)
+	if err != nil {
+		fmt.Println("Failed to encode json")
+		t.FailNow()
+	}
+	checkUpdateTraceable(t, stub, updatedPartyAsBytes, updatedParty)
+	checkChildren(t, stub, "org_1", 0)
+	checkChildren(t, stub, "org_2", 1)
+}
+
+func TestFood_ReparentTraceableCycle(t *testing.T) {
+	scc := new(FoodChaincode)
+	stub := shim.NewMockStub("food", scc)
+
+	checkInit(t, stub, [][]byte{})
+
+	for _, traceable := range []Traceable{
+		{ObjectType: "org", ID: "org_1", Name: "org 1"},
+		{ObjectType: "party", ID: "party_1", Name: "party 1", Parent: "org_1"},
+		{ObjectType: "location", ID: "location_1", Name: "location 1", Parent: "party_1"},
+	} {
+		traceableAsBytes, err := json.Marshal(traceable)
+		if err != nil {
+			fmt.Println("Failed to encode json")
+			t.FailNow()
+		}
+		checkCreateTraceable(t, stub, traceableAsBytes, traceable)
+	}
+
+	updatedOrg := Traceable{ObjectType: "org", ID: "org_1", Name: "org 1", Parent: "location_1", Version: 1}
+	updatedOrgAsBytes, err := json.Marshal(updatedOrg)
+	if err != nil {
+		fmt.Println("Failed to encode json")
+		t.FailNow()
+	}
+	res := stub.MockInvoke("1", [][]byte{[]byte("updateTraceable"), updatedOrgAsBytes})
+	checkErrorCode(t, res, ccerror.INVALID_REFERENCE)
+	checkChildren(t, stub, "location_1", 0)
+}
+
+func TestFood_CreateTraceableOfReservedType(t *testing.T) {
+	scc := new(FoodChaincode)
+	stub := shim.NewMockStub("food", scc)
+
+	checkInit(t, stub, [][]byte{})
+
+	for _, objectType := range []string{TYPE_LOG, TYPE_AUDITOR, TYPE_AUDITACTION, TYPE_RECALL, TYPE_POLICY,
+		TYPE_TOMBSTONE, TYPE_CTE_SCHEMA, TYPE_LOG_PRIVATE, TYPE_CONFIG} {
+		traceableAsBytes, err := json.Marshal(Traceable{ObjectType: objectType, ID: "object_1", Name: "object 1"})
+		if err != nil {
+			fmt.Println("Failed to encode json")
+			t.FailNow()
+		}
+		res := stub.MockInvoke("1", [][]byte{[]byte("createTraceable"), traceableAsBytes})
+		checkErrorCode(t, res, ccerror.TYPE_MISMATCH)
+	}
+}
+
+func TestFood_GetTraceableTree(t *testing.T) {
+	scc := new(FoodChaincode)
+	stub := shim.NewMockStub("food", scc)
+
+	checkInit(t, stub, [][]byte{})
+
+	newData := InitData{
+		Traceable: []Traceable{
+			{ObjectType: "org", ID: "org_1", Name: "org 1"},
+			{ObjectType: "party", ID: "party_1", Name: "party 1", Parent: "org_1"},
+			{ObjectType: "party", ID: "party_2", Name: "party 2", Parent: "org_1"},
+			{ObjectType: "location", ID: "location_1", Name: "location 1", Parent: "party_1"},
+		},
+	}
+	newDataAsBytes, err := json.Marshal(newData)
+	if err != nil {
+		fmt.Println("Failed to encode json")
+		t.FailNow()
+	}
+	res := stub.MockInvoke("1", [][]byte{[]byte("initOrgData"), newDataAsBytes})
+	if res.Status != shim.OK {
+		fmt.Println("failed", string(res.Message))
+		t.FailNow()
+	}
+
+	res = stub.MockInvoke("1", [][]byte{[]byte("getTraceableTree"), []byte("org_1")})
+	if res.Status != shim.OK {
+		fmt.Println("failed", string(res.Message))
+		t.FailNow()
+	}
+	tree := TraceableTree{}
+	err = json.Unmarshal(res.Payload, &tree)
+	if err != nil {
+		fmt.Println("Failed to decode json of TraceableTree:", err.Error())
+		t.FailNow()
+	}
+	if tree.ID != "org_1" || len(tree.Children) != 2 {
+		fmt.Println("Query value was not as expected")
+		t.FailNow()
+	}
+	if tree.Children[0].ID != "party_1" || len(tree.Children[0].Children) != 1 {
+		fmt.Println("Query value was not as expected")
+		t.FailNow()
+	}
+}
+
+func checkChildren(t *testing.T, stub *shim.MockStub, ID string, expected int) {
+	res := stub.MockInvoke("1", [][]byte{[]byte("getChildrenOfTraceable"), []byte(ID)})
+	if res.Status != shim.OK {
+		fmt.Println("failed", string(res.Message))
+		t.FailNow()
+	}
+
+	children := []Traceable{}
+	err := json.Unmarshal(res.Payload, &children)
+	if err != nil {
+		fmt.Println("Failed to decode json of Traceables:", err.Error())
+		t.FailNow()
+	}
+	if len(children) != expected {
+		fmt.Println("Size of response does not match")
+		t.FailNow()
+	}
+}
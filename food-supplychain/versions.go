@@ -0,0 +1,93 @@
+package main
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"github.com/deevotech/sc-chaincode.deevo.io/lib/ccerror"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+// versionOf returns the version of a stored object, objects stored before
+// versions were maintained have version 0
+func versionOf(objectAsBytes []byte) (int64, error) {
+	object := struct {
+		Version int64 `json:"version"`
+	}{}
+	err := json.Unmarshal(objectAsBytes, &object)
+	return object.Version, err
+}
+
+// withVersion sets the version of an object and the schema version it is
+// written in. Other fields are kept as they are, also the ones which are not
+// in the model.
+func withVersion(objectAsBytes []byte, version int64) ([]byte, error) {
+	object := map[string]json.RawMessage{}
+	err := json.Unmarshal(objectAsBytes, &object)
+	if err != nil {
+		return nil, err
+	}
+	object["version"] = json.RawMessage(strconv.FormatInt(version, 10))
+	object["schemaVersion"] = json.RawMessage(strconv.Itoa(SCHEMA_VERSION))
+	return json.Marshal(object)
+}
+
+// checkVersion compares the version an update expects with the stored one
+// and returns the object with the next version
+func checkVersion(ID string, existedObjectAsBytes []byte, newObjectAsBytes []byte) (pb.Response, []byte) {
+	current, err := versionOf(existedObjectAsBytes)
+	if err != nil {
+		return ccerror.Internal("Failed to get version of Object with ID: " + ID + ", error: " + err.Error()), nil
+	}
+	expected, err := versionOf(newObjectAsBytes)
+	if err != nil {
+		return ccerror.Internal("Failed to get expected version of Object with ID: " + ID + ", error: " + err.Error()), nil
+	}
+	if expected != current {
+		return versionConflict(ID, expected, current), nil
+	}
+
+	newObjectAsBytes, err = withVersion(newObjectAsBytes, current+1)
+	if err != nil {
+		return ccerror.Internal("Failed to set version of Object with ID: " + ID + ", error: " + err.Error()), nil
+	}
+	return shim.Success(nil), newObjectAsBytes
+}
+
+// putVersioned stores an object which is set rather than created and
+// updated. The first set stores version 1, later ones have to expect the
+// stored version.
+func putVersioned(stub shim.ChaincodeStubInterface, key string, ID string, newObjectAsBytes []byte) pb.Response {
+	existedObjectAsBytes, err := stub.GetState(key)
+	if err != nil {
+		return ccerror.Internal("Failed to get existed Object with ID: " + ID + ", error: " + err.Error())
+	}
+
+	if existedObjectAsBytes == nil {
+		newObjectAsBytes, err = withVersion(newObjectAsBytes, 1)
+		if err != nil {
+			return ccerror.Internal("Failed to set version of Object with ID: " + ID + ", error: " + err.Error())
+		}
+	} else {
+		var result pb.Response
+		result, newObjectAsBytes = checkVersion(ID, existedObjectAsBytes, newObjectAsBytes)
+		if result.Status != shim.OK {
+			return result
+		}
+	}
+
+	err = stub.PutState(key, newObjectAsBytes)
+	if err != nil {
+		return ccerror.Internal("Failed to save Object with ID: " + ID + ", error: " + err.Error())
+	}
+	return shim.Success(nil)
+}
+
+// versionConflict tells the client to read the object again
+func versionConflict(ID string, expected int64, current int64) pb.Response {
+	return ccerror.WithDetails(ccerror.VERSION_CONFLICT,
+		"Object with ID "+ID+" has version "+strconv.FormatInt(current, 10)+", expected "+strconv.FormatInt(expected, 10),
+		map[string]interface{}{"id": ID, "expected": expected, "current": current})
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/deevotech/sc-chaincode.deevo.io/lib/ccerror"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+func TestFood_UpdateTraceableWithStaleVersion(t *testing.T) {
+	scc := new(FoodChaincode)
+	stub := shim.NewMockStub("food", scc)
+
+	checkInit(t, stub, [][]byte{})
+	checkCreateReferences(t, stub, map[string]string{"org_1": "org"})
+
+	// two operators read version 1, the first update wins
+	first := Traceable{ObjectType: "org", ID: "org_1", Name: "org_1", Content: "address 1", Version: 1}
+	firstAsBytes, err := json.Marshal(first)
+	if err != nil {
+		fmt.Println("Failed to encode json")
+		t.FailNow()
+	}
+	checkUpdateTraceable(t, stub, firstAsBytes, first)
+
+	second := Traceable{ObjectType: "org", ID: "org_1", Name: "org_1", Content: "address 2", Version: 1}
+	checkVersionConflict(t, stub, "updateTraceable", second)
+
+	second.Version = 2
+	secondAsBytes, err := json.Marshal(second)
+	if err != nil {
+		fmt.Println("Failed to encode json")
+		t.FailNow()
+	}
+	checkUpdateTraceable(t, stub, secondAsBytes, second)
+}
+
+func TestFood_UpdateWithoutVersion(t *testing.T) {
+	scc := new(FoodChaincode)
+	stub := shim.NewMockStub("food", scc)
+
+	checkInit(t, stub, [][]byte{})
+	checkCreateReferences(t, stub, map[string]string{"Auditor_1": TYPE_AUDITOR})
+	checkCreateLogAt(t, stub, Log{ObjectType: TYPE_LOG, ID: "Log_1", Time: 100, Ref: []string{}, CTE: "shipping"})
+
+	checkVersionConflict(t, stub, "updateLog", Log{ObjectType: TYPE_LOG, ID: "Log_1", Time: 100, Ref: []string{}, CTE: "receiving"})
+	checkVersionConflict(t, stub, "updateAuditor", Auditor{ObjectType: TYPE_AUDITOR, ID: "Auditor_1", Name: "Auditor 1", Accreditations: []Accreditation{testAccreditation}})
+
+	log := Log{}
+	json.Unmarshal(stub.State["Log_1"], &log)
+	if log.CTE != "shipping" || log.Version != 1 {
+		fmt.Println("Log should be unchanged", log)
+		t.FailNow()
+	}
+}
+
+func TestFood_SetWithStaleVersion(t *testing.T) {
+	scc := new(FoodChaincode)
+	stub := shim.NewMockStub("food", scc)
+
+	checkInit(t, stub, [][]byte{})
+	setMockIdentity(TEST_MSP, map[string]string{ADMIN_ATTRIBUTE: "true"})
+
+	schema := CTESchema{ObjectType: TYPE_CTE_SCHEMA, CTE: "shipping", KDEs: []string{"traceabilityLotCode"}}
+	policy := FunctionPolicy{ObjectType: TYPE_POLICY, Function: "createAuditor", MSPIDs: []string{TEST_MSP}}
+	for _, set := range []struct {
+		function string
+		value    interface{}
+	}{{"setCTESchema", &schema}, {"setFunctionPolicy", &policy}} {
+		// the first set stores version 1 whatever the value expects
+		checkSetVersion(t, stub, set.function, set.value)
+		checkVersionConflict(t, stub, set.function, set.value)
+	}
+
+	schema.Version = 1
+	policy.Version = 1
+	checkSetVersion(t, stub, "setCTESchema", schema)
+	checkSetVersion(t, stub, "setFunctionPolicy", policy)
+
+	stored := CTESchema{}
+	key, _ := stub.CreateCompositeKey(CK_CTE_SCHEMA, []string{"shipping"})
+	json.Unmarshal(stub.State[key], &stored)
+	if stored.Version != 2 || stored.SchemaVersion != SCHEMA_VERSION {
+		fmt.Println("CTESchema should have version 2", stored)
+		t.FailNow()
+	}
+	res := stub.MockInvoke("1", [][]byte{[]byte("getFunctionPolicies")})
+	policies := []FunctionPolicy{}
+	json.Unmarshal(res.Payload, &policies)
+	if len(policies) != 1 || policies[0].Version != 2 {
+		fmt.Println("FunctionPolicy should have version 2", string(res.Payload))
+		t.FailNow()
+	}
+}
+
+func checkSetVersion(t *testing.T, stub *shim.MockStub, function string, value interface{}) {
+	valueAsBytes, err := json.Marshal(value)
+	if err != nil {
+		fmt.Println("Failed to encode json")
+		t.FailNow()
+	}
+	res := stub.MockInvoke("1", [][]byte{[]byte(function), valueAsBytes})
+	if res.Status != shim.OK {
+		fmt.Println(function, "failed", string(res.Message))
+		t.FailNow()
+	}
+}
+
+func checkVersionConflict(t *testing.T, stub *shim.MockStub, function string, value interface{}) {
+	valueAsBytes, err := json.Marshal(value)
+	if err != nil {
+		fmt.Println("Failed to encode json")
+		t.FailNow()
+	}
+	res := stub.MockInvoke("1", [][]byte{[]byte(function), valueAsBytes})
+	if res.Status != ccerror.Status(ccerror.VERSION_CONFLICT) {
+		fmt.Println(function, "should fail with a version conflict", res.Status, res.Message)
+		t.FailNow()
+	}
+}
@@ -0,0 +1,125 @@
+// Package ccconfig stores the config of a chaincode on a channel. The current
+// version is kept under one key and every version is added to the history,
+// so all chaincodes store, list and attribute their configs the same way.
+// The fields of a config are up to the chaincode, every config has an
+// objectType, adminMSPs, an Updater and a version.
+package ccconfig
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric/core/chaincode/lib/cid"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+const (
+	// OBJECT_TYPE is the objectType of every config
+	OBJECT_TYPE = "config"
+	// KEY_SETTING holds the config under the name SETTING
+	KEY_SETTING = "setting~name"
+	SETTING     = "config"
+	// KEY_HISTORY holds every version of the config
+	KEY_HISTORY = "history~config"
+)
+
+// Updater is the client which stored a version of a config
+type Updater struct {
+	MSPID   string `json:"mspId"`
+	Subject string `json:"subject"`
+	Time    int64  `json:"time"`
+}
+
+// NewUpdater returns the MSP ID and certificate subject of the client of a
+// transaction together with the transaction timestamp
+func NewUpdater(stub shim.ChaincodeStubInterface, identity cid.ClientIdentity) (Updater, error) {
+	updater := Updater{}
+
+	mspID, err := identity.GetMSPID()
+	if err != nil {
+		return updater, fmt.Errorf("Failed to get MSP ID of client: %s", err)
+	}
+	updater.MSPID = mspID
+
+	cert, err := identity.GetX509Certificate()
+	if err != nil {
+		return updater, fmt.Errorf("Failed to get certificate of client: %s", err)
+	}
+	// idemix identities have no certificate
+	if cert != nil {
+		updater.Subject = cert.Subject.String()
+	}
+
+	txTimestamp, err := stub.GetTxTimestamp()
+	if err != nil {
+		return updater, fmt.Errorf("Failed to get transaction timestamp: %s", err)
+	}
+	updater.Time = txTimestamp.GetSeconds()
+	return updater, nil
+}
+
+// Read returns the json of the stored config, nil before one was stored
+func Read(stub shim.ChaincodeStubInterface) ([]byte, error) {
+	configKey, err := stub.CreateCompositeKey(KEY_SETTING, []string{SETTING})
+	if err != nil {
+		return nil, err
+	}
+	return stub.GetState(configKey)
+}
+
+// Put stores the json of a config as the given version and adds it to the
+// history. The version in the json has to be the same.
+func Put(stub shim.ChaincodeStubInterface, configAsBytes []byte, version int64) error {
+	configKey, err := stub.CreateCompositeKey(KEY_SETTING, []string{SETTING})
+	if err != nil {
+		return err
+	}
+	err = stub.PutState(configKey, configAsBytes)
+	if err != nil {
+		return err
+	}
+
+	historyKey, err := stub.CreateCompositeKey(KEY_HISTORY, []string{historyID(version)})
+	if err != nil {
+		return err
+	}
+	return stub.PutState(historyKey, configAsBytes)
+}
+
+// historyID pads a version, so the history is ordered by version
+func historyID(version int64) string {
+	return fmt.Sprintf("%020d", version)
+}
+
+// History returns the json of every version of the config, oldest first
+func History(stub shim.ChaincodeStubInterface) ([]json.RawMessage, error) {
+	resultsIterator, err := stub.GetStateByPartialCompositeKey(KEY_HISTORY, []string{})
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	configs := []json.RawMessage{}
+	for resultsIterator.HasNext() {
+		responseRange, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		configs = append(configs, json.RawMessage(responseRange.Value))
+	}
+	return configs, nil
+}
+
+// IsAdmin reports whether an MSP is one of the adminMSPs of a config, empty
+// adminMSPs hold every MSP
+func IsAdmin(adminMSPs []string, mspID string) bool {
+	if len(adminMSPs) == 0 {
+		return true
+	}
+	for _, adminMSP := range adminMSPs {
+		if adminMSP == mspID {
+			return true
+		}
+	}
+	return false
+}
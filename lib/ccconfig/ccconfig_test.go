@@ -0,0 +1,80 @@
+package ccconfig
+
+import (
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+type identity struct {
+	mspID string
+}
+
+func (i identity) GetID() (string, error)    { return "id", nil }
+func (i identity) GetMSPID() (string, error) { return i.mspID, nil }
+func (i identity) GetAttributeValue(attrName string) (string, bool, error) {
+	return "", false, nil
+}
+func (i identity) AssertAttributeValue(attrName, attrValue string) error {
+	return fmt.Errorf("attribute %s is not set", attrName)
+}
+func (i identity) GetX509Certificate() (*x509.Certificate, error) { return nil, nil }
+
+type config struct {
+	ObjectType string  `json:"objectType"`
+	Updater    Updater `json:"updater"`
+	Version    int64   `json:"version"`
+}
+
+func TestPut_History(t *testing.T) {
+	stub := shim.NewMockStub("ccconfig", nil)
+	stub.MockTransactionStart("1")
+	defer stub.MockTransactionEnd("1")
+
+	configAsBytes, err := Read(stub)
+	if err != nil || configAsBytes != nil {
+		t.Fatalf("Expected no config before Put, got %s %v", configAsBytes, err)
+	}
+
+	updater, err := NewUpdater(stub, identity{mspID: "Org1MSP"})
+	if err != nil || updater.MSPID != "Org1MSP" || updater.Time <= 0 {
+		t.Fatalf("Unexpected updater %+v %v", updater, err)
+	}
+	// the history is ordered by version, not by the digits of it
+	for _, version := range []int64{9, 10} {
+		configAsBytes, _ := json.Marshal(config{ObjectType: OBJECT_TYPE, Updater: updater, Version: version})
+		err = Put(stub, configAsBytes, version)
+		if err != nil {
+			t.Fatalf("Put failed: %s", err)
+		}
+	}
+
+	current := config{}
+	configAsBytes, err = Read(stub)
+	if err != nil || json.Unmarshal(configAsBytes, &current) != nil || current.Version != 10 {
+		t.Fatalf("Expected version 10, got %s %v", configAsBytes, err)
+	}
+
+	configs, err := History(stub)
+	if err != nil || len(configs) != 2 {
+		t.Fatalf("Expected 2 versions, got %d %v", len(configs), err)
+	}
+	for i, version := range []int64{9, 10} {
+		stored := config{}
+		if json.Unmarshal(configs[i], &stored) != nil || stored.Version != version || stored.Updater != updater {
+			t.Fatalf("Expected version %d at %d, got %s", version, i, configs[i])
+		}
+	}
+}
+
+func TestIsAdmin(t *testing.T) {
+	if !IsAdmin(nil, "Org1MSP") {
+		t.Fatalf("Expected empty adminMSPs to hold every MSP")
+	}
+	if !IsAdmin([]string{"Org1MSP"}, "Org1MSP") || IsAdmin([]string{"Org1MSP"}, "Org2MSP") {
+		t.Fatalf("Expected only Org1MSP to be an admin")
+	}
+}
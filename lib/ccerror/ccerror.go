@@ -0,0 +1,118 @@
+// Package ccerror is the error model shared by the chaincodes. An error
+// response has the status of its code and a json Body as message, so
+// clients can switch on the code instead of parsing the message.
+package ccerror
+
+import (
+	"encoding/json"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+// Code is a stable, machine readable error code
+type Code string
+
+const (
+	VALIDATION_FAILED Code = "VALIDATION_FAILED"
+	TYPE_MISMATCH     Code = "TYPE_MISMATCH"
+	INVALID_REFERENCE Code = "INVALID_REFERENCE"
+	FORBIDDEN         Code = "FORBIDDEN"
+	NOT_ACCREDITED    Code = "NOT_ACCREDITED"
+	NOT_FOUND         Code = "NOT_FOUND"
+	ALREADY_EXISTS    Code = "ALREADY_EXISTS"
+	VERSION_CONFLICT  Code = "VERSION_CONFLICT"
+	INTERNAL          Code = "INTERNAL"
+)
+
+// statuses are the response statuses of the codes. All of them are at or
+// above shim.ERRORTHRESHOLD, so the proposal fails.
+var statuses = map[Code]int32{
+	VALIDATION_FAILED: 400,
+	TYPE_MISMATCH:     400,
+	INVALID_REFERENCE: 422,
+	FORBIDDEN:         403,
+	NOT_ACCREDITED:    403,
+	NOT_FOUND:         404,
+	ALREADY_EXISTS:    409,
+	VERSION_CONFLICT:  409,
+	INTERNAL:          shim.ERROR,
+}
+
+// Body is the json message of an error response
+type Body struct {
+	Code    Code                   `json:"code"`
+	Message string                 `json:"message"`
+	Details map[string]interface{} `json:"details,omitempty"`
+}
+
+// Status returns the response status of a code, the one of INTERNAL for
+// unknown codes
+func Status(code Code) int32 {
+	status, ok := statuses[code]
+	if !ok {
+		return statuses[INTERNAL]
+	}
+	return status
+}
+
+// New returns the error response of a code
+func New(code Code, message string) pb.Response {
+	return WithDetails(code, message, nil)
+}
+
+// WithDetails returns the error response of a code with details, which have
+// to be json encodable
+func WithDetails(code Code, message string, details map[string]interface{}) pb.Response {
+	bodyAsBytes, err := json.Marshal(Body{Code: code, Message: message, Details: details})
+	if err != nil {
+		return pb.Response{Status: statuses[INTERNAL], Message: `{"code":"` + string(INTERNAL) + `","message":"Failed to encode error"}`}
+	}
+	return pb.Response{Status: Status(code), Message: string(bodyAsBytes)}
+}
+
+// Parse returns the body of an error response. Messages which are not a
+// Body, e.g. from shim.Error, are INTERNAL errors.
+func Parse(response pb.Response) Body {
+	body := Body{}
+	err := json.Unmarshal([]byte(response.Message), &body)
+	if err != nil || len(body.Code) == 0 {
+		return Body{Code: INTERNAL, Message: response.Message}
+	}
+	return body
+}
+
+// Prefix puts context in front of the message of an error response and
+// keeps its code and details
+func Prefix(prefix string, response pb.Response) pb.Response {
+	body := Parse(response)
+	return WithDetails(body.Code, prefix+body.Message, body.Details)
+}
+
+func ValidationFailed(message string) pb.Response {
+	return New(VALIDATION_FAILED, message)
+}
+
+func TypeMismatch(message string) pb.Response {
+	return New(TYPE_MISMATCH, message)
+}
+
+func Forbidden(message string) pb.Response {
+	return New(FORBIDDEN, message)
+}
+
+func NotAccredited(message string) pb.Response {
+	return New(NOT_ACCREDITED, message)
+}
+
+func NotFound(message string) pb.Response {
+	return New(NOT_FOUND, message)
+}
+
+func AlreadyExists(message string) pb.Response {
+	return New(ALREADY_EXISTS, message)
+}
+
+func Internal(message string) pb.Response {
+	return New(INTERNAL, message)
+}
@@ -0,0 +1,43 @@
+package ccerror
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+func TestWithDetails(t *testing.T) {
+	res := WithDetails(VERSION_CONFLICT, "Object with ID Log_1 has version 2, expected 1", map[string]interface{}{"id": "Log_1"})
+	if res.Status != 409 {
+		t.Fatalf("Expected status 409, got %d", res.Status)
+	}
+	body := Parse(res)
+	if body.Code != VERSION_CONFLICT || body.Details["id"] != "Log_1" {
+		t.Fatalf("Unexpected body %+v", body)
+	}
+}
+
+func TestStatus(t *testing.T) {
+	for code := range statuses {
+		if Status(code) < shim.ERRORTHRESHOLD {
+			t.Fatalf("Status of %s does not fail the proposal", code)
+		}
+	}
+	if Status("UNKNOWN") != shim.ERROR {
+		t.Fatalf("Unknown codes should have the status of INTERNAL")
+	}
+}
+
+func TestParse(t *testing.T) {
+	body := Parse(shim.Error("plain message"))
+	if body.Code != INTERNAL || body.Message != "plain message" {
+		t.Fatalf("Unexpected body %+v", body)
+	}
+}
+
+func TestPrefix(t *testing.T) {
+	body := Parse(Prefix("Event 1: ", NotFound("Log with ID Log_1 does not exist")))
+	if body.Code != NOT_FOUND || body.Message != "Event 1: Log with ID Log_1 does not exist" {
+		t.Fatalf("Unexpected body %+v", body)
+	}
+}
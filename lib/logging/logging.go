@@ -0,0 +1,299 @@
+// Package logging writes leveled, structured logs of the chaincodes. Every
+// entry is a json object on a line of its own. The values of fields whose key
+// is in the redaction rules are replaced, so the data of a ledger does not end
+// up in the logs of the peers.
+//
+// The level and the redaction rules come from the environment and can be
+// changed for a channel with Configure, the chaincodes do that with the
+// logging settings of their config.
+package logging
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+// Level of an entry, entries below the level of a channel are not written
+type Level int
+
+const (
+	DEBUG Level = iota
+	INFO
+	WARNING
+	ERROR
+)
+
+// REDACTED replaces the values of redacted fields
+const REDACTED = "[REDACTED]"
+
+// Environment variables of the defaults. CORE_CHAINCODE_LOGGING_LEVEL is set
+// by the peer for its chaincodes.
+const (
+	ENV_LEVEL      = "CHAINCODE_LOGGING_LEVEL"
+	ENV_PEER_LEVEL = "CORE_CHAINCODE_LOGGING_LEVEL"
+	ENV_REDACT     = "CHAINCODE_LOGGING_REDACT"
+)
+
+// DefaultRedact are the keys which are always redacted
+var DefaultRedact = []string{"content", "certificate", "cert", "publicKey", "privateKey", "salt", "password"}
+
+var levelNames = map[Level]string{DEBUG: "DEBUG", INFO: "INFO", WARNING: "WARNING", ERROR: "ERROR"}
+
+func (level Level) String() string {
+	if name, ok := levelNames[level]; ok {
+		return name
+	}
+	return fmt.Sprintf("Level(%d)", int(level))
+}
+
+// ParseLevel returns the level of a name, the names of the peer's log levels
+// are accepted too
+func ParseLevel(name string) (Level, error) {
+	switch strings.ToUpper(strings.TrimSpace(name)) {
+	case "DEBUG":
+		return DEBUG, nil
+	case "INFO", "NOTICE":
+		return INFO, nil
+	case "WARNING", "WARN":
+		return WARNING, nil
+	case "ERROR", "CRITICAL", "FATAL", "PANIC":
+		return ERROR, nil
+	}
+	return INFO, fmt.Errorf("unknown log level %q, expected DEBUG, INFO, WARNING or ERROR", name)
+}
+
+// Fields of an entry
+type Fields map[string]interface{}
+
+// Settings of the logs of a channel. Redact adds keys to the defaults, a
+// default can not be removed.
+type Settings struct {
+	Level  string   `json:"level,omitempty"`
+	Redact []string `json:"redact,omitempty"`
+}
+
+// Check returns an error when the settings are not valid
+func (s Settings) Check() error {
+	if len(s.Level) > 0 {
+		_, err := ParseLevel(s.Level)
+		if err != nil {
+			return err
+		}
+	}
+	for _, key := range s.Redact {
+		if len(normalize(key)) < 1 {
+			return fmt.Errorf("redacted keys can not be empty")
+		}
+	}
+	return nil
+}
+
+type rules struct {
+	level  Level
+	redact map[string]bool
+}
+
+var state = struct {
+	sync.RWMutex
+	out      io.Writer
+	defaults rules
+	channels map[string]rules
+}{out: os.Stdout, channels: map[string]rules{}}
+
+func init() {
+	state.defaults = defaultRules(os.Getenv)
+}
+
+func defaultRules(getenv func(string) string) rules {
+	defaults := rules{level: INFO, redact: map[string]bool{}}
+	for _, key := range DefaultRedact {
+		defaults.redact[normalize(key)] = true
+	}
+	for _, name := range []string{getenv(ENV_LEVEL), getenv(ENV_PEER_LEVEL)} {
+		if len(name) > 0 {
+			level, err := ParseLevel(name)
+			if err == nil {
+				defaults.level = level
+				break
+			}
+		}
+	}
+	for _, key := range strings.Split(getenv(ENV_REDACT), ",") {
+		if len(normalize(key)) > 0 {
+			defaults.redact[normalize(key)] = true
+		}
+	}
+	return defaults
+}
+
+// normalize makes publicKey, public_key and PUBLIC-KEY the same key
+func normalize(key string) string {
+	key = strings.ToLower(strings.TrimSpace(key))
+	return strings.NewReplacer("_", "", "-", "").Replace(key)
+}
+
+// Configure replaces the settings of a channel. Empty settings use the
+// defaults.
+func Configure(channelID string, settings Settings) error {
+	err := settings.Check()
+	if err != nil {
+		return err
+	}
+
+	state.Lock()
+	defer state.Unlock()
+	if len(settings.Level) < 1 && len(settings.Redact) < 1 {
+		delete(state.channels, channelID)
+		return nil
+	}
+	channel := rules{level: state.defaults.level, redact: map[string]bool{}}
+	if len(settings.Level) > 0 {
+		channel.level, _ = ParseLevel(settings.Level)
+	}
+	for key := range state.defaults.redact {
+		channel.redact[key] = true
+	}
+	for _, key := range settings.Redact {
+		channel.redact[normalize(key)] = true
+	}
+	state.channels[channelID] = channel
+	return nil
+}
+
+// SetOutput changes where the entries are written to, it returns the
+// previous writer
+func SetOutput(out io.Writer) io.Writer {
+	state.Lock()
+	defer state.Unlock()
+	previous := state.out
+	state.out = out
+	return previous
+}
+
+// Logger writes the entries of a chaincode or package
+type Logger struct {
+	name string
+}
+
+// New returns the logger of a chaincode or package
+func New(name string) *Logger {
+	return &Logger{name: name}
+}
+
+// Debug writes an entry at level DEBUG. The stub adds the channel and the
+// transaction to the entry, it may be nil outside of transactions.
+func (l *Logger) Debug(stub shim.ChaincodeStubInterface, msg string, fields ...Fields) {
+	l.write(stub, DEBUG, msg, fields)
+}
+
+// Info writes an entry at level INFO
+func (l *Logger) Info(stub shim.ChaincodeStubInterface, msg string, fields ...Fields) {
+	l.write(stub, INFO, msg, fields)
+}
+
+// Warning writes an entry at level WARNING
+func (l *Logger) Warning(stub shim.ChaincodeStubInterface, msg string, fields ...Fields) {
+	l.write(stub, WARNING, msg, fields)
+}
+
+// Error writes an entry at level ERROR
+func (l *Logger) Error(stub shim.ChaincodeStubInterface, msg string, fields ...Fields) {
+	l.write(stub, ERROR, msg, fields)
+}
+
+// rulesOf returns the rules of the channel of a stub, the lock must be held
+func rulesOf(stub shim.ChaincodeStubInterface) rules {
+	if stub != nil {
+		if channel, ok := state.channels[stub.GetChannelID()]; ok {
+			return channel
+		}
+	}
+	return state.defaults
+}
+
+func (l *Logger) write(stub shim.ChaincodeStubInterface, level Level, msg string, fields []Fields) {
+	state.RLock()
+	rules := rulesOf(stub)
+	state.RUnlock()
+	if level < rules.level {
+		return
+	}
+
+	entry := map[string]interface{}{
+		"time":   time.Now().UTC().Format(time.RFC3339Nano),
+		"level":  level.String(),
+		"logger": l.name,
+		"msg":    msg,
+	}
+	if stub != nil {
+		entry["channel"] = stub.GetChannelID()
+		entry["tx"] = stub.GetTxID()
+	}
+	merged := map[string]interface{}{}
+	for _, f := range fields {
+		for key, value := range f {
+			merged[key] = value
+		}
+	}
+	if len(merged) > 0 {
+		entry["fields"] = redact(merged, rules.redact)
+	}
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		line, _ = json.Marshal(map[string]interface{}{"level": ERROR.String(), "logger": l.name, "msg": msg,
+			"error": "Failed to encode fields: " + err.Error()})
+	}
+
+	state.Lock()
+	defer state.Unlock()
+	state.out.Write(append(line, '\n'))
+}
+
+// redact returns a copy of a value without the values of the redacted keys.
+// Structs and other values are converted to their json first, so their
+// fields are redacted by their json names.
+func redact(value interface{}, keys map[string]bool) interface{} {
+	switch v := value.(type) {
+	case nil, bool, string, int, int32, int64, uint, uint32, uint64, float32, float64:
+		return v
+	case error:
+		return v.Error()
+	case map[string]interface{}:
+		copied := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			if keys[normalize(key)] {
+				copied[key] = REDACTED
+			} else {
+				copied[key] = redact(item, keys)
+			}
+		}
+		return copied
+	case Fields:
+		return redact(map[string]interface{}(v), keys)
+	case []interface{}:
+		copied := make([]interface{}, len(v))
+		for i, item := range v {
+			copied[i] = redact(item, keys)
+		}
+		return copied
+	}
+
+	asJSON, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	var decoded interface{}
+	err = json.Unmarshal(asJSON, &decoded)
+	if err != nil {
+		return string(asJSON)
+	}
+	return redact(decoded, keys)
+}
@@ -0,0 +1,118 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+type account struct {
+	Name      string `json:"name"`
+	PublicKey string `json:"publickey"`
+}
+
+func entries(t *testing.T, out *bytes.Buffer) []map[string]interface{} {
+	result := []map[string]interface{}{}
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		if len(line) < 1 {
+			continue
+		}
+		entry := map[string]interface{}{}
+		err := json.Unmarshal([]byte(line), &entry)
+		if err != nil {
+			t.Fatalf("Entry %s is not json: %s", line, err)
+		}
+		result = append(result, entry)
+	}
+	return result
+}
+
+func TestParseLevel(t *testing.T) {
+	for name, level := range map[string]Level{"debug": DEBUG, "INFO": INFO, "warn": WARNING, "critical": ERROR} {
+		parsed, err := ParseLevel(name)
+		if err != nil || parsed != level {
+			t.Fatalf("Expected %s for %s, got %s %v", level, name, parsed, err)
+		}
+	}
+	if _, err := ParseLevel("LOUD"); err == nil {
+		t.Fatalf("Expected an unknown level to fail")
+	}
+	if err := (Settings{Redact: []string{" "}}).Check(); err == nil {
+		t.Fatalf("Expected an empty redacted key to fail")
+	}
+}
+
+func TestDefaultRules(t *testing.T) {
+	env := map[string]string{ENV_PEER_LEVEL: "warning", ENV_REDACT: "iban, tax_id"}
+	defaults := defaultRules(func(key string) string { return env[key] })
+	if defaults.level != WARNING || !defaults.redact["iban"] || !defaults.redact["taxid"] || !defaults.redact["publickey"] {
+		t.Fatalf("Unexpected defaults %+v", defaults)
+	}
+
+	env[ENV_LEVEL] = "debug"
+	if defaultRules(func(key string) string { return env[key] }).level != DEBUG {
+		t.Fatalf("Expected %s to override %s", ENV_LEVEL, ENV_PEER_LEVEL)
+	}
+}
+
+func TestLogger_Redact(t *testing.T) {
+	var out bytes.Buffer
+	defer SetOutput(SetOutput(&out))
+
+	stub := shim.NewMockStub("logging", nil)
+	logger := New("test")
+	logger.Info(stub, "created", Fields{
+		"ID":          "Log_1",
+		"Content":     "secret recipe",
+		"account":     account{Name: "alice", PublicKey: "key"},
+		"certificate": []byte("cert"),
+		"items":       []interface{}{map[string]interface{}{"salt": "pepper"}},
+	})
+
+	logged := entries(t, &out)
+	if len(logged) != 1 || logged[0]["msg"] != "created" || logged[0]["level"] != "INFO" || logged[0]["logger"] != "test" {
+		t.Fatalf("Unexpected entries %v", logged)
+	}
+	if strings.Contains(out.String(), "secret") || strings.Contains(out.String(), "pepper") || strings.Contains(out.String(), `"key"`) {
+		t.Fatalf("Expected sensitive values to be redacted: %s", out.String())
+	}
+	fields := logged[0]["fields"].(map[string]interface{})
+	if fields["ID"] != "Log_1" || fields["account"].(map[string]interface{})["name"] != "alice" || fields["certificate"] != REDACTED {
+		t.Fatalf("Unexpected fields %v", fields)
+	}
+}
+
+func TestConfigure(t *testing.T) {
+	var out bytes.Buffer
+	defer SetOutput(SetOutput(&out))
+
+	stub := shim.NewMockStub("logging", nil)
+	stub.ChannelID = "channel1"
+	other := shim.NewMockStub("logging", nil)
+	other.ChannelID = "channel2"
+	defer Configure(stub.ChannelID, Settings{})
+
+	err := Configure(stub.ChannelID, Settings{Level: "ERROR", Redact: []string{"name"}})
+	if err != nil {
+		t.Fatalf("Configure failed: %s", err)
+	}
+	logger := New("test")
+	logger.Warning(stub, "skipped")
+	logger.Error(stub, "failed", Fields{"name": "alice"})
+	logger.Warning(other, "written")
+
+	logged := entries(t, &out)
+	if len(logged) != 2 || logged[0]["msg"] != "failed" || logged[0]["channel"] != "channel1" || logged[1]["msg"] != "written" {
+		t.Fatalf("Unexpected entries %v", logged)
+	}
+	if logged[0]["fields"].(map[string]interface{})["name"] != REDACTED {
+		t.Fatalf("Expected the redaction rules of the channel to apply: %v", logged[0])
+	}
+
+	if err := Configure(stub.ChannelID, Settings{Level: "LOUD"}); err == nil {
+		t.Fatalf("Expected invalid settings to fail")
+	}
+}
@@ -0,0 +1,63 @@
+package router
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+)
+
+// Kind is the kind of value an argument holds
+type Kind int
+
+const (
+	// STRING arguments may be empty
+	STRING Kind = iota
+	NON_EMPTY
+	INT
+	JSON
+)
+
+// Arg is an argument in the schema of a route
+type Arg struct {
+	Name string
+	Kind Kind
+}
+
+func (kind Kind) check(value string) error {
+	switch kind {
+	case NON_EMPTY:
+		if len(value) < 1 {
+			return errors.New("must be a non-empty string")
+		}
+	case INT:
+		_, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return errors.New("must be a numeric string")
+		}
+	case JSON:
+		if !json.Valid([]byte(value)) {
+			return errors.New("must be json")
+		}
+	}
+	return nil
+}
+
+// String returns an argument which may be empty
+func String(name string) Arg {
+	return Arg{Name: name, Kind: STRING}
+}
+
+// NonEmpty returns an argument which can not be empty
+func NonEmpty(name string) Arg {
+	return Arg{Name: name, Kind: NON_EMPTY}
+}
+
+// Int returns a numeric argument
+func Int(name string) Arg {
+	return Arg{Name: name, Kind: INT}
+}
+
+// JSONArg returns an argument which holds a json document
+func JSONArg(name string) Arg {
+	return Arg{Name: name, Kind: JSON}
+}
@@ -0,0 +1,259 @@
+// Package router dispatches the invocations of a chaincode with a table of
+// routes. A Route declares the arguments of a function, whether it writes
+// to the ledger and the permissions of its callers, the middleware of the
+// Router checks these before the handler runs.
+package router
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/deevotech/sc-chaincode.deevo.io/lib/ccerror"
+	"github.com/deevotech/sc-chaincode.deevo.io/lib/logging"
+
+	"github.com/hyperledger/fabric/core/chaincode/lib/cid"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+var logger = logging.New("router")
+
+// Handler runs a chaincode function
+type Handler func(stub shim.ChaincodeStubInterface, args []string) pb.Response
+
+// Middleware wraps the handler of a route
+type Middleware func(route Route, next Handler) Handler
+
+// Permission fails when the client may not call a route
+type Permission func(stub shim.ChaincodeStubInterface) pb.Response
+
+// Route is a chaincode function with the schema of its arguments
+type Route struct {
+	Name    string
+	Handler Handler
+	Args    []Arg
+	// Counts are the numbers of arguments the function takes, only len(Args)
+	// when empty
+	Counts []int
+	// Variadic functions take more arguments than Counts, these are not
+	// checked
+	Variadic bool
+	// ReadOnly functions can not write to the ledger
+	ReadOnly    bool
+	Permissions []Permission
+}
+
+// Router holds the routes of a chaincode
+type Router struct {
+	routes     []Route
+	handlers   map[string]Handler
+	middleware []Middleware
+}
+
+// New returns a Router, the first middleware is the outermost one
+func New(middleware ...Middleware) *Router {
+	return &Router{handlers: map[string]Handler{}, middleware: middleware}
+}
+
+// Register adds routes to the Router. A function can only be registered
+// once, registering it again is a programming error and panics.
+func (r *Router) Register(routes ...Route) {
+	for _, route := range routes {
+		if _, ok := r.handlers[route.Name]; ok {
+			panic("router: function " + route.Name + " is registered twice")
+		}
+		handler := route.Handler
+		for i := len(r.middleware) - 1; i >= 0; i-- {
+			handler = r.middleware[i](route, handler)
+		}
+		r.handlers[route.Name] = handler
+		r.routes = append(r.routes, route)
+	}
+}
+
+// Routes returns the registered routes in the order of registration
+func (r *Router) Routes() []Route {
+	return append([]Route{}, r.routes...)
+}
+
+// Invoke runs the function of an invocation
+func (r *Router) Invoke(stub shim.ChaincodeStubInterface) pb.Response {
+	function, args := stub.GetFunctionAndParameters()
+	handler, ok := r.handlers[function]
+	if !ok {
+		logger.Warning(stub, "invoke did not find func", logging.Fields{"function": function})
+		return ccerror.WithDetails(ccerror.VALIDATION_FAILED, "Received unknown function invocation",
+			map[string]interface{}{"function": function})
+	}
+	return handler(stub, args)
+}
+
+// Recover turns a panic of a handler into an INTERNAL error
+func Recover(route Route, next Handler) Handler {
+	return func(stub shim.ChaincodeStubInterface, args []string) (response pb.Response) {
+		defer func() {
+			if r := recover(); r != nil {
+				logger.Error(stub, "function panicked", logging.Fields{"function": route.Name, "panic": fmt.Sprint(r)})
+				response = ccerror.Internal(fmt.Sprintf("Function %s failed: %v", route.Name, r))
+			}
+		}()
+		return next(stub, args)
+	}
+}
+
+// Log writes every invocation and the errors of failed ones. The arguments
+// are not logged, they may hold data which should not end up in logs.
+func Log(route Route, next Handler) Handler {
+	return func(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+		logger.Info(stub, "invoke is running", logging.Fields{"function": route.Name, "args": len(args)})
+		response := next(stub, args)
+		if response.Status >= shim.ERRORTHRESHOLD {
+			body := ccerror.Parse(response)
+			logger.Warning(stub, "invoke failed", logging.Fields{"function": route.Name, "status": response.Status, "code": body.Code, "message": body.Message})
+		}
+		return response
+	}
+}
+
+// ConfigureLogging applies the logging settings of the channel before the
+// invocation is logged. settings returns them with the version of the config
+// they are part of, the settings are applied again when the version changes.
+// A proposal reads the committed config, so settings of a transaction which
+// is not committed never apply. When the settings can not be read the
+// previous ones stay.
+func ConfigureLogging(settings func(stub shim.ChaincodeStubInterface) (logging.Settings, int64, error)) Middleware {
+	var mutex sync.Mutex
+	applied := map[string]int64{}
+
+	return func(route Route, next Handler) Handler {
+		return func(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+			channelID := stub.GetChannelID()
+			channelSettings, version, err := settings(stub)
+			if err == nil {
+				mutex.Lock()
+				current, ok := applied[channelID]
+				if !ok || current != version {
+					err = logging.Configure(channelID, channelSettings)
+					if err == nil {
+						applied[channelID] = version
+					}
+				}
+				mutex.Unlock()
+			}
+			if err != nil {
+				logger.Warning(stub, "Failed to configure logging", logging.Fields{"error": err})
+			}
+			return next(stub, args)
+		}
+	}
+}
+
+// Authorize checks the permissions of a route
+func Authorize(route Route, next Handler) Handler {
+	if len(route.Permissions) == 0 {
+		return next
+	}
+	return func(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+		for _, permission := range route.Permissions {
+			result := permission(stub)
+			if result.Status != shim.OK {
+				return result
+			}
+		}
+		return next(stub, args)
+	}
+}
+
+// Attribute only lets clients through whose certificate has an attribute
+// with the value
+func Attribute(name string, value string) Permission {
+	return func(stub shim.ChaincodeStubInterface) pb.Response {
+		err := cid.AssertAttributeValue(stub, name, value)
+		if err != nil {
+			return ccerror.Forbidden("Access denied: attribute " + name + "=" + value + " is required")
+		}
+		return shim.Success(nil)
+	}
+}
+
+// Validate checks the arguments against the schema of a route
+func Validate(route Route, next Handler) Handler {
+	return func(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+		result := CheckArgs(route, args)
+		if result.Status != shim.OK {
+			return result
+		}
+		return next(stub, args)
+	}
+}
+
+// GuardReadOnly makes the writes of ReadOnly routes fail
+func GuardReadOnly(route Route, next Handler) Handler {
+	if !route.ReadOnly {
+		return next
+	}
+	return func(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+		return next(readOnlyStub{ChaincodeStubInterface: stub, function: route.Name}, args)
+	}
+}
+
+// CheckArgs checks the number and the kinds of arguments of a route
+func CheckArgs(route Route, args []string) pb.Response {
+	counts := route.Counts
+	if len(counts) == 0 {
+		counts = []int{len(route.Args)}
+	}
+	if !acceptsCount(route, counts, len(args)) {
+		return ccerror.WithDetails(ccerror.VALIDATION_FAILED, "Incorrect number of arguments. Expecting "+expecting(route, counts),
+			map[string]interface{}{"args": argNames(route)})
+	}
+
+	for i, arg := range route.Args {
+		if i >= len(args) {
+			break
+		}
+		err := arg.Kind.check(args[i])
+		if err != nil {
+			return ccerror.WithDetails(ccerror.VALIDATION_FAILED, "Argument "+arg.Name+" "+err.Error(),
+				map[string]interface{}{"arg": arg.Name, "index": i})
+		}
+	}
+	return shim.Success(nil)
+}
+
+func acceptsCount(route Route, counts []int, count int) bool {
+	for _, accepted := range counts {
+		if count == accepted || route.Variadic && count > accepted {
+			return true
+		}
+	}
+	return false
+}
+
+func expecting(route Route, counts []int) string {
+	sorted := append([]int{}, counts...)
+	sort.Ints(sorted)
+	if route.Variadic {
+		return "at least " + strconv.Itoa(sorted[0])
+	}
+	text := strconv.Itoa(sorted[0])
+	for i := 1; i < len(sorted); i++ {
+		if i == len(sorted)-1 {
+			text += " or "
+		} else {
+			text += ", "
+		}
+		text += strconv.Itoa(sorted[i])
+	}
+	return text
+}
+
+func argNames(route Route) []string {
+	names := []string{}
+	for _, arg := range route.Args {
+		names = append(names, arg.Name)
+	}
+	return names
+}
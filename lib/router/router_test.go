@@ -0,0 +1,195 @@
+package router
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/deevotech/sc-chaincode.deevo.io/lib/ccerror"
+	"github.com/deevotech/sc-chaincode.deevo.io/lib/logging"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+type testChaincode struct {
+	router *Router
+}
+
+func (c *testChaincode) Init(stub shim.ChaincodeStubInterface) pb.Response {
+	return shim.Success(nil)
+}
+
+func (c *testChaincode) Invoke(stub shim.ChaincodeStubInterface) pb.Response {
+	return c.router.Invoke(stub)
+}
+
+func put(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	err := stub.PutState(args[0], []byte(args[1]))
+	if err != nil {
+		return ccerror.Internal(err.Error())
+	}
+	return shim.Success(nil)
+}
+
+func newTestStub(routes ...Route) *shim.MockStub {
+	r := New(Recover, Log, Authorize, Validate, GuardReadOnly)
+	r.Register(routes...)
+	return shim.NewMockStub("router", &testChaincode{router: r})
+}
+
+func invoke(stub *shim.MockStub, args ...string) pb.Response {
+	bytes := [][]byte{}
+	for _, arg := range args {
+		bytes = append(bytes, []byte(arg))
+	}
+	return stub.MockInvoke("1", bytes)
+}
+
+func checkCode(t *testing.T, res pb.Response, code ccerror.Code) ccerror.Body {
+	body := ccerror.Parse(res)
+	if res.Status == shim.OK || body.Code != code {
+		t.Fatalf("Expected %s, got status %d: %s", code, res.Status, res.Message)
+	}
+	return body
+}
+
+func TestCheckArgs_Counts(t *testing.T) {
+	route := Route{Name: "get", Args: []Arg{NonEmpty("ID"), Int("depth")}, Counts: []int{1, 2}}
+	if res := CheckArgs(route, []string{"Log_1"}); res.Status != shim.OK {
+		t.Fatalf("Expected 1 argument to pass: %s", res.Message)
+	}
+	body := checkCode(t, CheckArgs(route, []string{}), ccerror.VALIDATION_FAILED)
+	if body.Message != "Incorrect number of arguments. Expecting 1 or 2" {
+		t.Fatalf("Unexpected message %s", body.Message)
+	}
+
+	route.Variadic = true
+	if res := CheckArgs(route, []string{"Log_1", "2", "extra"}); res.Status != shim.OK {
+		t.Fatalf("Expected a variadic route to accept more arguments: %s", res.Message)
+	}
+	body = checkCode(t, CheckArgs(route, []string{}), ccerror.VALIDATION_FAILED)
+	if body.Message != "Incorrect number of arguments. Expecting at least 1" {
+		t.Fatalf("Unexpected message %s", body.Message)
+	}
+}
+
+func TestCheckArgs_Kinds(t *testing.T) {
+	route := Route{Name: "create", Args: []Arg{NonEmpty("ID"), Int("qty"), JSONArg("data"), String("note")}}
+	if res := CheckArgs(route, []string{"Log_1", "-2", `{"a":1}`, ""}); res.Status != shim.OK {
+		t.Fatalf("Expected the arguments to pass: %s", res.Message)
+	}
+
+	tests := []struct {
+		args  []string
+		arg   string
+		index float64
+	}{
+		{[]string{"", "1", "{}", ""}, "ID", 0},
+		{[]string{"Log_1", "one", "{}", ""}, "qty", 1},
+		{[]string{"Log_1", "1", "{", ""}, "data", 2},
+	}
+	for _, test := range tests {
+		body := checkCode(t, CheckArgs(route, test.args), ccerror.VALIDATION_FAILED)
+		if body.Details["arg"] != test.arg || body.Details["index"] != test.index {
+			t.Fatalf("Expected argument %s at %v, got %+v", test.arg, test.index, body.Details)
+		}
+	}
+}
+
+func TestRegister_Twice(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("Expected registering a function twice to panic")
+		}
+	}()
+	r := New()
+	r.Register(Route{Name: "put", Handler: put}, Route{Name: "put", Handler: put})
+}
+
+func TestInvoke(t *testing.T) {
+	stub := newTestStub(
+		Route{Name: "put", Handler: put, Args: []Arg{NonEmpty("key"), String("value")}},
+		Route{Name: "fail", Handler: func(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+			panic("broken")
+		}},
+		Route{Name: "denied", Handler: put, Permissions: []Permission{
+			func(stub shim.ChaincodeStubInterface) pb.Response { return ccerror.Forbidden("Access denied") },
+		}},
+	)
+
+	if res := invoke(stub, "put", "a", "1"); res.Status != shim.OK {
+		t.Fatalf("put failed: %s", res.Message)
+	}
+	if string(stub.State["a"]) != "1" {
+		t.Fatalf("Expected put to write the state")
+	}
+	body := checkCode(t, invoke(stub, "unknown"), ccerror.VALIDATION_FAILED)
+	if body.Details["function"] != "unknown" {
+		t.Fatalf("Unexpected details %+v", body.Details)
+	}
+	checkCode(t, invoke(stub, "put", "a"), ccerror.VALIDATION_FAILED)
+	checkCode(t, invoke(stub, "fail"), ccerror.INTERNAL)
+	checkCode(t, invoke(stub, "denied"), ccerror.FORBIDDEN)
+}
+
+func TestGuardReadOnly(t *testing.T) {
+	stub := newTestStub(Route{Name: "put", Handler: put, Args: []Arg{NonEmpty("key"), String("value")}, ReadOnly: true})
+
+	res := invoke(stub, "put", "a", "1")
+	if res.Status == shim.OK {
+		t.Fatalf("Expected a read-only function to fail writing")
+	}
+	if _, ok := stub.State["a"]; ok {
+		t.Fatalf("Expected the state not to be written")
+	}
+}
+
+func TestConfigureLogging(t *testing.T) {
+	var out bytes.Buffer
+	defer logging.SetOutput(logging.SetOutput(&out))
+
+	stored := logging.Settings{Level: "ERROR"}
+	version := int64(1)
+	var err error
+	settings := func(stub shim.ChaincodeStubInterface) (logging.Settings, int64, error) {
+		return stored, version, err
+	}
+	r := New(ConfigureLogging(settings), Log)
+	r.Register(Route{Name: "fail", Handler: func(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+		return ccerror.ValidationFailed("failed")
+	}})
+	stub := shim.NewMockStub("router", &testChaincode{router: r})
+	stub.ChannelID = "channel1"
+	defer logging.Configure(stub.ChannelID, logging.Settings{})
+
+	// failed invocations are logged at WARNING
+	logged := func() bool {
+		out.Reset()
+		invoke(stub, "fail")
+		return out.Len() > 0
+	}
+	if logged() {
+		t.Fatalf("Expected nothing to be logged below ERROR: %s", out.String())
+	}
+
+	// settings of the same version are not applied again
+	stored = logging.Settings{Level: "DEBUG"}
+	if logged() {
+		t.Fatalf("Expected the settings of version 1 to stay: %s", out.String())
+	}
+
+	version = 2
+	if !logged() {
+		t.Fatalf("Expected the settings of version 2 to apply")
+	}
+
+	// unreadable settings keep the previous ones
+	stored, version, err = logging.Settings{Level: "ERROR"}, 3, errors.New("config not readable")
+	out.Reset()
+	invoke(stub, "fail")
+	if !strings.Contains(out.String(), "Failed to configure logging") || !strings.Contains(out.String(), `"msg":"invoke failed"`) {
+		t.Fatalf("Expected the previous settings to stay: %s", out.String())
+	}
+}
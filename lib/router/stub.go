@@ -0,0 +1,41 @@
+package router
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+// readOnlyStub fails the writes of a ReadOnly route
+type readOnlyStub struct {
+	shim.ChaincodeStubInterface
+	function string
+}
+
+func (s readOnlyStub) denied(key string) error {
+	return fmt.Errorf("function %s is read-only and can not write %s", s.function, key)
+}
+
+func (s readOnlyStub) PutState(key string, value []byte) error {
+	return s.denied(key)
+}
+
+func (s readOnlyStub) DelState(key string) error {
+	return s.denied(key)
+}
+
+func (s readOnlyStub) SetStateValidationParameter(key string, ep []byte) error {
+	return s.denied(key)
+}
+
+func (s readOnlyStub) PutPrivateData(collection string, key string, value []byte) error {
+	return s.denied(key)
+}
+
+func (s readOnlyStub) DelPrivateData(collection, key string) error {
+	return s.denied(key)
+}
+
+func (s readOnlyStub) SetPrivateDataValidationParameter(collection, key string, ep []byte) error {
+	return s.denied(key)
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"github.com/deevotech/sc-chaincode.deevo.io/lib/router"
+)
+
+func (t *SimpleChaincode) newRouter() *router.Router {
+	r := router.New(router.Recover, router.ConfigureLogging(loggingSettings), router.Log, router.Authorize, router.Validate, router.GuardReadOnly)
+	r.Register(t.routes()...)
+	return r
+}
+
+// routes of the chaincode. Every function requires the attribute
+// supplychain_account.<function>. Functions which took more arguments than
+// they use are Variadic, so existing clients keep working.
+func (t *SimpleChaincode) routes() []router.Route {
+	routes := []router.Route{
+		{Name: "initAcc", Handler: t.initAcc, Args: []router.Arg{router.NonEmpty("publickey"), router.NonEmpty("certificate"), router.NonEmpty("orgType"), router.Int("role")}},
+		{Name: "changeRole", Handler: t.changeRole, Args: []router.Arg{router.NonEmpty("publickey"), router.Int("role")}, Variadic: true},
+		{Name: "delete", Handler: t.delete, Args: []router.Arg{router.NonEmpty("publickey")}},
+		{Name: "readAcc", Handler: t.readAcc, Args: []router.Arg{router.NonEmpty("publickey")}, ReadOnly: true},
+		{Name: "queryAccsByRole", Handler: t.queryAccsByRole, Args: []router.Arg{router.Int("role")}, Variadic: true, ReadOnly: true},
+		{Name: "queryAccs", Handler: t.queryAccs, Args: []router.Arg{router.JSONArg("query")}, Variadic: true, ReadOnly: true},
+		{Name: "getHistoryForAccs", Handler: t.getHistoryForAccs, Args: []router.Arg{router.NonEmpty("publickey")}, Variadic: true, ReadOnly: true},
+		{Name: "getConfig", Handler: t.getConfig, ReadOnly: true},
+		{Name: "updateConfig", Handler: t.updateConfig, Args: []router.Arg{router.JSONArg("config")}},
+		{Name: "getConfigHistory", Handler: t.getConfigHistory, ReadOnly: true},
+	}
+	for i := range routes {
+		permission := router.Attribute("supplychain_account."+routes[i].Name, "true")
+		routes[i].Permissions = append(routes[i].Permissions, permission)
+	}
+	return routes
+}
@@ -20,15 +20,24 @@ import (
     "encoding/json"
     "fmt"
     "strconv"
+    "sync"
     "time"
 
+	"github.com/deevotech/sc-chaincode.deevo.io/lib/ccerror"
+	"github.com/deevotech/sc-chaincode.deevo.io/lib/logging"
+	"github.com/deevotech/sc-chaincode.deevo.io/lib/router"
+
 	"github.com/hyperledger/fabric/core/chaincode/lib/cid"
     "github.com/hyperledger/fabric/core/chaincode/shim"
     pb "github.com/hyperledger/fabric/protos/peer"
 )
 
+var logger = logging.New("supplychain-account")
+
 // SimpleChaincode example simple Chaincode implementation
 type SimpleChaincode struct {
+    routerOnce sync.Once
+    router *router.Router
 }
 type account struct {
     ObjectType string `json:"docType"`
@@ -43,93 +52,63 @@ type account struct {
 func main() {
     err := shim.Start(new(SimpleChaincode))
     if err != nil {
-        fmt.Printf("Error starting Simple chaincode: %s", err)
+        logger.Error(nil, "Error starting Simple chaincode", logging.Fields{"error": err})
     }
 }
 
-// Init initializes chaincode
+// Init initializes chaincode, with an optional json config
 // ===========================
 func (t *SimpleChaincode) Init(stub shim.ChaincodeStubInterface) pb.Response {
 	err := cid.AssertAttributeValue(stub, "supplychain_account.init", "true")
 	if err != nil {
-		return shim.Error(err.Error())
+		return ccerror.Forbidden("Access denied: " + err.Error())
 	}
-    return shim.Success(nil)
+    return t.initConfig(stub)
 }
 
-// Invoke - Our entry point for Invocations
+// Invoke - Our entry point for Invocations, the functions are in routes.go
 // ========================================
 func (t *SimpleChaincode) Invoke(stub shim.ChaincodeStubInterface) pb.Response {
-    function, args := stub.GetFunctionAndParameters()
-    fmt.Println("invoke is running " + function)
-
-    // Handle different functions
-    if function == "initAcc" { //create a new account
-        return t.initAcc(stub, args)
-    } else if function == "changeRole" { //change name of a specific account
-        return t.changeRole(stub, args)
-    } else if function == "delete" { //delete a account
-        return t.delete(stub, args)
-    } else if function == "readAcc" { //read a account
-        return t.readAcc(stub, args)
-    } else if function == "queryAccsByRole" {
-		return t.queryAccsByRole(stub, args)
-	} else if function == "queryAccsByRole" {
-		return t.queryAccsByRole(stub, args)
-	} else if function == "queryAccs" {
-		return t.queryAccs(stub, args)
-	} else if function == "getHistoryForAccs" {
-		return t.getHistoryForAccs(stub, args)
-	}
-    // getHistory AgriProduct, get HistoryProduct
-    fmt.Println("invoke did not find func: " + function) //error
-    return shim.Error("Received unknown function invocation")
+    t.routerOnce.Do(func() {
+        t.router = t.newRouter()
+    })
+    return t.router.Invoke(stub)
 }
 
 // ============================================================
 // initorg - create a new org, store into chaincode state
 // ============================================================
 func (t *SimpleChaincode) initAcc(stub shim.ChaincodeStubInterface, args []string) pb.Response {
-	var err error
-	err = cid.AssertAttributeValue(stub, "supplychain_account.initAcc", "true")
-	if err != nil {
-		return shim.Error(err.Error())
-	}
     //   0       1       2     3
     // "1", "adf", "1", "67.0006, -70.5476"
-    if len(args) != 4 {
-        return shim.Error("Incorrect number of arguments. Expecting 5")
-    }
 
     // ==== Input sanitation ====
-    fmt.Println("- start init account")
-    if len(args[0]) <= 0 {
-        return shim.Error("1st argument must be a non-empty string")
-    }
-    if len(args[1]) <= 0 {
-        return shim.Error("2nd argument must be a non-empty string")
-    }
-    if len(args[2]) <= 0 {
-        return shim.Error("3rd argument must be a non-empty string")
-    }
-    if len(args[3]) <= 0 {
-        return shim.Error("4th argument must be a non-empty string")
-	}
+    logger.Debug(stub, "start init account")
     role, err := strconv.Atoi(args[3])
     if err != nil {
-        return shim.Error("5rd argument must be a numeric string")
+        return ccerror.ValidationFailed("5rd argument must be a numeric string")
     }
     publickey := args[0]
     certificate := args[1]
 	orgType := args[2]
 
+    // ==== Check if the config allows the org type and role ====
+    config, err := readConfig(stub)
+    if err != nil {
+        return ccerror.Internal("Failed to get config: " + err.Error())
+    } else if !containsString(config.OrgTypes, orgType) {
+        return ccerror.ValidationFailed("Org type " + orgType + " is not allowed by the config")
+    } else if !config.allowsRole(role) {
+        return ccerror.ValidationFailed("Role " + args[3] + " is not allowed by the config")
+    }
+
     // ==== Check if org already exists ====
     accAsBytes, err := stub.GetState(publickey)
     if err != nil {
-        return shim.Error("Failed to get org: " + err.Error())
+        return ccerror.Internal("Failed to get org: " + err.Error())
     } else if accAsBytes != nil {
-        fmt.Println("This org already exists: " + publickey)
-        return shim.Error("This org already exists: " + publickey)
+        logger.Debug(stub, "This org already exists", logging.Fields{"publickey": publickey})
+        return ccerror.AlreadyExists("This org already exists: " + publickey)
     }
 
     // ==== Create org object and marshal to JSON ====
@@ -137,13 +116,13 @@ func (t *SimpleChaincode) initAcc(stub shim.ChaincodeStubInterface, args []strin
     account := &account{objectType, publickey, certificate, orgType, role}
     accountJSONasBytes, err := json.Marshal(account)
     if err != nil {
-        return shim.Error(err.Error())
+        return ccerror.Internal(err.Error())
     }
 
     // === Save org to state ===
     err = stub.PutState(publickey, accountJSONasBytes)
     if err != nil {
-        return shim.Error(err.Error())
+        return ccerror.Internal(err.Error())
     }
 
     //  ==== Index the org to enable color-based range queries, e.g. return all blue orgs ====
@@ -154,7 +133,7 @@ func (t *SimpleChaincode) initAcc(stub shim.ChaincodeStubInterface, args []strin
     indexName := "role~publickey"
     rolePublickeyIndexKey, err := stub.CreateCompositeKey(indexName, []string{strconv.Itoa(account.Role), account.Publickey})
     if err != nil {
-        return shim.Error(err.Error())
+        return ccerror.Internal(err.Error())
     }
     //  Save index entry to state. Only the key name is needed, no need to store a duplicate copy of the org.
     //  Note - passing a 'nil' value will effectively delete the key from state, therefore we pass null character as value
@@ -162,7 +141,7 @@ func (t *SimpleChaincode) initAcc(stub shim.ChaincodeStubInterface, args []strin
     stub.PutState(rolePublickeyIndexKey, value)
 
     // ==== org saved and indexed. Return success ====
-    fmt.Println("- end init account")
+    logger.Debug(stub, "end init account")
     return shim.Success(nil)
 }
 
@@ -170,25 +149,14 @@ func (t *SimpleChaincode) initAcc(stub shim.ChaincodeStubInterface, args []strin
 // readorg - read a org from chaincode state
 // ===============================================
 func (t *SimpleChaincode) readAcc(stub shim.ChaincodeStubInterface, args []string) pb.Response {
-    var publickey, jsonResp string
-	var err error
-	err = cid.AssertAttributeValue(stub, "supplychain_account.readAcc", "true")
-	if err != nil {
-		return shim.Error(err.Error())
-	}
-
-    if len(args) != 1 {
-        return shim.Error("Incorrect number of arguments. Expecting name of the org to query")
-    }
+    var publickey string
 
     publickey = args[0]
     valAsbytes, err := stub.GetState(publickey) //get the org from chaincode state
     if err != nil {
-        jsonResp = "{\"Error\":\"Failed to get state for " + publickey + "\"}"
-        return shim.Error(jsonResp)
+        return ccerror.Internal("Failed to get state for " + publickey)
     } else if valAsbytes == nil {
-        jsonResp = "{\"Error\":\"org does not exist: " + publickey + "\"}"
-        return shim.Error(jsonResp)
+        return ccerror.NotFound("org does not exist: " + publickey)
     }
 
     return shim.Success(valAsbytes)
@@ -198,49 +166,38 @@ func (t *SimpleChaincode) readAcc(stub shim.ChaincodeStubInterface, args []strin
 // delete - remove a org key/value pair from state
 // ==================================================
 func (t *SimpleChaincode) delete(stub shim.ChaincodeStubInterface, args []string) pb.Response {
-	err := cid.AssertAttributeValue(stub, "supplychain_account.delete", "true")
-	if err != nil {
-		return shim.Error(err.Error())
-	}
-    var jsonResp string
     var accJSON account
-    if len(args) != 1 {
-        return shim.Error("Incorrect number of arguments. Expecting 1")
-    }
     publickey := args[0]
 
     // to maintain the color~name index, we need to read the org first and get its color
     valAsbytes, err := stub.GetState(publickey) //get the org from chaincode state
     if err != nil {
-        jsonResp = "{\"Error\":\"Failed to get state for " +publickey + "\"}"
-        return shim.Error(jsonResp)
+        return ccerror.Internal("Failed to get state for " +publickey)
     } else if valAsbytes == nil {
-        jsonResp = "{\"Error\":\"org does not exist: " + publickey + "\"}"
-        return shim.Error(jsonResp)
+        return ccerror.NotFound("org does not exist: " + publickey)
     }
 
     err = json.Unmarshal([]byte(valAsbytes), &accJSON)
     if err != nil {
-        jsonResp = "{\"Error\":\"Failed to decode JSON of: " + publickey + "\"}"
-        return shim.Error(jsonResp)
+        return ccerror.Internal("Failed to decode JSON of: " + publickey)
     }
 
     err = stub.DelState(publickey) //remove the org from chaincode state
     if err != nil {
-        return shim.Error("Failed to delete state:" + err.Error())
+        return ccerror.Internal("Failed to delete state:" + err.Error())
     }
 
     // maintain the index
     indexName := "role~publickey"
     rolePublickeyIndexKey, err := stub.CreateCompositeKey(indexName, []string{strconv.Itoa(accJSON.Role), accJSON.Publickey})
     if err != nil {
-        return shim.Error(err.Error())
+        return ccerror.Internal(err.Error())
     }
 
     //  Delete index entry to state.
     err = stub.DelState(rolePublickeyIndexKey)
     if err != nil {
-        return shim.Error("Failed to delete state:" + err.Error())
+        return ccerror.Internal("Failed to delete state:" + err.Error())
     }
     return shim.Success(nil)
 }
@@ -250,70 +207,63 @@ func (t *SimpleChaincode) delete(stub shim.ChaincodeStubInterface, args []string
 // ===========================================================
 func (t *SimpleChaincode) changeRole(stub shim.ChaincodeStubInterface, args []string) pb.Response {
 
-	err := cid.AssertAttributeValue(stub, "supplychain_account.changeRole", "true")
-	if err != nil {
-		return shim.Error(err.Error())
-	}
     //   0       1
     // "1", "org1"
-    if len(args) < 2 {
-        return shim.Error("Incorrect number of arguments. Expecting 2")
-    }
 
     publickey:= args[0]
    
 	role, err := strconv.Atoi(args[1])
 	if err != nil {
-		return shim.Error("5rd argument must be a numeric string")
+		return ccerror.ValidationFailed("5rd argument must be a numeric string")
 	}
-    fmt.Println("- start transferorg ", publickey, role)
+    logger.Debug(stub, "start transferorg", logging.Fields{"publickey": publickey, "role": role})
+
+    config, err := readConfig(stub)
+    if err != nil {
+        return ccerror.Internal("Failed to get config: " + err.Error())
+    } else if !config.allowsRole(role) {
+        return ccerror.ValidationFailed("Role " + args[1] + " is not allowed by the config")
+    }
 
     accAsBytes, err := stub.GetState(publickey)
     if err != nil {
-        return shim.Error("Failed to get account:" + err.Error())
+        return ccerror.Internal("Failed to get account:" + err.Error())
     } else if accAsBytes == nil {
-        return shim.Error("account does not exist")
+        return ccerror.NotFound("account does not exist")
     }
 
     accountToTransfer := account{}
     err = json.Unmarshal(accAsBytes, &accountToTransfer) //unmarshal it aka JSON.parse()
     if err != nil {
-        return shim.Error(err.Error())
+        return ccerror.Internal(err.Error())
     }
     accountToTransfer.Role = role //change the role
 
     accJSONasBytes, _ := json.Marshal(accountToTransfer)
     err = stub.PutState(publickey, accJSONasBytes) //rewrite the org
     if err != nil {
-        return shim.Error(err.Error())
+        return ccerror.Internal(err.Error())
     }
 
-    fmt.Println("- end transferorg (success)")
+    logger.Debug(stub, "end transferorg (success)")
     return shim.Success(nil)
 }
 
 func (t *SimpleChaincode) queryAccsByRole(stub shim.ChaincodeStubInterface, args []string) pb.Response {
 
-	err := cid.AssertAttributeValue(stub, "supplychain_account.queryAccsByRole", "true")
-	if err != nil {
-		return shim.Error(err.Error())
-	}
     //   0
     // "bob"
-    if len(args) < 1 {
-        return shim.Error("Incorrect number of arguments. Expecting 1")
-    }
 
     role, err := strconv.Atoi(args[0])
 	if err != nil {
-		return shim.Error("5rd argument must be a numeric string")
+		return ccerror.ValidationFailed("5rd argument must be a numeric string")
 	}
 
     queryString := fmt.Sprintf("{\"selector\":{\"docType\":\"account\",\"Role\":\"%d\"}}", role)
 
     queryResults, err := getQueryResultForQueryString(stub, queryString)
     if err != nil {
-        return shim.Error(err.Error())
+        return ccerror.Internal(err.Error())
     }
     return shim.Success(queryResults)
 }
@@ -327,21 +277,14 @@ func (t *SimpleChaincode) queryAccsByRole(stub shim.ChaincodeStubInterface, args
 // =========================================================================================
 func (t *SimpleChaincode) queryAccs(stub shim.ChaincodeStubInterface, args []string) pb.Response {
 
-	err := cid.AssertAttributeValue(stub, "supplychain_account.queryAccs", "true")
-	if err != nil {
-		return shim.Error(err.Error())
-	}
     //   0
     // "queryString"
-    if len(args) < 1 {
-        return shim.Error("Incorrect number of arguments. Expecting 1")
-    }
 
     queryString := args[0]
 
     queryResults, err := getQueryResultForQueryString(stub, queryString)
     if err != nil {
-        return shim.Error(err.Error())
+        return ccerror.Internal(err.Error())
     }
     return shim.Success(queryResults)
 }
@@ -352,7 +295,7 @@ func (t *SimpleChaincode) queryAccs(stub shim.ChaincodeStubInterface, args []str
 // =========================================================================================
 func getQueryResultForQueryString(stub shim.ChaincodeStubInterface, queryString string) ([]byte, error) {
 
-    fmt.Printf("- getQueryResultForQueryString queryString:\n%s\n", queryString)
+    logger.Debug(stub, "start getQueryResultForQueryString")
 
     resultsIterator, err := stub.GetQueryResult(queryString)
     if err != nil {
@@ -365,6 +308,7 @@ func getQueryResultForQueryString(stub shim.ChaincodeStubInterface, queryString
     buffer.WriteString("[")
 
     bArrayMemberAlreadyWritten := false
+    count := 0
     for resultsIterator.HasNext() {
         queryResponse, err := resultsIterator.Next()
         if err != nil {
@@ -384,31 +328,24 @@ func getQueryResultForQueryString(stub shim.ChaincodeStubInterface, queryString
         buffer.WriteString(string(queryResponse.Value))
         buffer.WriteString("}")
         bArrayMemberAlreadyWritten = true
+        count++
     }
     buffer.WriteString("]")
 
-    fmt.Printf("- getQueryResultForQueryString queryResult:\n%s\n", buffer.String())
+    logger.Debug(stub, "end getQueryResultForQueryString (success)", logging.Fields{"results": count})
 
     return buffer.Bytes(), nil
 }
 
 func (t *SimpleChaincode) getHistoryForAccs(stub shim.ChaincodeStubInterface, args []string) pb.Response {
 
-	err := cid.AssertAttributeValue(stub, "supplychain_account.getHistoryForAccs", "true")
-	if err != nil {
-		return shim.Error(err.Error())
-	}
-    if len(args) < 1 {
-        return shim.Error("Incorrect number of arguments. Expecting 1")
-    }
-
     publickey := args[0]
 
-    fmt.Printf("- start getHistoryForOrg: %s\n", publickey)
+    logger.Debug(stub, "start getHistoryForOrg", logging.Fields{"publickey": publickey})
 
     resultsIterator, err := stub.GetHistoryForKey(publickey)
     if err != nil {
-        return shim.Error(err.Error())
+        return ccerror.Internal(err.Error())
     }
     defer resultsIterator.Close()
 
@@ -417,10 +354,11 @@ func (t *SimpleChaincode) getHistoryForAccs(stub shim.ChaincodeStubInterface, ar
     buffer.WriteString("[")
 
     bArrayMemberAlreadyWritten := false
+    count := 0
     for resultsIterator.HasNext() {
         response, err := resultsIterator.Next()
         if err != nil {
-            return shim.Error(err.Error())
+            return ccerror.Internal(err.Error())
         }
         // Add a comma before array members, suppress it for the first array member
         if bArrayMemberAlreadyWritten == true {
@@ -453,10 +391,11 @@ func (t *SimpleChaincode) getHistoryForAccs(stub shim.ChaincodeStubInterface, ar
 
         buffer.WriteString("}")
         bArrayMemberAlreadyWritten = true
+        count++
     }
     buffer.WriteString("]")
 
-    fmt.Printf("- getHistoryForAccount returning:\n%s\n", buffer.String())
+    logger.Debug(stub, "end getHistoryForAccount (success)", logging.Fields{"results": count})
 
     return shim.Success(buffer.Bytes())
 }
\ No newline at end of file
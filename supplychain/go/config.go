@@ -0,0 +1,217 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/deevotech/sc-chaincode.deevo.io/lib/ccconfig"
+	"github.com/deevotech/sc-chaincode.deevo.io/lib/ccerror"
+	"github.com/deevotech/sc-chaincode.deevo.io/lib/logging"
+
+	"github.com/hyperledger/fabric/core/chaincode/lib/cid"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+const (
+	adminAttribute = "supplychain.admin"
+)
+
+// chaincodeConfig is the configuration of the chaincode on a channel. It is
+// passed to Init and changed with updateConfig, every version is kept in its
+// history. Empty OrgTypes allow every org type, empty AdminMSPs let admins of
+// every MSP through.
+type chaincodeConfig struct {
+	ObjectType string           `json:"objectType"`
+	OrgTypes   []string         `json:"orgTypes"`
+	AdminMSPs  []string         `json:"adminMSPs"`
+	Updater    ccconfig.Updater `json:"updater"`
+	Version    int64            `json:"version"`
+	// Logging settings of the channel, see lib/logging
+	Logging logging.Settings `json:"logging"`
+}
+
+// readConfig returns the stored config, one without restrictions before
+// Init stored one
+func readConfig(stub shim.ChaincodeStubInterface) (chaincodeConfig, error) {
+	config := chaincodeConfig{ObjectType: ccconfig.OBJECT_TYPE}
+	configAsBytes, err := ccconfig.Read(stub)
+	if err != nil || configAsBytes == nil {
+		return config, err
+	}
+	err = json.Unmarshal(configAsBytes, &config)
+	return config, err
+}
+
+// loggingSettings returns the logging settings of the stored config and
+// the version of the config
+func loggingSettings(stub shim.ChaincodeStubInterface) (logging.Settings, int64, error) {
+	config, err := readConfig(stub)
+	return config.Logging, config.Version, err
+}
+
+func checkConfig(config *chaincodeConfig) error {
+	if config.ObjectType != ccconfig.OBJECT_TYPE {
+		return fmt.Errorf("Expected objectType %s for the config", ccconfig.OBJECT_TYPE)
+	}
+	for i, orgType := range config.OrgTypes {
+		if len(orgType) <= 0 {
+			return fmt.Errorf("Entries of orgTypes must be non-empty strings")
+		}
+		// initOrg stores org types in lower case
+		config.OrgTypes[i] = strings.ToLower(orgType)
+	}
+	for _, mspID := range config.AdminMSPs {
+		if len(mspID) <= 0 {
+			return fmt.Errorf("Entries of adminMSPs must be non-empty strings")
+		}
+	}
+	err := config.Logging.Check()
+	if err != nil {
+		return fmt.Errorf("Invalid logging settings: %s", err)
+	}
+	return nil
+}
+
+// putConfig stores a config as the given version and adds it to the history
+func putConfig(stub shim.ChaincodeStubInterface, config chaincodeConfig, version int64) error {
+	identity, err := cid.New(stub)
+	if err != nil {
+		return err
+	}
+	config.Updater, err = ccconfig.NewUpdater(stub, identity)
+	if err != nil {
+		return err
+	}
+	config.Version = version
+
+	configAsBytes, err := json.Marshal(config)
+	if err != nil {
+		return err
+	}
+	return ccconfig.Put(stub, configAsBytes, version)
+}
+
+// initConfig stores the config passed to Init. Without one, an upgrade keeps
+// the current config.
+func (t *SimpleChaincode) initConfig(stub shim.ChaincodeStubInterface) pb.Response {
+	_, args := stub.GetFunctionAndParameters()
+	if len(args) == 0 {
+		return shim.Success(nil)
+	} else if len(args) != 1 {
+		return ccerror.ValidationFailed("Incorrect number of arguments. Expecting 0 or 1")
+	}
+
+	newConfig := chaincodeConfig{}
+	err := json.Unmarshal([]byte(args[0]), &newConfig)
+	if err != nil {
+		return ccerror.ValidationFailed("Failed to decode config: " + err.Error())
+	}
+	err = checkConfig(&newConfig)
+	if err != nil {
+		return ccerror.ValidationFailed(err.Error())
+	}
+	current, err := readConfig(stub)
+	if err != nil {
+		return ccerror.Internal("Failed to get config: " + err.Error())
+	}
+	err = putConfig(stub, newConfig, current.Version+1)
+	if err != nil {
+		return ccerror.Internal("Failed to save config: " + err.Error())
+	}
+	return shim.Success(nil)
+}
+
+// ===============================================
+// getConfig - read the config of the chaincode
+// ===============================================
+func (t *SimpleChaincode) getConfig(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	config, err := readConfig(stub)
+	if err != nil {
+		return ccerror.Internal("Failed to get config: " + err.Error())
+	}
+	configAsBytes, err := json.Marshal(config)
+	if err != nil {
+		return ccerror.Internal(err.Error())
+	}
+	return shim.Success(configAsBytes)
+}
+
+// ===============================================
+// updateConfig - replace the config, admins only, see routes.go
+// ===============================================
+func (t *SimpleChaincode) updateConfig(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	//   0
+	// "{\"objectType\":\"config\",\"orgTypes\":[\"farmer\"],\"version\":1}"
+	logger.Debug(stub, "start updateConfig")
+
+	mspID, err := cid.GetMSPID(stub)
+	if err != nil {
+		return ccerror.Internal("Failed to get MSP ID: " + err.Error())
+	}
+	current, err := readConfig(stub)
+	if err != nil {
+		return ccerror.Internal("Failed to get config: " + err.Error())
+	}
+	if !ccconfig.IsAdmin(current.AdminMSPs, mspID) {
+		return ccerror.Forbidden("Access denied: " + mspID + " is not an admin MSP")
+	}
+
+	newConfig := chaincodeConfig{}
+	err = json.Unmarshal([]byte(args[0]), &newConfig)
+	if err != nil {
+		return ccerror.ValidationFailed("Failed to decode config: " + err.Error())
+	}
+	err = checkConfig(&newConfig)
+	if err != nil {
+		return ccerror.ValidationFailed(err.Error())
+	}
+	// the version of the new config is the one it replaces, so concurrent
+	// changes are not lost
+	if newConfig.Version != current.Version {
+		return ccerror.WithDetails(ccerror.VERSION_CONFLICT, "Config has version "+strconv.FormatInt(current.Version, 10)+", expected "+strconv.FormatInt(newConfig.Version, 10),
+			map[string]interface{}{"expected": newConfig.Version, "current": current.Version})
+	}
+	if !ccconfig.IsAdmin(newConfig.AdminMSPs, mspID) {
+		return ccerror.ValidationFailed("adminMSPs of the config have to include " + mspID)
+	}
+
+	err = putConfig(stub, newConfig, current.Version+1)
+	if err != nil {
+		return ccerror.Internal("Failed to save config: " + err.Error())
+	}
+	logger.Debug(stub, "end updateConfig (success)")
+	return shim.Success(nil)
+}
+
+// ===============================================
+// getConfigHistory - read every version of the config, oldest first
+// ===============================================
+func (t *SimpleChaincode) getConfigHistory(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	configs, err := ccconfig.History(stub)
+	if err != nil {
+		return ccerror.Internal("Failed to get history of config: " + err.Error())
+	}
+
+	configsAsBytes, err := json.Marshal(configs)
+	if err != nil {
+		return ccerror.Internal(err.Error())
+	}
+	return shim.Success(configsAsBytes)
+}
+
+// containsString reports whether values holds value, an empty list holds
+// every value
+func containsString(values []string, value string) bool {
+	if len(values) == 0 {
+		return true
+	}
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"github.com/deevotech/sc-chaincode.deevo.io/lib/router"
+)
+
+func (t *SimpleChaincode) newRouter() *router.Router {
+	r := router.New(router.Recover, router.ConfigureLogging(loggingSettings), router.Log, router.Authorize, router.Validate, router.GuardReadOnly)
+	r.Register(t.routes()...)
+	return r
+}
+
+// routes of the chaincode. Functions which took more arguments than they
+// use are Variadic, so existing clients keep working. harvestAgriProduct
+// required a sixth argument it never read, it still accepts one.
+func (t *SimpleChaincode) routes() []router.Route {
+	ownerArgs := []router.Arg{router.NonEmpty("name"), router.Int("owner")}
+
+	return []router.Route{
+		// orgs
+		{Name: "initOrg", Handler: t.initOrg, Args: []router.Arg{router.Int("id"), router.NonEmpty("name"), router.NonEmpty("orgType"), router.NonEmpty("location")}},
+		{Name: "changeOrg", Handler: t.changeOrg, Args: []router.Arg{router.Int("id"), router.NonEmpty("name")}, Variadic: true},
+		{Name: "delete", Handler: t.delete, Args: []router.Arg{router.Int("id")}},
+		{Name: "readOrg", Handler: t.readOrg, Args: []router.Arg{router.NonEmpty("id")}, ReadOnly: true},
+		{Name: "queryOrgsByType", Handler: t.queryOrgsByType, Args: []router.Arg{router.NonEmpty("orgType")}, Variadic: true, ReadOnly: true},
+		{Name: "queryOrgs", Handler: t.queryOrgs, Args: []router.Arg{router.JSONArg("query")}, Variadic: true, ReadOnly: true},
+		{Name: "getHistoryForOrg", Handler: t.getHistoryForOrg, Args: []router.Arg{router.Int("id")}, Variadic: true, ReadOnly: true},
+
+		// materials
+		{Name: "initSupplierMaterial", Handler: t.initSupplierMaterial, Args: []router.Arg{router.Int("batchCode"), router.NonEmpty("name"), router.Int("qty"), router.Int("owner")}},
+		{Name: "changeOwnerMaterial", Handler: t.changeOwnerMaterial, Args: ownerArgs, Variadic: true},
+		{Name: "getHistoryForMaterial", Handler: t.getHistoryForMaterial, Args: []router.Arg{router.NonEmpty("name")}, Variadic: true, ReadOnly: true},
+		{Name: "queryMaterialsByOwner", Handler: t.queryMaterialsByOwner, Args: []router.Arg{router.NonEmpty("owner")}, Variadic: true, ReadOnly: true},
+
+		// trees and agri products
+		{Name: "initFarmerTree", Handler: t.initFarmerTree, Args: []router.Arg{
+			router.Int("treeId"), router.NonEmpty("name"), router.Int("qty"), router.NonEmpty("startTime"), router.NonEmpty("endTime"),
+			router.NonEmpty("liveTime"), router.NonEmpty("location"), router.Int("owner"), router.Int("rateHarvest"),
+		}},
+		{Name: "harvestAgriProduct", Handler: t.harvestAgriProduct, Args: []router.Arg{router.Int("aProductBatchCode"), router.NonEmpty("name"), router.Int("treeId"), router.Int("qty"), router.Int("owner")}, Counts: []int{5, 6}},
+		{Name: "changeOwnerAgriProduct", Handler: t.changeOwnerAgriProduct, Args: ownerArgs, Variadic: true},
+		{Name: "queryAgriProductByOwner", Handler: t.queryAgriProductByOwner, Args: []router.Arg{router.NonEmpty("owner")}, Variadic: true, ReadOnly: true},
+		{Name: "getHistoryForAgriProduct", Handler: t.getHistoryForAgriProduct, Args: []router.Arg{router.NonEmpty("name")}, Variadic: true, ReadOnly: true},
+
+		// products
+		{Name: "makeProduct", Handler: t.makeProduct, Args: []router.Arg{router.Int("aProductBatchCode"), router.Int("productBatchCode"), router.NonEmpty("name"), router.Int("qty"), router.Int("owner")}},
+		{Name: "changeOwnerProduct", Handler: t.changeOwnerProduct, Args: ownerArgs, Variadic: true},
+		{Name: "queryProductByOwner", Handler: t.queryProductByOwner, Args: []router.Arg{router.NonEmpty("owner")}, Variadic: true, ReadOnly: true},
+		{Name: "getHistoryForProduct", Handler: t.getHistoryForProduct, Args: []router.Arg{router.NonEmpty("name")}, Variadic: true, ReadOnly: true},
+
+		// config
+		{Name: "getConfig", Handler: t.getConfig, ReadOnly: true},
+		{Name: "updateConfig", Handler: t.updateConfig, Args: []router.Arg{router.JSONArg("config")}, Permissions: []router.Permission{router.Attribute(adminAttribute, "true")}},
+		{Name: "getConfigHistory", Handler: t.getConfigHistory, ReadOnly: true},
+	}
+}
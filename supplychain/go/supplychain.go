@@ -21,14 +21,23 @@ import (
     "fmt"
     "strconv"
     "strings"
+    "sync"
     "time"
 
+    "github.com/deevotech/sc-chaincode.deevo.io/lib/ccerror"
+    "github.com/deevotech/sc-chaincode.deevo.io/lib/logging"
+    "github.com/deevotech/sc-chaincode.deevo.io/lib/router"
+
     "github.com/hyperledger/fabric/core/chaincode/shim"
     pb "github.com/hyperledger/fabric/protos/peer"
 )
 
+var logger = logging.New("supplychain")
+
 // SimpleChaincode example simple Chaincode implementation
 type SimpleChaincode struct {
+    routerOnce sync.Once
+    router *router.Router
 }
 type org struct {
     ObjectType string `json:"docType"`
@@ -80,67 +89,23 @@ type product struct {
 func main() {
     err := shim.Start(new(SimpleChaincode))
     if err != nil {
-        fmt.Printf("Error starting Simple chaincode: %s", err)
+        logger.Error(nil, "Error starting Simple chaincode", logging.Fields{"error": err})
     }
 }
 
-// Init initializes chaincode
+// Init initializes chaincode, with an optional json config
 // ===========================
 func (t *SimpleChaincode) Init(stub shim.ChaincodeStubInterface) pb.Response {
-    return shim.Success(nil)
+    return t.initConfig(stub)
 }
 
-// Invoke - Our entry point for Invocations
+// Invoke - Our entry point for Invocations, the functions are in routes.go
 // ========================================
 func (t *SimpleChaincode) Invoke(stub shim.ChaincodeStubInterface) pb.Response {
-    function, args := stub.GetFunctionAndParameters()
-    fmt.Println("invoke is running " + function)
-
-    // Handle different functions
-    if function == "initOrg" { //create a new org
-        return t.initOrg(stub, args)
-    } else if function == "changeOrg" { //change name of a specific org
-        return t.changeOrg(stub, args)
-    } else if function == "delete" { //delete a org
-        return t.delete(stub, args)
-    } else if function == "readOrg" { //read a org
-        return t.readOrg(stub, args)
-    } else if function == "queryOrgsByType" { //find orgs for type X using rich query
-        return t.queryOrgsByType(stub, args)
-    } else if function == "queryOrgs" { //find orgs based on an ad hoc rich query
-        return t.queryOrgs(stub, args)
-    } else if function == "getHistoryForOrg" { //get history of values for a org
-        return t.getHistoryForOrg(stub, args)
-    } else if function == "initSupplierMaterial" {
-        return t.initSupplierMaterial(stub, args)
-    } else if function == "changeOwnerMaterial" {
-        return t.changeOwnerMaterial(stub, args)
-    } else if function == "getHistoryForMaterial" {
-        return t.getHistoryForMaterial(stub, args)
-    } else if function == "queryMaterialsByOwner" {
-        return t.queryMaterialsByOwner(stub, args)
-    } else if function == "initFarmerTree" {
-        return t.initFarmerTree(stub, args)
-    } else if function == "harvestAgriProduct" {
-        return t.harvestAgriProduct(stub, args)
-    } else if function == "changeOwnerAgriProduct" {
-        return t.changeOwnerAgriProduct(stub, args)
-    } else if function == "makeProduct" {
-        return t.makeProduct(stub, args)
-    } else if function == "changeOwnerProduct" {
-        return t.changeOwnerProduct(stub, args)
-    } else if function == "queryAgriProductByOwner" {
-        return t.queryAgriProductByOwner(stub, args)
-    } else if function == "queryProductByOwner" {
-        return t.queryProductByOwner(stub, args)
-    } else if function == "getHistoryForAgriProduct" {
-        return t.getHistoryForAgriProduct(stub, args)
-    } else if function == "getHistoryForProduct" {
-        return t.getHistoryForProduct(stub, args)
-    }
-    // getHistory AgriProduct, get HistoryProduct
-    fmt.Println("invoke did not find func: " + function) //error
-    return shim.Error("Received unknown function invocation")
+    t.routerOnce.Do(func() {
+        t.router = t.newRouter()
+    })
+    return t.router.Invoke(stub)
 }
 // ============================================================
 // initSupplierMaterial - create a new material, store into chaincode state
@@ -150,44 +115,29 @@ func (t *SimpleChaincode) initSupplierMaterial(stub shim.ChaincodeStubInterface,
     // 0         1    2    3
     // bachcode name qty owner
     // 1        material1  2  1
-    if len(args) != 4 {
-        return shim.Error("Incorrect number of arguments. Expecting 4")
-    }
     // ==== Input sanitation ====
-    fmt.Println("- start init material")
-    if len(args[0]) <= 0 {
-        return shim.Error("1st argument must be a non-empty string")
-    }
-    if len(args[1]) <= 0 {
-        return shim.Error("2nd argument must be a non-empty string")
-    }
-    if len(args[2]) <= 0 {
-        return shim.Error("3rd argument must be a non-empty string")
-    }
-    if len(args[3]) <= 0 {
-        return shim.Error("4th argument must be a non-empty string")
-    }
+    logger.Debug(stub, "start init material")
     batchcode, err := strconv.Atoi(args[0])
     if err != nil {
-        return shim.Error("1rd argument must be a numeric string")
+        return ccerror.ValidationFailed("1rd argument must be a numeric string")
     }
     name := strings.ToLower(args[1])
     qty, err := strconv.Atoi(args[2])
     if err != nil {
-        return shim.Error("3rd argument must be a numeric string")
+        return ccerror.ValidationFailed("3rd argument must be a numeric string")
     }
     owner, err := strconv.Atoi(args[3])
     if err != nil {
-        return shim.Error("4rd argument must be a numeric string")
+        return ccerror.ValidationFailed("4rd argument must be a numeric string")
     }
 
     // ==== Check if org already exists ====
     supplierMaterialAsBytes, err := stub.GetState(name)
     if err != nil {
-        return shim.Error("Failed to get supplier material: " + err.Error())
+        return ccerror.Internal("Failed to get supplier material: " + err.Error())
     } else if supplierMaterialAsBytes != nil {
-        fmt.Println("This supplier material already exists: " + name)
-        return shim.Error("This supplier material already exists: " + name)
+        logger.Debug(stub, "This supplier material already exists", logging.Fields{"name": name})
+        return ccerror.AlreadyExists("This supplier material already exists: " + name)
     }
 
     // ==== Create supplierMaterial object and marshal to JSON ====
@@ -195,64 +145,61 @@ func (t *SimpleChaincode) initSupplierMaterial(stub shim.ChaincodeStubInterface,
     supplierMaterial := &supplierMaterial{objectType, batchcode, name, qty, owner}
     supplierMaterialJSONasBytes, err := json.Marshal(supplierMaterial)
     if err != nil {
-        return shim.Error(err.Error())
+        return ccerror.Internal(err.Error())
     }
 
     // === Save org to state ===
     err = stub.PutState(name, supplierMaterialJSONasBytes)
     if err != nil {
-        return shim.Error(err.Error())
+        return ccerror.Internal(err.Error())
     }
 
     indexName := "materialBatchcode-name"
     batchcodeNameIndexKey, err := stub.CreateCompositeKey(indexName, []string{strconv.Itoa(supplierMaterial.BatchCode), supplierMaterial.Name})
     if err != nil {
-        return shim.Error(err.Error())
+        return ccerror.Internal(err.Error())
     }
 
     value := []byte{0x00}
     stub.PutState(batchcodeNameIndexKey, value)
 
     // ==== org saved and indexed. Return success ====
-    fmt.Println("- end init supplier material")
+    logger.Debug(stub, "end init supplier material")
     return shim.Success(nil)
 }
 func (t *SimpleChaincode) changeOwnerMaterial(stub shim.ChaincodeStubInterface, args []string) pb.Response {
     //   0       1
     // "name", "owner"
     // "material1" "1"
-    if len(args) < 2 {
-        return shim.Error("Incorrect number of arguments. Expecting 2")
-    }
 
     owner, err:= strconv.Atoi(args[1])
     if err != nil {
-        return shim.Error("1rd argument must be a numeric string")
+        return ccerror.ValidationFailed("1rd argument must be a numeric string")
     }
     name := strings.ToLower(args[0])
-    fmt.Println("- start transferorg ", owner, name)
+    logger.Debug(stub, "start transferorg", logging.Fields{"owner": owner, "name": name})
 
     supplierMaterialAsBytes, err := stub.GetState(name)
     if err != nil {
-        return shim.Error("Failed to get supplier Material:" + err.Error())
+        return ccerror.Internal("Failed to get supplier Material:" + err.Error())
     } else if supplierMaterialAsBytes == nil {
-        return shim.Error("supplier material does not exist")
+        return ccerror.NotFound("supplier material does not exist")
     }
 
     suppplierMaterialToTransfer := supplierMaterial{}
     err = json.Unmarshal(supplierMaterialAsBytes, &suppplierMaterialToTransfer) //unmarshal it aka JSON.parse()
     if err != nil {
-        return shim.Error(err.Error())
+        return ccerror.Internal(err.Error())
     }
     suppplierMaterialToTransfer.Owner = owner //change the name
 
     supplierMaterialJSONasBytes, _ := json.Marshal(suppplierMaterialToTransfer)
     err = stub.PutState(name, supplierMaterialJSONasBytes) //rewrite the supplier Material
     if err != nil {
-        return shim.Error(err.Error())
+        return ccerror.Internal(err.Error())
     }
 
-    fmt.Println("- end transfeMaterial (success)")
+    logger.Debug(stub, "end transfeMaterial (success)")
     return shim.Success(nil)
 }
 // ============================================================
@@ -263,39 +210,32 @@ func (t *SimpleChaincode) initOrg(stub shim.ChaincodeStubInterface, args []strin
 
     //   0       1       2     3
     // "1", "adf", "1", "67.0006, -70.5476"
-    if len(args) != 4 {
-        return shim.Error("Incorrect number of arguments. Expecting 4")
-    }
 
     // ==== Input sanitation ====
-    fmt.Println("- start init org")
-    if len(args[0]) <= 0 {
-        return shim.Error("1st argument must be a non-empty string")
-    }
-    if len(args[1]) <= 0 {
-        return shim.Error("2nd argument must be a non-empty string")
-    }
-    if len(args[2]) <= 0 {
-        return shim.Error("3rd argument must be a non-empty string")
-    }
-    if len(args[3]) <= 0 {
-        return shim.Error("4th argument must be a non-empty string")
-    }
+    logger.Debug(stub, "start init org")
     orgId, err := strconv.Atoi(args[0])
     if err != nil {
-        return shim.Error("3rd argument must be a numeric string")
+        return ccerror.ValidationFailed("3rd argument must be a numeric string")
     }
     orgName := args[1]
     orgType := strings.ToLower(args[2])
     orgLocation := strings.ToLower(args[3])
 
+    // ==== Check if the config allows the org type ====
+    config, err := readConfig(stub)
+    if err != nil {
+        return ccerror.Internal("Failed to get config: " + err.Error())
+    } else if !containsString(config.OrgTypes, orgType) {
+        return ccerror.ValidationFailed("Org type " + orgType + " is not allowed by the config")
+    }
+
     // ==== Check if org already exists ====
     orgAsBytes, err := stub.GetState(strconv.Itoa(orgId))
     if err != nil {
-        return shim.Error("Failed to get org: " + err.Error())
+        return ccerror.Internal("Failed to get org: " + err.Error())
     } else if orgAsBytes != nil {
-        fmt.Println("This org already exists: " + strconv.Itoa(orgId))
-        return shim.Error("This org already exists: " + strconv.Itoa(orgId))
+        logger.Debug(stub, "This org already exists", logging.Fields{"orgId": orgId})
+        return ccerror.AlreadyExists("This org already exists: " + strconv.Itoa(orgId))
     }
 
     // ==== Create org object and marshal to JSON ====
@@ -303,13 +243,13 @@ func (t *SimpleChaincode) initOrg(stub shim.ChaincodeStubInterface, args []strin
     org := &org{objectType, orgId, orgName, orgType, orgLocation}
     orgJSONasBytes, err := json.Marshal(org)
     if err != nil {
-        return shim.Error(err.Error())
+        return ccerror.Internal(err.Error())
     }
 
     // === Save org to state ===
     err = stub.PutState(strconv.Itoa(orgId), orgJSONasBytes)
     if err != nil {
-        return shim.Error(err.Error())
+        return ccerror.Internal(err.Error())
     }
 
     //  ==== Index the org to enable color-based range queries, e.g. return all blue orgs ====
@@ -320,7 +260,7 @@ func (t *SimpleChaincode) initOrg(stub shim.ChaincodeStubInterface, args []strin
     indexName := "orgType~name"
     orgTypeNameIndexKey, err := stub.CreateCompositeKey(indexName, []string{org.OrgType, org.Name})
     if err != nil {
-        return shim.Error(err.Error())
+        return ccerror.Internal(err.Error())
     }
     //  Save index entry to state. Only the key name is needed, no need to store a duplicate copy of the org.
     //  Note - passing a 'nil' value will effectively delete the key from state, therefore we pass null character as value
@@ -328,7 +268,7 @@ func (t *SimpleChaincode) initOrg(stub shim.ChaincodeStubInterface, args []strin
     stub.PutState(orgTypeNameIndexKey, value)
 
     // ==== org saved and indexed. Return success ====
-    fmt.Println("- end init org")
+    logger.Debug(stub, "end init org")
     return shim.Success(nil)
 }
 
@@ -336,21 +276,15 @@ func (t *SimpleChaincode) initOrg(stub shim.ChaincodeStubInterface, args []strin
 // readorg - read a org from chaincode state
 // ===============================================
 func (t *SimpleChaincode) readOrg(stub shim.ChaincodeStubInterface, args []string) pb.Response {
-    var orgId, jsonResp string
+    var orgId string
     var err error
 
-    if len(args) != 1 {
-        return shim.Error("Incorrect number of arguments. Expecting name of the org to query")
-    }
-
     orgId = args[0]
     valAsbytes, err := stub.GetState(orgId) //get the org from chaincode state
     if err != nil {
-        jsonResp = "{\"Error\":\"Failed to get state for " + orgId + "\"}"
-        return shim.Error(jsonResp)
+        return ccerror.Internal("Failed to get state for " + orgId)
     } else if valAsbytes == nil {
-        jsonResp = "{\"Error\":\"org does not exist: " + orgId + "\"}"
-        return shim.Error(jsonResp)
+        return ccerror.NotFound("org does not exist: " + orgId)
     }
 
     return shim.Success(valAsbytes)
@@ -360,48 +294,41 @@ func (t *SimpleChaincode) readOrg(stub shim.ChaincodeStubInterface, args []strin
 // delete - remove a org key/value pair from state
 // ==================================================
 func (t *SimpleChaincode) delete(stub shim.ChaincodeStubInterface, args []string) pb.Response {
-    var jsonResp string
     var orgJSON org
-    if len(args) != 1 {
-        return shim.Error("Incorrect number of arguments. Expecting 1")
-    }
     orgId, err:= strconv.Atoi(args[0])
     if err != nil {
-        return shim.Error("1rd argument must be a numeric string")
+        return ccerror.ValidationFailed("1rd argument must be a numeric string")
     }
 
     // to maintain the color~name index, we need to read the org first and get its color
     valAsbytes, err := stub.GetState(strconv.Itoa(orgId)) //get the org from chaincode state
     if err != nil {
-        jsonResp = "{\"Error\":\"Failed to get state for " + strconv.Itoa(orgId) + "\"}"
-        return shim.Error(jsonResp)
+        return ccerror.Internal("Failed to get state for " + strconv.Itoa(orgId))
     } else if valAsbytes == nil {
-        jsonResp = "{\"Error\":\"org does not exist: " + strconv.Itoa(orgId) + "\"}"
-        return shim.Error(jsonResp)
+        return ccerror.NotFound("org does not exist: " + strconv.Itoa(orgId))
     }
 
     err = json.Unmarshal([]byte(valAsbytes), &orgJSON)
     if err != nil {
-        jsonResp = "{\"Error\":\"Failed to decode JSON of: " + strconv.Itoa(orgId) + "\"}"
-        return shim.Error(jsonResp)
+        return ccerror.Internal("Failed to decode JSON of: " + strconv.Itoa(orgId))
     }
 
     err = stub.DelState(strconv.Itoa(orgId)) //remove the org from chaincode state
     if err != nil {
-        return shim.Error("Failed to delete state:" + err.Error())
+        return ccerror.Internal("Failed to delete state:" + err.Error())
     }
 
     // maintain the index
     indexName := "orgType~name"
     orgTypeNameIndexKey, err := stub.CreateCompositeKey(indexName, []string{orgJSON.OrgType, orgJSON.Name})
     if err != nil {
-        return shim.Error(err.Error())
+        return ccerror.Internal(err.Error())
     }
 
     //  Delete index entry to state.
     err = stub.DelState(orgTypeNameIndexKey)
     if err != nil {
-        return shim.Error("Failed to delete state:" + err.Error())
+        return ccerror.Internal("Failed to delete state:" + err.Error())
     }
     return shim.Success(nil)
 }
@@ -413,38 +340,35 @@ func (t *SimpleChaincode) changeOrg(stub shim.ChaincodeStubInterface, args []str
 
     //   0       1
     // "1", "org1"
-    if len(args) < 2 {
-        return shim.Error("Incorrect number of arguments. Expecting 2")
-    }
 
     orgId, err:= strconv.Atoi(args[0])
     if err != nil {
-        return shim.Error("1rd argument must be a numeric string")
+        return ccerror.ValidationFailed("1rd argument must be a numeric string")
     }
     newOrgName := strings.ToLower(args[1])
-    fmt.Println("- start transferorg ", orgId, newOrgName)
+    logger.Debug(stub, "start transferorg", logging.Fields{"orgId": orgId, "newOrgName": newOrgName})
 
     orgAsBytes, err := stub.GetState(strconv.Itoa(orgId))
     if err != nil {
-        return shim.Error("Failed to get org:" + err.Error())
+        return ccerror.Internal("Failed to get org:" + err.Error())
     } else if orgAsBytes == nil {
-        return shim.Error("org does not exist")
+        return ccerror.NotFound("org does not exist")
     }
 
     orgToTransfer := org{}
     err = json.Unmarshal(orgAsBytes, &orgToTransfer) //unmarshal it aka JSON.parse()
     if err != nil {
-        return shim.Error(err.Error())
+        return ccerror.Internal(err.Error())
     }
     orgToTransfer.Name = newOrgName //change the name
 
     orgJSONasBytes, _ := json.Marshal(orgToTransfer)
     err = stub.PutState(strconv.Itoa(orgId), orgJSONasBytes) //rewrite the org
     if err != nil {
-        return shim.Error(err.Error())
+        return ccerror.Internal(err.Error())
     }
 
-    fmt.Println("- end transferorg (success)")
+    logger.Debug(stub, "end transferorg (success)")
     return shim.Success(nil)
 }
 
@@ -452,9 +376,6 @@ func (t *SimpleChaincode) queryOrgsByType(stub shim.ChaincodeStubInterface, args
 
     //   0
     // "bob"
-    if len(args) < 1 {
-        return shim.Error("Incorrect number of arguments. Expecting 1")
-    }
 
     orgType := strings.ToLower(args[0])
 
@@ -462,7 +383,7 @@ func (t *SimpleChaincode) queryOrgsByType(stub shim.ChaincodeStubInterface, args
 
     queryResults, err := getQueryResultForQueryString(stub, queryString)
     if err != nil {
-        return shim.Error(err.Error())
+        return ccerror.Internal(err.Error())
     }
     return shim.Success(queryResults)
 }
@@ -478,15 +399,12 @@ func (t *SimpleChaincode) queryOrgs(stub shim.ChaincodeStubInterface, args []str
 
     //   0
     // "queryString"
-    if len(args) < 1 {
-        return shim.Error("Incorrect number of arguments. Expecting 1")
-    }
 
     queryString := args[0]
 
     queryResults, err := getQueryResultForQueryString(stub, queryString)
     if err != nil {
-        return shim.Error(err.Error())
+        return ccerror.Internal(err.Error())
     }
     return shim.Success(queryResults)
 }
@@ -497,7 +415,7 @@ func (t *SimpleChaincode) queryOrgs(stub shim.ChaincodeStubInterface, args []str
 // =========================================================================================
 func getQueryResultForQueryString(stub shim.ChaincodeStubInterface, queryString string) ([]byte, error) {
 
-    fmt.Printf("- getQueryResultForQueryString queryString:\n%s\n", queryString)
+    logger.Debug(stub, "start getQueryResultForQueryString")
 
     resultsIterator, err := stub.GetQueryResult(queryString)
     if err != nil {
@@ -510,6 +428,7 @@ func getQueryResultForQueryString(stub shim.ChaincodeStubInterface, queryString
     buffer.WriteString("[")
 
     bArrayMemberAlreadyWritten := false
+    count := 0
     for resultsIterator.HasNext() {
         queryResponse, err := resultsIterator.Next()
         if err != nil {
@@ -529,29 +448,26 @@ func getQueryResultForQueryString(stub shim.ChaincodeStubInterface, queryString
         buffer.WriteString(string(queryResponse.Value))
         buffer.WriteString("}")
         bArrayMemberAlreadyWritten = true
+        count++
     }
     buffer.WriteString("]")
 
-    fmt.Printf("- getQueryResultForQueryString queryResult:\n%s\n", buffer.String())
+    logger.Debug(stub, "end getQueryResultForQueryString (success)", logging.Fields{"results": count})
 
     return buffer.Bytes(), nil
 }
 
 func (t *SimpleChaincode) getHistoryForOrg(stub shim.ChaincodeStubInterface, args []string) pb.Response {
 
-    if len(args) < 1 {
-        return shim.Error("Incorrect number of arguments. Expecting 1")
-    }
-
     orgId, err:= strconv.Atoi(args[0])
     if err != nil {
-        return shim.Error("1rd argument must be a numeric string")
+        return ccerror.ValidationFailed("1rd argument must be a numeric string")
     }
-    fmt.Printf("- start getHistoryForOrg: %s\n", strconv.Itoa(orgId))
+    logger.Debug(stub, "start getHistoryForOrg", logging.Fields{"orgId": orgId})
 
     resultsIterator, err := stub.GetHistoryForKey(strconv.Itoa(orgId))
     if err != nil {
-        return shim.Error(err.Error())
+        return ccerror.Internal(err.Error())
     }
     defer resultsIterator.Close()
 
@@ -560,10 +476,11 @@ func (t *SimpleChaincode) getHistoryForOrg(stub shim.ChaincodeStubInterface, arg
     buffer.WriteString("[")
 
     bArrayMemberAlreadyWritten := false
+    count := 0
     for resultsIterator.HasNext() {
         response, err := resultsIterator.Next()
         if err != nil {
-            return shim.Error(err.Error())
+            return ccerror.Internal(err.Error())
         }
         // Add a comma before array members, suppress it for the first array member
         if bArrayMemberAlreadyWritten == true {
@@ -596,26 +513,23 @@ func (t *SimpleChaincode) getHistoryForOrg(stub shim.ChaincodeStubInterface, arg
 
         buffer.WriteString("}")
         bArrayMemberAlreadyWritten = true
+        count++
     }
     buffer.WriteString("]")
 
-    fmt.Printf("- getHistoryForOrg returning:\n%s\n", buffer.String())
+    logger.Debug(stub, "end getHistoryForOrg (success)", logging.Fields{"results": count})
 
     return shim.Success(buffer.Bytes())
 }
 
 func (t *SimpleChaincode) getHistoryForMaterial(stub shim.ChaincodeStubInterface, args []string) pb.Response {
 
-    if len(args) < 1 {
-        return shim.Error("Incorrect number of arguments. Expecting 1")
-    }
-
     name := strings.ToLower(args[0])
-    fmt.Printf("- start getHistoryForMaterial: %s\n", name)
+    logger.Debug(stub, "start getHistoryForMaterial", logging.Fields{"name": name})
 
     resultsIterator, err := stub.GetHistoryForKey(name)
     if err != nil {
-        return shim.Error(err.Error())
+        return ccerror.Internal(err.Error())
     }
     defer resultsIterator.Close()
 
@@ -624,10 +538,11 @@ func (t *SimpleChaincode) getHistoryForMaterial(stub shim.ChaincodeStubInterface
     buffer.WriteString("[")
 
     bArrayMemberAlreadyWritten := false
+    count := 0
     for resultsIterator.HasNext() {
         response, err := resultsIterator.Next()
         if err != nil {
-            return shim.Error(err.Error())
+            return ccerror.Internal(err.Error())
         }
         // Add a comma before array members, suppress it for the first array member
         if bArrayMemberAlreadyWritten == true {
@@ -660,10 +575,11 @@ func (t *SimpleChaincode) getHistoryForMaterial(stub shim.ChaincodeStubInterface
 
         buffer.WriteString("}")
         bArrayMemberAlreadyWritten = true
+        count++
     }
     buffer.WriteString("]")
 
-    fmt.Printf("- getHistoryForMaterial returning:\n%s\n", buffer.String())
+    logger.Debug(stub, "end getHistoryForMaterial (success)", logging.Fields{"results": count})
 
     return shim.Success(buffer.Bytes())
 }
@@ -672,9 +588,6 @@ func (t *SimpleChaincode) queryMaterialsByOwner(stub shim.ChaincodeStubInterface
 
     //   0
     // "bob"
-    if len(args) < 1 {
-        return shim.Error("Incorrect number of arguments. Expecting 1")
-    }
 
     owner := strings.ToLower(args[0])
 
@@ -682,7 +595,7 @@ func (t *SimpleChaincode) queryMaterialsByOwner(stub shim.ChaincodeStubInterface
 
     queryResults, err := getQueryResultForQueryString(stub, queryString)
     if err != nil {
-        return shim.Error(err.Error())
+        return ccerror.Internal(err.Error())
     }
     return shim.Success(queryResults)
 }
@@ -703,47 +616,17 @@ func (t *SimpleChaincode) initFarmerTree(stub shim.ChaincodeStubInterface, args
     Owner int `json:"owner"` 7
     RateHarvest int `json:"rateharvest"` 8
     */
-    if len(args) != 9 {
-        return shim.Error("Incorrect number of arguments. Expecting 9")
-    }
     // ==== Input sanitation ====
-    fmt.Println("- start init material")
-    if len(args[0]) <= 0 {
-        return shim.Error("1st argument must be a non-empty string")
-    }
-    if len(args[1]) <= 0 {
-        return shim.Error("2nd argument must be a non-empty string")
-    }
-    if len(args[2]) <= 0 {
-        return shim.Error("3rd argument must be a non-empty string")
-    }
-    if len(args[3]) <= 0 {
-        return shim.Error("4th argument must be a non-empty string")
-    }
-    if len(args[4]) <= 0 {
-        return shim.Error("5th argument must be a non-empty string")
-    }
-    if len(args[5]) <= 0 {
-        return shim.Error("6th argument must be a non-empty string")
-    }
-    if len(args[6]) <= 0 {
-        return shim.Error("7th argument must be a non-empty string")
-    }
-    if len(args[7]) <= 0 {
-        return shim.Error("8th argument must be a non-empty string")
-    }
-    if len(args[8]) <= 0 {
-        return shim.Error("9th argument must be a non-empty string")
-    }
+    logger.Debug(stub, "start init material")
 
     treeId, err := strconv.Atoi(args[0])
     if err != nil {
-        return shim.Error("1rd argument must be a numeric string")
+        return ccerror.ValidationFailed("1rd argument must be a numeric string")
     }
     name := strings.ToLower(args[1])
     qty, err := strconv.Atoi(args[2])
     if err != nil {
-        return shim.Error("3rd argument must be a numeric string")
+        return ccerror.ValidationFailed("3rd argument must be a numeric string")
     }
     starttime := strings.ToLower(args[3])
     endtime := strings.ToLower(args[4])
@@ -751,20 +634,20 @@ func (t *SimpleChaincode) initFarmerTree(stub shim.ChaincodeStubInterface, args
     location := strings.ToLower(args[6])
     owner, err := strconv.Atoi(args[7])
     if err != nil {
-        return shim.Error("8rd argument must be a numeric string")
+        return ccerror.ValidationFailed("8rd argument must be a numeric string")
     }
     harvestrate, err := strconv.Atoi(args[8])
     if err != nil {
-        return shim.Error("9rd argument must be a numeric string")
+        return ccerror.ValidationFailed("9rd argument must be a numeric string")
     }
 
     // ==== Check if farmerTree already exists ====
     farmerTreeAsBytes, err := stub.GetState(name)
     if err != nil {
-        return shim.Error("Failed to get tree: " + err.Error())
+        return ccerror.Internal("Failed to get tree: " + err.Error())
     } else if farmerTreeAsBytes != nil {
-        fmt.Println("This stree already exists: " + name)
-        return shim.Error("This tree exists: " + name)
+        logger.Debug(stub, "This stree already exists", logging.Fields{"name": name})
+        return ccerror.AlreadyExists("This tree exists: " + name)
     }
 
     // ==== Create farmerTreeAs object and marshal to JSON ====
@@ -772,19 +655,19 @@ func (t *SimpleChaincode) initFarmerTree(stub shim.ChaincodeStubInterface, args
     farmerTree := &farmerTree{objectType, treeId, name, qty, starttime, endtime, livetime, location, owner, harvestrate}
     farmerTreeJSONasBytes, err := json.Marshal(farmerTree)
     if err != nil {
-        return shim.Error(err.Error())
+        return ccerror.Internal(err.Error())
     }
 
     // === Save org to state ===
     err = stub.PutState(name, farmerTreeJSONasBytes)
     if err != nil {
-        return shim.Error(err.Error())
+        return ccerror.Internal(err.Error())
     }
 
     indexName := "owner-name"
     ownerNameIndexKey, err := stub.CreateCompositeKey(indexName, []string{strconv.Itoa(farmerTree.Owner), farmerTree.Name})
     if err != nil {
-        return shim.Error(err.Error())
+        return ccerror.Internal(err.Error())
     }
 
     value := []byte{0x00}
@@ -796,27 +679,27 @@ func (t *SimpleChaincode) initFarmerTree(stub shim.ChaincodeStubInterface, args
         org := &org{objectType, treeId, name, "6", "67.0006, -70.5476"}
         orgJSONasBytes, err := json.Marshal(org)
         if err != nil {
-            return shim.Error(err.Error())
+            return ccerror.Internal(err.Error())
         }
     
         // === Save org to state ===
         err = stub.PutState(strconv.Itoa(treeId), orgJSONasBytes)
         if err != nil {
-            return shim.Error(err.Error())
+            return ccerror.Internal(err.Error())
         }
         indexName := "orgType~name"
         orgTypeNameIndexKey, err := stub.CreateCompositeKey(indexName, []string{org.OrgType, org.Name})
         if err != nil {
-            return shim.Error(err.Error())
+            return ccerror.Internal(err.Error())
         }
 
         value := []byte{0x00}
         stub.PutState(orgTypeNameIndexKey, value)
 
-        fmt.Println("- end init org")
+        logger.Debug(stub, "end init org")
     }
 
-    fmt.Println("- end init supplier farmerTree")
+    logger.Debug(stub, "end init supplier farmerTree")
     return shim.Success(nil)
 }
 
@@ -831,51 +714,33 @@ func (t *SimpleChaincode) harvestAgriProduct(stub shim.ChaincodeStubInterface, a
     */
     var err error
  
-    if len(args) != 6 {
-        return shim.Error("Incorrect number of arguments. Expecting 5")
-    }
     // ==== Input sanitation ====
-    fmt.Println("- start argiProductHarvest")
-    if len(args[0]) <= 0 {
-        return shim.Error("1st argument must be a non-empty string")
-    }
-    if len(args[1]) <= 0 {
-        return shim.Error("2nd argument must be a non-empty string")
-    }
-    if len(args[2]) <= 0 {
-        return shim.Error("3rd argument must be a non-empty string")
-    }
-    if len(args[3]) <= 0 {
-        return shim.Error("4th argument must be a non-empty string")
-    }
-    if len(args[4]) <= 0 {
-        return shim.Error("5th argument must be a non-empty string")
-    }
+    logger.Debug(stub, "start argiProductHarvest")
     batchcode, err := strconv.Atoi(args[0])
     if err != nil {
-        return shim.Error("1rd argument must be a numeric string")
+        return ccerror.ValidationFailed("1rd argument must be a numeric string")
     }
     name := strings.ToLower(args[1])
     treeId, err := strconv.Atoi(args[2])
     if err != nil {
-        return shim.Error("3rd argument must be a numeric string")
+        return ccerror.ValidationFailed("3rd argument must be a numeric string")
     }
     qty, err := strconv.Atoi(args[3])
     if err != nil {
-        return shim.Error("4rd argument must be a numeric string")
+        return ccerror.ValidationFailed("4rd argument must be a numeric string")
     }
     owner, err := strconv.Atoi(args[4])
     if err != nil {
-        return shim.Error("5rd argument must be a numeric string")
+        return ccerror.ValidationFailed("5rd argument must be a numeric string")
     }
 
     // ==== Check if agriProduct already exists ====
     supplierMaterialAsBytes, err := stub.GetState(name)
     if err != nil {
-        return shim.Error("Failed to get supplier material: " + err.Error())
+        return ccerror.Internal("Failed to get supplier material: " + err.Error())
     } else if supplierMaterialAsBytes != nil {
-        fmt.Println("This supplier material already exists: " + name)
-        return shim.Error("This supplier material already exists: " + name)
+        logger.Debug(stub, "This supplier material already exists", logging.Fields{"name": name})
+        return ccerror.AlreadyExists("This supplier material already exists: " + name)
     }
 
     // ==== Create agriproduct object and marshal to JSON ====
@@ -883,26 +748,26 @@ func (t *SimpleChaincode) harvestAgriProduct(stub shim.ChaincodeStubInterface, a
     agriProduct := &agriProduct{objectType, batchcode, name, treeId, qty, owner}
     agriProductJSONasBytes, err := json.Marshal(agriProduct)
     if err != nil {
-        return shim.Error(err.Error())
+        return ccerror.Internal(err.Error())
     }
 
     // === Save org to state ===
     err = stub.PutState(name, agriProductJSONasBytes)
     if err != nil {
-        return shim.Error(err.Error())
+        return ccerror.Internal(err.Error())
     }
 
     indexName := "owner-name"
     ownerNameIndexKey, err := stub.CreateCompositeKey(indexName, []string{strconv.Itoa(agriProduct.Owner), agriProduct.Name})
     if err != nil {
-        return shim.Error(err.Error())
+        return ccerror.Internal(err.Error())
     }
 
     value := []byte{0x00}
     stub.PutState(ownerNameIndexKey, value)
 
     // ==== agriProduct saved and indexed. Return success ====
-    fmt.Println("- end harvestAgriProduct")
+    logger.Debug(stub, "end harvestAgriProduct")
     return shim.Success(nil)
 }
 
@@ -910,38 +775,35 @@ func (t *SimpleChaincode) changeOwnerAgriProduct(stub shim.ChaincodeStubInterfac
     //   0       1
     // "name", "owner"
     // "agriproduct1" "1"
-    if len(args) < 2 {
-        return shim.Error("Incorrect number of arguments. Expecting 2")
-    }
 
     name := strings.ToLower(args[0])
     owner, err:= strconv.Atoi(args[1])
     if err != nil {
-        return shim.Error("1rd argument must be a numeric string")
+        return ccerror.ValidationFailed("1rd argument must be a numeric string")
     }
-    fmt.Println("- start transAgriproduct ", owner, name)
+    logger.Debug(stub, "start transAgriproduct", logging.Fields{"owner": owner, "name": name})
 
     agriProductAsBytes, err := stub.GetState(name)
     if err != nil {
-        return shim.Error("Failed to get agri product:" + err.Error())
+        return ccerror.Internal("Failed to get agri product:" + err.Error())
     } else if agriProductAsBytes == nil {
-        return shim.Error("supplier material does not exist")
+        return ccerror.NotFound("supplier material does not exist")
     }
 
     agriProductToTransfer := agriProduct{}
     err = json.Unmarshal(agriProductAsBytes, &agriProductToTransfer) //unmarshal it aka JSON.parse()
     if err != nil {
-        return shim.Error(err.Error())
+        return ccerror.Internal(err.Error())
     }
     agriProductToTransfer.Owner = owner //change the name
 
     agriProductJSONasBytes, _ := json.Marshal(agriProductToTransfer)
     err = stub.PutState(name, agriProductJSONasBytes) //rewrite the supplier Material
     if err != nil {
-        return shim.Error(err.Error())
+        return ccerror.Internal(err.Error())
     }
 
-    fmt.Println("- end transfeAgriProduct (success)")
+    logger.Debug(stub, "end transfeAgriProduct (success)")
     return shim.Success(nil)
 }
 func (t *SimpleChaincode) makeProduct(stub shim.ChaincodeStubInterface, args []string) pb.Response {
@@ -955,51 +817,33 @@ func (t *SimpleChaincode) makeProduct(stub shim.ChaincodeStubInterface, args []s
     */
     var err error
  
-    if len(args) != 5 {
-        return shim.Error("Incorrect number of arguments. Expecting 5")
-    }
     // ==== Input sanitation ====
-    fmt.Println("- start make product")
-    if len(args[0]) <= 0 {
-        return shim.Error("1st argument must be a non-empty string")
-    }
-    if len(args[1]) <= 0 {
-        return shim.Error("2nd argument must be a non-empty string")
-    }
-    if len(args[2]) <= 0 {
-        return shim.Error("3rd argument must be a non-empty string")
-    }
-    if len(args[3]) <= 0 {
-        return shim.Error("4th argument must be a non-empty string")
-    }
-    if len(args[4]) <= 0 {
-        return shim.Error("5th argument must be a non-empty string")
-    }
+    logger.Debug(stub, "start make product")
     aProductBatchCode, err := strconv.Atoi(args[0])
     if err != nil {
-        return shim.Error("1rd argument must be a numeric string")
+        return ccerror.ValidationFailed("1rd argument must be a numeric string")
     }
     productBatchCode, err := strconv.Atoi(args[1])
     if err != nil {
-        return shim.Error("2rd argument must be a numeric string")
+        return ccerror.ValidationFailed("2rd argument must be a numeric string")
     }
     name := strings.ToLower(args[2])
     qty, err := strconv.Atoi(args[3])
     if err != nil {
-        return shim.Error("4rd argument must be a numeric string")
+        return ccerror.ValidationFailed("4rd argument must be a numeric string")
     }
     owner, err := strconv.Atoi(args[4])
     if err != nil {
-        return shim.Error("5rd argument must be a numeric string")
+        return ccerror.ValidationFailed("5rd argument must be a numeric string")
     }
 
     // ==== Check if Product already exists ====
     productAsBytes, err := stub.GetState(name)
     if err != nil {
-        return shim.Error("Failed to get supplier material: " + err.Error())
+        return ccerror.Internal("Failed to get supplier material: " + err.Error())
     } else if productAsBytes != nil {
-        fmt.Println("This supplier material already exists: " + name)
-        return shim.Error("This supplier material already exists: " + name)
+        logger.Debug(stub, "This supplier material already exists", logging.Fields{"name": name})
+        return ccerror.AlreadyExists("This supplier material already exists: " + name)
     }
 
     // ==== Create agriproduct object and marshal to JSON ====
@@ -1007,64 +851,61 @@ func (t *SimpleChaincode) makeProduct(stub shim.ChaincodeStubInterface, args []s
     product := &product{objectType, aProductBatchCode, productBatchCode, name, qty, owner}
     productJSONasBytes, err := json.Marshal(product)
     if err != nil {
-        return shim.Error(err.Error())
+        return ccerror.Internal(err.Error())
     }
 
     // === Save product to state ===
     err = stub.PutState(name, productJSONasBytes)
     if err != nil {
-        return shim.Error(err.Error())
+        return ccerror.Internal(err.Error())
     }
 
     indexName := "owner-name"
     ownerNameIndexKey, err := stub.CreateCompositeKey(indexName, []string{strconv.Itoa(product.Owner), product.Name})
     if err != nil {
-        return shim.Error(err.Error())
+        return ccerror.Internal(err.Error())
     }
 
     value := []byte{0x00}
     stub.PutState(ownerNameIndexKey, value)
 
     // ==== product saved and indexed. Return success ====
-    fmt.Println("- end make product")
+    logger.Debug(stub, "end make product")
     return shim.Success(nil)
 }
  func (t *SimpleChaincode) changeOwnerProduct(stub shim.ChaincodeStubInterface, args []string) pb.Response {
     //   0       1
     // "name", "owner"
     // "product1" "1"
-    if len(args) < 2 {
-        return shim.Error("Incorrect number of arguments. Expecting 2")
-    }
 
     name := strings.ToLower(args[0])
     owner, err:= strconv.Atoi(args[1])
     if err != nil {
-        return shim.Error("1rd argument must be a numeric string")
+        return ccerror.ValidationFailed("1rd argument must be a numeric string")
     }
-    fmt.Println("- start transferProduct ", owner, name)
+    logger.Debug(stub, "start transferProduct", logging.Fields{"owner": owner, "name": name})
 
     productAsBytes, err := stub.GetState(name)
     if err != nil {
-        return shim.Error("Failed to get product:" + err.Error())
+        return ccerror.Internal("Failed to get product:" + err.Error())
     } else if productAsBytes == nil {
-        return shim.Error("supplier material does not exist")
+        return ccerror.NotFound("supplier material does not exist")
     }
 
     productToTransfer := agriProduct{}
     err = json.Unmarshal(productAsBytes, &productToTransfer) //unmarshal it aka JSON.parse()
     if err != nil {
-        return shim.Error(err.Error())
+        return ccerror.Internal(err.Error())
     }
     productToTransfer.Owner = owner //change the name
 
     productJSONasBytes, _ := json.Marshal(productToTransfer)
     err = stub.PutState(name, productJSONasBytes) //rewrite the product
     if err != nil {
-        return shim.Error(err.Error())
+        return ccerror.Internal(err.Error())
     }
 
-    fmt.Println("- end transferProduct (success)")
+    logger.Debug(stub, "end transferProduct (success)")
     return shim.Success(nil)
 }
 
@@ -1072,9 +913,6 @@ func (t *SimpleChaincode) queryAgriProductByOwner(stub shim.ChaincodeStubInterfa
 
     //   0
     // "bob"
-    if len(args) < 1 {
-        return shim.Error("Incorrect number of arguments. Expecting 1")
-    }
 
     owner := strings.ToLower(args[0])
 
@@ -1082,7 +920,7 @@ func (t *SimpleChaincode) queryAgriProductByOwner(stub shim.ChaincodeStubInterfa
 
     queryResults, err := getQueryResultForQueryString(stub, queryString)
     if err != nil {
-        return shim.Error(err.Error())
+        return ccerror.Internal(err.Error())
     }
     return shim.Success(queryResults)
 }
@@ -1090,9 +928,6 @@ func (t *SimpleChaincode) queryProductByOwner(stub shim.ChaincodeStubInterface,
 
     //   0
     // "bob"
-    if len(args) < 1 {
-        return shim.Error("Incorrect number of arguments. Expecting 1")
-    }
 
     owner := strings.ToLower(args[0])
 
@@ -1100,22 +935,18 @@ func (t *SimpleChaincode) queryProductByOwner(stub shim.ChaincodeStubInterface,
 
     queryResults, err := getQueryResultForQueryString(stub, queryString)
     if err != nil {
-        return shim.Error(err.Error())
+        return ccerror.Internal(err.Error())
     }
     return shim.Success(queryResults)
 }
 func (t *SimpleChaincode) getHistoryForAgriProduct(stub shim.ChaincodeStubInterface, args []string) pb.Response {
 
-    if len(args) < 1 {
-        return shim.Error("Incorrect number of arguments. Expecting 1")
-    }
-
     name := strings.ToLower(args[0])
-    fmt.Printf("- start getHistoryForAgriProduct: %s\n", name)
+    logger.Debug(stub, "start getHistoryForAgriProduct", logging.Fields{"name": name})
 
     resultsIterator, err := stub.GetHistoryForKey(name)
     if err != nil {
-        return shim.Error(err.Error())
+        return ccerror.Internal(err.Error())
     }
     defer resultsIterator.Close()
 
@@ -1124,10 +955,11 @@ func (t *SimpleChaincode) getHistoryForAgriProduct(stub shim.ChaincodeStubInterf
     buffer.WriteString("[")
 
     bArrayMemberAlreadyWritten := false
+    count := 0
     for resultsIterator.HasNext() {
         response, err := resultsIterator.Next()
         if err != nil {
-            return shim.Error(err.Error())
+            return ccerror.Internal(err.Error())
         }
         // Add a comma before array members, suppress it for the first array member
         if bArrayMemberAlreadyWritten == true {
@@ -1160,26 +992,23 @@ func (t *SimpleChaincode) getHistoryForAgriProduct(stub shim.ChaincodeStubInterf
 
         buffer.WriteString("}")
         bArrayMemberAlreadyWritten = true
+        count++
     }
     buffer.WriteString("]")
 
-    fmt.Printf("- getHistoryForAgriProduct returning:\n%s\n", buffer.String())
+    logger.Debug(stub, "end getHistoryForAgriProduct (success)", logging.Fields{"results": count})
 
     return shim.Success(buffer.Bytes())
 }
 
 func (t *SimpleChaincode) getHistoryForProduct(stub shim.ChaincodeStubInterface, args []string) pb.Response {
 
-    if len(args) < 1 {
-        return shim.Error("Incorrect number of arguments. Expecting 1")
-    }
-
     name := strings.ToLower(args[0])
-    fmt.Printf("- start getHistoryForProduct: %s\n", name)
+    logger.Debug(stub, "start getHistoryForProduct", logging.Fields{"name": name})
 
     resultsIterator, err := stub.GetHistoryForKey(name)
     if err != nil {
-        return shim.Error(err.Error())
+        return ccerror.Internal(err.Error())
     }
     defer resultsIterator.Close()
 
@@ -1188,10 +1017,11 @@ func (t *SimpleChaincode) getHistoryForProduct(stub shim.ChaincodeStubInterface,
     buffer.WriteString("[")
 
     bArrayMemberAlreadyWritten := false
+    count := 0
     for resultsIterator.HasNext() {
         response, err := resultsIterator.Next()
         if err != nil {
-            return shim.Error(err.Error())
+            return ccerror.Internal(err.Error())
         }
         // Add a comma before array members, suppress it for the first array member
         if bArrayMemberAlreadyWritten == true {
@@ -1224,10 +1054,11 @@ func (t *SimpleChaincode) getHistoryForProduct(stub shim.ChaincodeStubInterface,
 
         buffer.WriteString("}")
         bArrayMemberAlreadyWritten = true
+        count++
     }
     buffer.WriteString("]")
 
-    fmt.Printf("- getHistoryForProduct returning:\n%s\n", buffer.String())
+    logger.Debug(stub, "end getHistoryForProduct (success)", logging.Fields{"results": count})
 
     return shim.Success(buffer.Bytes())
 }